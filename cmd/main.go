@@ -1,16 +1,36 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 
 	"github.com/ghulammuzz/backend-parkerin/config"
 	applicants "github.com/ghulammuzz/backend-parkerin/internal/applicants/di"
+	appRepo "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
+	appService "github.com/ghulammuzz/backend-parkerin/internal/applicants/svc"
+	attendance "github.com/ghulammuzz/backend-parkerin/internal/attendance/di"
+	deposits "github.com/ghulammuzz/backend-parkerin/internal/deposits/di"
+	employments "github.com/ghulammuzz/backend-parkerin/internal/employments/di"
 	health "github.com/ghulammuzz/backend-parkerin/internal/health"
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	"github.com/ghulammuzz/backend-parkerin/internal/migration"
+	packages "github.com/ghulammuzz/backend-parkerin/internal/packages/di"
+	parking "github.com/ghulammuzz/backend-parkerin/internal/parking/di"
+	parkRepo "github.com/ghulammuzz/backend-parkerin/internal/parking/repo"
+	parkSvc "github.com/ghulammuzz/backend-parkerin/internal/parking/svc"
+	payment "github.com/ghulammuzz/backend-parkerin/internal/payment/di"
+	payRepo "github.com/ghulammuzz/backend-parkerin/internal/payment/repo"
+	paySvc "github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
+	"github.com/ghulammuzz/backend-parkerin/internal/scheduler"
 	store "github.com/ghulammuzz/backend-parkerin/internal/store/di"
+	timesheets "github.com/ghulammuzz/backend-parkerin/internal/timesheets/di"
 	users "github.com/ghulammuzz/backend-parkerin/internal/users/di"
+	userRepo "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
+	vacancies "github.com/ghulammuzz/backend-parkerin/internal/vacancies/di"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 
 	mlog "log/slog"
 
@@ -18,8 +38,18 @@ import (
 	"github.com/joho/godotenv"
 )
 
+var migrateCmd string
+
+// advisory lock keys of the background jobs; distinct from the migration lock
+const (
+	applicationExpiryLockKey       int64 = 7274758
+	paymentReconcileLockKey        int64 = 7274759
+	parkingPaymentReconcileLockKey int64 = 7274760
+)
+
 func init() {
 	env := flag.String("env", "prod", "Environment for (stg/prod)")
+	flag.StringVar(&migrateCmd, "migrate", "", "Run database migrations (up/down/status) and exit")
 	flag.Parse()
 
 	if *env == "stg" {
@@ -33,6 +63,15 @@ func init() {
 		mlog.Info("Environment: production (using system environment variables)")
 	}
 
+	// APP_ENV gates development-only conveniences; an explicit value wins
+	if os.Getenv("APP_ENV") == "" {
+		if *env == "stg" {
+			os.Setenv("APP_ENV", "staging")
+		} else {
+			os.Setenv("APP_ENV", "production")
+		}
+	}
+
 	lokiClient, err := config.InitLoki(os.Getenv("LOKI_URI"))
 	if err != nil {
 		fmt.Println("Error init loki")
@@ -57,19 +96,89 @@ func main() {
 	}
 	defer db.Close()
 
-	// midtransClient := config.InitMidtrans()
+	if migrateCmd != "" {
+		if err := migration.Run(db, migrateCmd, os.Stdout); err != nil {
+			log.Error("Migration failed: %v", err)
+			fmt.Println("Migration failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := migration.EnsureUpToDate(db); err != nil {
+		log.Error("Refusing to start: %v", err)
+		fmt.Println("Refusing to start:", err)
+		os.Exit(1)
+	}
+
+	smsSender, err := config.InitSMSSender()
+	if err != nil {
+		log.Error("Failed to initialize sms sender: %v", err)
+		os.Exit(1)
+	}
+
+	payGateway, err := config.InitPaymentGateway()
+	if err != nil {
+		log.Error("Failed to initialize payment gateway: %v", err)
+		os.Exit(1)
+	}
+	payConfig, err := config.InitPayment()
+	if err != nil {
+		log.Error("Failed to initialize payment config: %v", err)
+		os.Exit(1)
+	}
 
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
 	})
 
+	// a panicking handler answers 500 instead of taking the process down
+	app.Use(recover.New())
+
+	// background jobs; each run is taken by a single replica via an advisory lock
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	expiry := config.InitExpiry()
+	jobs := scheduler.New(db)
+	jobs.Register(scheduler.Job{
+		Name:     "application_expiry",
+		LockKey:  applicationExpiryLockKey,
+		Interval: expiry.Interval,
+		Run:      appService.ExpireStale(appRepo.NewApplicationRepository(db), expiry.ApplicationTTL, expiry.OfferTTL),
+	})
+	reconcile := config.InitReconcile()
+	jobs.Register(scheduler.Job{
+		Name:     "payment_reconcile",
+		LockKey:  paymentReconcileLockKey,
+		Interval: reconcile.Interval,
+		Run:      paySvc.ReconcileStale(payRepo.NewPaymentRepository(db), payGateway, reconcile.Age, reconcile.AbandonAfter),
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "parking_payment_reconcile",
+		LockKey:  parkingPaymentReconcileLockKey,
+		Interval: reconcile.Interval,
+		Run:      parkSvc.ReconcileStale(parkRepo.NewParkingRepository(db), payGateway, reconcile.Age, reconcile.AbandonAfter),
+	})
+	jobs.Start(ctx)
+
 	app.Get("/hc", health.HealthCheck(db))
+	app.Get("/hc/jobs", health.Jobs(jobs))
+
+	middleware.UseDenyList(userRepo.NewTokenRepository(db))
 
 	api := app.Group("/api")
-	users.InitializedUsersService(db, config.Validate).Router(api)
+	users.InitializedUsersService(db, config.Validate, smsSender).Router(api)
 	store.InitializedStoreService(db).Router(api)
-	applicants.InitializedApplicationService(db).Router(api)
-	// payment.InitializedPaymentService(db, midtransClient).Router(api)
+	applicants.InitializedApplicationService(db, config.Validate).Router(api)
+	employments.InitializedEmploymentService(db, config.Validate).Router(api)
+	vacancies.InitializedVacancyService(db, config.Validate).Router(api)
+	attendance.InitializedAttendanceService(db, config.Validate, config.InitAttendance()).Router(api)
+	timesheets.InitializedTimesheetService(db).Router(api)
+	parking.InitializedParkingService(db, config.Validate, payGateway, payConfig).Router(api)
+	deposits.InitializedDepositService(db, config.Validate).Router(api)
+	packages.InitializedPackageService(db, config.Validate).Router(api)
+	payment.InitializedPaymentService(db, payGateway).Router(api)
 
 	if err := app.Listen(fmt.Sprint(":", os.Getenv("APP_PORT"))); err != nil {
 		log.Error("Failed to start the server: %v", err)
@@ -0,0 +1,21 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// AttendanceConfig holds how far from the store, in meters, a tukang may be
+// when checking in. Check-outs beyond it are allowed but flagged.
+type AttendanceConfig struct {
+	RadiusMeters float64
+}
+
+// InitAttendance reads ATTENDANCE_RADIUS_M, defaulting to 100 meters.
+func InitAttendance() AttendanceConfig {
+	radius, err := strconv.ParseFloat(os.Getenv("ATTENDANCE_RADIUS_M"), 64)
+	if err != nil || radius <= 0 {
+		radius = 100
+	}
+	return AttendanceConfig{RadiusMeters: radius}
+}
@@ -0,0 +1,24 @@
+package config
+
+import "os"
+
+// AppEnv is APP_ENV: "production", "staging", "development" or "local". main
+// fills it in from -env when it is unset, so a deploy that sets neither counts
+// as production.
+func AppEnv() string {
+	return os.Getenv("APP_ENV")
+}
+
+func IsProduction() bool {
+	return AppEnv() == "production"
+}
+
+// IsLocal reports a developer machine, where test credentials and printed
+// codes are acceptable.
+func IsLocal() bool {
+	switch AppEnv() {
+	case "local", "development":
+		return true
+	}
+	return false
+}
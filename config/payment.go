@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/fake"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+)
+
+// PaymentConfig is what the payment flows need besides the gateway.
+type PaymentConfig struct {
+	// ParkingNotificationURL receives the notifications of QRIS ticket
+	// charges.
+	ParkingNotificationURL string
+}
+
+// InitPayment reads MIDTRANS_PARKING_NOTIFICATION_URL, this server's
+// /api/parking/payments/notification as Midtrans reaches it. It must be set:
+// without it QRIS charges are notified to the account's notification URL, the
+// package webhook, which does not know them, and paid tickets never close.
+// Local and development runs default to localhost:APP_PORT.
+func InitPayment() (PaymentConfig, error) {
+	notificationURL := os.Getenv("MIDTRANS_PARKING_NOTIFICATION_URL")
+	if notificationURL == "" {
+		if !IsLocal() {
+			return PaymentConfig{}, errors.New("MIDTRANS_PARKING_NOTIFICATION_URL is not set")
+		}
+		notificationURL = fmt.Sprintf("http://localhost:%s/api/parking/payments/notification", os.Getenv("APP_PORT"))
+	}
+	return PaymentConfig{ParkingNotificationURL: notificationURL}, nil
+}
+
+// InitPaymentGateway picks the payment provider from PAYMENT_GATEWAY (default
+// "midtrans"). MIDTRANS_SERVER_KEY signs the notifications and must be set:
+// without it anyone could forge them.
+//
+// midtrans uses the key against the sandbox, or production when
+// MIDTRANS_ENV=production; MIDTRANS_BASE_URL points it at a stand-in server.
+//
+// fake keeps charges in memory and, after FAKE_PAYMENT_DELAY (default 3s),
+// completes each one with FAKE_PAYMENT_OUTCOME (settlement, expire, deny, or
+// manual to leave it pending), posting the notification to the charge's
+// notification URL or PAYMENT_NOTIFICATION_URL (default this server's
+// /api/payment/notification). It is refused in production, and only falls back
+// to a built-in key when APP_ENV is local or development.
+func InitPaymentGateway() (gateway.PaymentGateway, error) {
+	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
+
+	switch os.Getenv("PAYMENT_GATEWAY") {
+	case "", "midtrans":
+		if serverKey == "" {
+			return nil, errors.New("MIDTRANS_SERVER_KEY is not set")
+		}
+		return gateway.NewMidtrans(serverKey, os.Getenv("MIDTRANS_ENV") == "production", os.Getenv("MIDTRANS_BASE_URL")), nil
+	case "fake":
+		if IsProduction() {
+			return nil, errors.New("PAYMENT_GATEWAY=fake is not allowed in production")
+		}
+		if serverKey == "" {
+			if !IsLocal() {
+				return nil, errors.New("MIDTRANS_SERVER_KEY is not set")
+			}
+			serverKey = "fake-server-key"
+		}
+		notificationURL := os.Getenv("PAYMENT_NOTIFICATION_URL")
+		if notificationURL == "" {
+			notificationURL = fmt.Sprintf("http://localhost:%s/api/payment/notification", os.Getenv("APP_PORT"))
+		}
+		outcome := os.Getenv("FAKE_PAYMENT_OUTCOME")
+		if outcome == "" {
+			outcome = fake.OutcomeSettlement
+		}
+		gw, err := fake.NewGateway(serverKey, notificationURL, outcome, durationFromEnv("FAKE_PAYMENT_DELAY", 3*time.Second))
+		if err != nil {
+			return nil, err
+		}
+		return gw, nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_GATEWAY %q", os.Getenv("PAYMENT_GATEWAY"))
+	}
+}
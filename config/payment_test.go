@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestInitPayment(t *testing.T) {
+	cases := []struct {
+		name    string
+		env     string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"set", "production", "https://api.example.com/api/parking/payments/notification", "https://api.example.com/api/parking/payments/notification", false},
+		{"unset in production", "production", "", "", true},
+		{"unset in staging", "staging", "", "", true},
+		{"unset locally", "local", "", "http://localhost:8080/api/parking/payments/notification", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("APP_ENV", tc.env)
+			t.Setenv("APP_PORT", "8080")
+			t.Setenv("MIDTRANS_PARKING_NOTIFICATION_URL", tc.url)
+
+			cfg, err := InitPayment()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tc.wantErr)
+			}
+			if cfg.ParkingNotificationURL != tc.want {
+				t.Fatalf("notification URL = %q, want %q", cfg.ParkingNotificationURL, tc.want)
+			}
+		})
+	}
+}
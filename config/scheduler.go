@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// ExpiryConfig drives the job expiring unanswered applications and offers.
+type ExpiryConfig struct {
+	Interval       time.Duration
+	ApplicationTTL time.Duration
+	OfferTTL       time.Duration
+}
+
+// InitExpiry reads EXPIRY_INTERVAL, APPLICATION_TTL and OFFER_TTL (Go
+// durations such as "15m" or "336h"), defaulting to every 15 minutes, two
+// weeks for applications and three days for direct-hire offers.
+func InitExpiry() ExpiryConfig {
+	return ExpiryConfig{
+		Interval:       durationFromEnv("EXPIRY_INTERVAL", 15*time.Minute),
+		ApplicationTTL: durationFromEnv("APPLICATION_TTL", 14*24*time.Hour),
+		OfferTTL:       durationFromEnv("OFFER_TTL", 3*24*time.Hour),
+	}
+}
+
+// ReconcileConfig drives the job checking payments stuck in pending against
+// the gateway.
+type ReconcileConfig struct {
+	Interval time.Duration
+	// Age is how long an order is left to its notification before the job
+	// asks the gateway.
+	Age time.Duration
+	// AbandonAfter expires a pending order the gateway still does not know
+	// after this long.
+	AbandonAfter time.Duration
+}
+
+// InitReconcile reads RECONCILE_INTERVAL, RECONCILE_AGE and
+// RECONCILE_ABANDON_AFTER, defaulting to every 5 minutes for orders older
+// than 15 minutes, abandoning unknown ones after a day.
+func InitReconcile() ReconcileConfig {
+	return ReconcileConfig{
+		Interval:     durationFromEnv("RECONCILE_INTERVAL", 5*time.Minute),
+		Age:          durationFromEnv("RECONCILE_AGE", 15*time.Minute),
+		AbandonAfter: durationFromEnv("RECONCILE_ABANDON_AFTER", 24*time.Hour),
+	}
+}
+
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ghulammuzz/backend-parkerin/pkg/sms"
+)
+
+// InitSMSSender picks the OTP delivery channel from SMS_SENDER. It must be set:
+// without it no code would ever reach a user.
+//
+// twilio sends SMS, or WhatsApp when TWILIO_FROM starts with "whatsapp:",
+// through Twilio with TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN.
+//
+// log only writes messages to the log, with the codes redacted, and prints
+// them in full to stdout when APP_ENV is local or development. It is refused
+// in production.
+func InitSMSSender() (sms.Sender, error) {
+	switch os.Getenv("SMS_SENDER") {
+	case "":
+		return nil, errors.New("SMS_SENDER is not set")
+	case "twilio":
+		accountSID, authToken, from := os.Getenv("TWILIO_ACCOUNT_SID"), os.Getenv("TWILIO_AUTH_TOKEN"), os.Getenv("TWILIO_FROM")
+		if accountSID == "" || authToken == "" || from == "" {
+			return nil, errors.New("SMS_SENDER=twilio needs TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM")
+		}
+		return sms.NewTwilio(accountSID, authToken, from), nil
+	case "log":
+		if IsProduction() {
+			return nil, errors.New("SMS_SENDER=log is not allowed in production")
+		}
+		return sms.LogSender{PrintCodes: IsLocal()}, nil
+	default:
+		return nil, fmt.Errorf("unknown SMS_SENDER %q", os.Getenv("SMS_SENDER"))
+	}
+}
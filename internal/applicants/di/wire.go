@@ -6,13 +6,16 @@ import (
 	"github.com/ghulammuzz/backend-parkerin/internal/applicants/handler"
 	appRepo "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
 	appSvc "github.com/ghulammuzz/backend-parkerin/internal/applicants/svc"
+	depRepo "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
 	storeRepo "github.com/ghulammuzz/backend-parkerin/internal/store/repo"
 	storeSvc "github.com/ghulammuzz/backend-parkerin/internal/store/svc"
 	userRepo "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
+	vacRepo "github.com/ghulammuzz/backend-parkerin/internal/vacancies/repo"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/wire"
 )
 
-func InitializedApplicationServiceFake(sb *sql.DB) *handler.ApplicationHandler {
+func InitializedApplicationServiceFake(sb *sql.DB, val *validator.Validate) *handler.ApplicationHandler {
 	wire.Build(
 		handler.NewApplicationHandler,
 		appSvc.NewApplicationService,
@@ -20,6 +23,8 @@ func InitializedApplicationServiceFake(sb *sql.DB) *handler.ApplicationHandler {
 		storeSvc.NewStoreService,
 		storeRepo.NewStoreRepository,
 		userRepo.NewUserRepository,
+		vacRepo.NewVacancyRepository,
+		depRepo.NewDepositRepository,
 	)
 
 	return &handler.ApplicationHandler{}
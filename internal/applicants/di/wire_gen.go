@@ -11,19 +11,24 @@ import (
 	"github.com/ghulammuzz/backend-parkerin/internal/applicants/handler"
 	"github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/applicants/svc"
+	repo5 "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
 	repo2 "github.com/ghulammuzz/backend-parkerin/internal/store/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/svc"
 	repo3 "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
+	repo4 "github.com/ghulammuzz/backend-parkerin/internal/vacancies/repo"
+	"github.com/go-playground/validator/v10"
 )
 
 // Injectors from wire.go:
 
-func InitializedApplicationService(sb *sql.DB) *handler.ApplicationHandler {
+func InitializedApplicationService(sb *sql.DB, val *validator.Validate) *handler.ApplicationHandler {
 	applicationRepository := repo.NewApplicationRepository(sb)
 	storeRepository := repo2.NewStoreRepository(sb)
 	userRepository := repo3.NewUserRepository(sb)
-	applicationService := service.NewApplicationService(applicationRepository, storeRepository, userRepository)
-	storeService := svc.NewStoreService(storeRepository, userRepository, applicationRepository)
-	applicationHandler := handler.NewApplicationHandler(applicationService, storeService)
+	vacancyRepository := repo4.NewVacancyRepository(sb)
+	applicationService := service.NewApplicationService(applicationRepository, storeRepository, userRepository, vacancyRepository)
+	depositRepository := repo5.NewDepositRepository(sb)
+	storeService := svc.NewStoreService(storeRepository, userRepository, applicationRepository, depositRepository)
+	applicationHandler := handler.NewApplicationHandler(applicationService, storeService, val)
 	return applicationHandler
 }
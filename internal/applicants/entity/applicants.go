@@ -11,10 +11,11 @@ type Application struct {
 }
 
 type ApplicationResponse struct {
-	ID       int    `json:"id"`
-	UserID   int    `json:"user_id"`
-	UserName string `json:"user_name"`
-	Status   string `json:"status"`
+	ID        int    `json:"id"`
+	UserID    int    `json:"user_id"`
+	UserName  string `json:"user_name"`
+	VacancyID *int   `json:"vacancy_id"`
+	Status    string `json:"status"`
 }
 
 type ApplicationUserResponse struct {
@@ -23,6 +24,7 @@ type ApplicationUserResponse struct {
 	StoreName      string `json:"store_name"`
 	IsHiring       bool   `json:"is_hiring"`
 	IsDirectHiring bool   `json:"is_direct_hiring"`
+	VacancyID      *int   `json:"vacancy_id"`
 	Address        string `json:"address"`
 	UrlImage       string `json:"url_image"`
 	WorkingHours   string `json:"working_hours"`
@@ -30,9 +32,60 @@ type ApplicationUserResponse struct {
 }
 
 type ApplicationUserResponseDetail struct {
-	ID        int                           `json:"id"`
-	StoreName string                        `json:"store_name"`
-	Address   string                        `json:"address"`
-	Status    string                        `json:"status"`
-	User      userEntity.UserDetailResponse `json:"user"`
+	ID           int                           `json:"id"`
+	StoreID      int                           `json:"store_id"`
+	VacancyID    *int                          `json:"vacancy_id"`
+	StoreName    string                        `json:"store_name"`
+	Address      string                        `json:"address"`
+	UrlImage     string                        `json:"url_image"`
+	WorkingHours string                        `json:"working_hours"`
+	IsHiring     bool                          `json:"is_hiring"`
+	Status       string                        `json:"status"`
+	IsDirectHire bool                          `json:"is_direct_hire"`
+	AppliedAt    int64                         `json:"applied_at"`
+	UpdatedAt    int64                         `json:"updated_at"`
+	User         userEntity.UserDetailResponse `json:"user"`
+	Events       []ApplicationEvent            `json:"events"`
+}
+
+const (
+	StatusSent      = "sent"
+	StatusAccepted  = "accepted"
+	StatusRejected  = "rejected"
+	StatusWithdrawn = "withdrawn"
+	StatusExpired   = "expired"
+)
+
+const (
+	ActorTukang = "tukang"
+	ActorStore  = "store"
+	ActorSystem = "system"
+)
+
+// Actor is whoever asks for a status change. UserID is the acting user and
+// StoreID the store they own, if any; both are zero for ActorSystem.
+type Actor struct {
+	Role    string
+	UserID  int
+	StoreID int
+}
+
+type ApplicationState struct {
+	ID           int
+	TukangID     int
+	StoreID      int
+	VacancyID    *int
+	Status       string
+	IsDirectHire bool
+	UpdatedAt    int64
+}
+
+type ApplicationEvent struct {
+	ID            int    `json:"id"`
+	ApplicationID int    `json:"application_id"`
+	FromStatus    string `json:"from_status"`
+	ToStatus      string `json:"to_status"`
+	Actor         string `json:"actor"`
+	ActorUserID   *int   `json:"actor_user_id"`
+	CreatedAt     int64  `json:"created_at"`
 }
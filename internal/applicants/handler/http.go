@@ -1,56 +1,55 @@
 package handler
 
 import (
+	"errors"
 	"log/slog"
 	"strconv"
 
-	"github.com/dgrijalva/jwt-go"
-
+	appEntity "github.com/ghulammuzz/backend-parkerin/internal/applicants/entity"
 	appService "github.com/ghulammuzz/backend-parkerin/internal/applicants/svc"
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
 	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
 
 	storeService "github.com/ghulammuzz/backend-parkerin/internal/store/svc"
-	"github.com/ghulammuzz/backend-parkerin/pkg/log" 
+	"github.com/ghulammuzz/backend-parkerin/pkg/form"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
 	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
 type ApplicationHandler struct {
 	appService   appService.ApplicationService
 	storeService storeService.StoreService
+	val          *validator.Validate
 }
 
-func NewApplicationHandler(appService appService.ApplicationService, storeService storeService.StoreService) *ApplicationHandler {
-	return &ApplicationHandler{appService, storeService}
+func NewApplicationHandler(appService appService.ApplicationService, storeService storeService.StoreService, val *validator.Validate) *ApplicationHandler {
+	return &ApplicationHandler{appService, storeService, val}
 }
 
 func (h *ApplicationHandler) Router(r fiber.Router) {
-	r.Post("/apply-store/:storeID", middleware.JWTProtected(), h.ApplyStore)
-	r.Post("/apply-user/:userID", middleware.JWTProtected(), h.ApplyUser)
-	r.Get("/application/store", middleware.JWTProtected(), h.ReviewApplicationsStore)
-	r.Get("/application/user", middleware.JWTProtected(), h.ReviewApplicationsUser)
-	r.Put("/status-apply-user/:appID", middleware.JWTProtected(), h.UpdateApplicationUserStatus)
-	r.Put("/status-apply-store/:appID", middleware.JWTProtected(), h.UpdateApplicationStoreStatus)
-	r.Delete("/applicants/:appID", middleware.JWTProtected(), h.DeleteAppsInUserHandler)
+	r.Post("/apply-store/:storeID", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.ApplyStore)
+	r.Post("/apply-vacancy/:vacancyID", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.ApplyVacancy)
+	r.Post("/apply-user/:userID", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.ApplyUser)
+	r.Get("/application/store", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.ReviewApplicationsStore)
+	r.Get("/application/user", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.ReviewApplicationsUser)
+	r.Put("/status-apply-user/:appID", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.UpdateApplicationUserStatus)
+	r.Put("/status-apply-store/:appID", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.UpdateApplicationStoreStatus)
+	r.Get("/application/:appID", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang, middleware.RoleStore), h.ApplicationDetail)
+	r.Get("/application/:appID/events", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang, middleware.RoleStore), h.ApplicationEvents)
+	r.Delete("/applicants/:appID", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.DeleteAppsInUserHandler)
 }
 
 // us (using jwt)
 func (h *ApplicationHandler) ApplyStore(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
-
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token") // Log error
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
-	roles := string(claims["role"].(string))
-
-	if roles != "tukang" {
-		log.Error("Unauthorized role", slog.String("role", roles)) // Log error
-		return response.JSON(c, 401, "Unauthorized", nil)
-	}
+	userID := principal.UserID
 
 	storeID, err := strconv.Atoi(c.Params("storeID"))
 	if err != nil {
@@ -58,33 +57,86 @@ func (h *ApplicationHandler) ApplyStore(c *fiber.Ctx) error {
 		return response.JSON(c, 400, "invalid store ID", nil)
 	}
 
-	err = h.appService.CreateApply(userID, storeID, false)
+	vacancyID, err := optionalVacancyID(c)
+	if err != nil {
+		log.Error("Invalid vacancy ID", slog.String("vacancy_id", c.Query("vacancy_id"))) // Log error
+		return response.JSON(c, 400, "invalid vacancy ID", nil)
+	}
+
+	err = h.appService.CreateApply(userID, storeID, false, vacancyID)
 	if err != nil {
 		log.Error("Error applying to store", slog.String("error", err.Error())) // Log error
+		if errors.Is(err, appService.ErrVacancyNotFound) {
+			return response.JSON(c, fiber.StatusNotFound, err.Error(), nil)
+		}
+		if isApplyConflict(err) {
+			return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+		}
 		return response.JSON(c, 500, "error apply", err.Error())
 	}
 
 	return response.JSON(c, 200, "Application submitted successfully", nil)
 }
 
-// st (using jwt)
-func (h *ApplicationHandler) ApplyUser(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
-
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+// us (using jwt)
+func (h *ApplicationHandler) ApplyVacancy(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token") // Log error
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userSelfID := int(claims["user_id"].(float64))
+	vacancyID, err := strconv.Atoi(c.Params("vacancyID"))
+	if err != nil {
+		log.Error("Invalid vacancy ID", slog.String("vacancyID", c.Params("vacancyID"))) // Log error
+		return response.JSON(c, 400, "invalid vacancy ID", nil)
+	}
 
-	// using get storeID by userID
-	storeID, err := h.storeService.GetStoreIDByUserID(userSelfID)
+	if err := h.appService.ApplyVacancy(principal.UserID, vacancyID); err != nil {
+		log.Error("Error applying to vacancy", slog.String("error", err.Error())) // Log error
+		if errors.Is(err, appService.ErrVacancyNotFound) {
+			return response.JSON(c, fiber.StatusNotFound, err.Error(), nil)
+		}
+		if isApplyConflict(err) {
+			return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+		}
+		return response.JSON(c, 500, "error apply", err.Error())
+	}
+
+	return response.JSON(c, 200, "Application submitted successfully", nil)
+}
+
+// isApplyConflict reports whether an apply failed because of the state of the
+// store, vacancy or tukang rather than a server error.
+func isApplyConflict(err error) bool {
+	return errors.Is(err, appService.ErrNotHiring) ||
+		errors.Is(err, appService.ErrCapacityReached) ||
+		errors.Is(err, appService.ErrApplicationExists) ||
+		errors.Is(err, appService.ErrAlreadyEmployed)
+}
+
+// optionalVacancyID reads ?vacancy_id=, returning nil when it is absent.
+func optionalVacancyID(c *fiber.Ctx) (*int, error) {
+	raw := c.Query("vacancy_id")
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(raw)
 	if err != nil {
-		log.Error("Invalid user ID", slog.String("userID", strconv.Itoa(userSelfID))) // Log error
-		return response.JSON(c, 400, "invalid user ID", nil)
+		return nil, err
 	}
+	return &id, nil
+}
+
+// st (using jwt)
+func (h *ApplicationHandler) ApplyUser(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token") // Log error
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	storeID := principal.StoreID
 
 	userID, err := strconv.Atoi(c.Params("userID"))
 	if err != nil {
@@ -92,8 +144,28 @@ func (h *ApplicationHandler) ApplyUser(c *fiber.Ctx) error {
 		return response.JSON(c, 400, "invalid user ID", nil)
 	}
 
-	if err := h.appService.CreateApply(userID, storeID, true); err != nil {
+	if err := h.storeService.RequireSubscription(storeID); err != nil {
+		log.Error("Direct hire requires subscription", slog.String("error", err.Error())) // Log error
+		if errors.Is(err, storeService.ErrSubscriptionRequired) {
+			return response.JSON(c, fiber.StatusPaymentRequired, err.Error(), nil)
+		}
+		return response.JSON(c, 500, "error svc subscription", err.Error())
+	}
+
+	vacancyID, err := optionalVacancyID(c)
+	if err != nil {
+		log.Error("Invalid vacancy ID", slog.String("vacancy_id", c.Query("vacancy_id"))) // Log error
+		return response.JSON(c, 400, "invalid vacancy ID", nil)
+	}
+
+	if err := h.appService.CreateApply(userID, storeID, true, vacancyID); err != nil {
 		log.Error("Error creating application", slog.String("error", err.Error())) // Log error
+		if errors.Is(err, appService.ErrVacancyNotFound) {
+			return response.JSON(c, fiber.StatusNotFound, err.Error(), nil)
+		}
+		if isApplyConflict(err) {
+			return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+		}
 		return response.JSON(c, 500, "error svc createapply", err.Error())
 	}
 
@@ -102,21 +174,13 @@ func (h *ApplicationHandler) ApplyUser(c *fiber.Ctx) error {
 
 // st (using jwt)
 func (h *ApplicationHandler) ReviewApplicationsStore(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
-
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token") // Log error
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
-
-	storeID, err := h.storeService.GetStoreIDByUserID(userID)
-	if err != nil {
-		log.Error("Invalid user ID", slog.String("userID", strconv.Itoa(userID))) // Log error
-		return response.JSON(c, 400, "invalid user ID", nil)
-	}
+	storeID := principal.StoreID
 
 	applications, err := h.appService.ReviewApplications(storeID)
 	if err != nil {
@@ -130,15 +194,13 @@ func (h *ApplicationHandler) ReviewApplicationsStore(c *fiber.Ctx) error {
 // us (using jwt)
 // review app by user
 func (h *ApplicationHandler) ReviewApplicationsUser(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
-
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token") // Log error
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
+	userID := principal.UserID
 
 	isDirectHire, err := strconv.ParseBool(c.Query("is_direct_hire", "false"))
 	if err != nil {
@@ -157,16 +219,27 @@ func (h *ApplicationHandler) ReviewApplicationsUser(c *fiber.Ctx) error {
 
 // us
 func (h *ApplicationHandler) UpdateApplicationUserStatus(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token") // Log error
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	return h.changeStatus(c, appEntity.Actor{Role: appEntity.ActorTukang, UserID: principal.UserID})
+}
 
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+// st
+func (h *ApplicationHandler) UpdateApplicationStoreStatus(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token") // Log error
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
+	return h.changeStatus(c, appEntity.Actor{Role: appEntity.ActorStore, UserID: principal.UserID, StoreID: principal.StoreID})
+}
 
+func (h *ApplicationHandler) changeStatus(c *fiber.Ctx, actor appEntity.Actor) error {
 	appID, err := strconv.Atoi(c.Params("appID"))
 	if err != nil {
 		log.Error("Invalid application ID", slog.String("appID", c.Params("appID"))) // Log error
@@ -174,37 +247,62 @@ func (h *ApplicationHandler) UpdateApplicationUserStatus(c *fiber.Ctx) error {
 	}
 
 	status := c.Query("update")
-	if status != "accepted" && status != "rejected" {
+	if status != appEntity.StatusAccepted && status != appEntity.StatusRejected && status != appEntity.StatusWithdrawn {
 		log.Error("Invalid status", slog.String("status", status)) // Log error
-		return response.JSON(c, 400, "invalid status; must be 'accepted' or 'rejected'", nil)
+		return response.JSON(c, 400, "invalid status; must be 'accepted', 'rejected' or 'withdrawn'", nil)
 	}
 
-	if status == "accepted" {
-		if err := h.appService.AcceptApplicationUser(appID, userID); err != nil {
-			log.Error("Error accepting application", slog.String("error", err.Error())) // Log error
-			return response.JSON(c, 500, "acc user svc", err.Error())
+	// the store may send the employment terms along with an acceptance
+	var terms *empEntity.Terms
+	if status == appEntity.StatusAccepted && actor.Role == appEntity.ActorStore && len(c.Body()) > 0 {
+		terms = new(empEntity.Terms)
+		if err := c.BodyParser(terms); err != nil {
+			log.Error("Payload error", slog.String("error", err.Error())) // Log error
+			return response.JSON(c, 400, "invalid payload", err.Error())
 		}
-		return response.JSON(c, 200, "Application accepted", nil)
-	} else {
-		if err := h.appService.RejectApplicationUser(appID, userID); err != nil {
-			log.Error("Error rejecting application", slog.String("error", err.Error())) // Log error
-			return response.JSON(c, 500, "reject user svc", err.Error())
+		if err := h.val.Struct(terms); err != nil {
+			validationErrors := form.ValidationErrorResponse(err)
+			log.Error("Validation failed", slog.Any("errors", validationErrors)) // Log error
+			return response.JSON(c, 400, "Validation failed", validationErrors)
 		}
-		return response.JSON(c, 200, "Application rejected", nil)
 	}
-}
 
-// st
-func (h *ApplicationHandler) UpdateApplicationStoreStatus(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
+	if err := h.appService.ChangeStatus(appID, actor, status, terms); err != nil {
+		log.Error("Error changing application status", slog.String("error", err.Error())) // Log error
+		return applicationError(c, err)
+	}
+
+	return response.JSON(c, 200, "Application "+status, nil)
+}
 
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+func (h *ApplicationHandler) ApplicationEvents(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token") // Log error
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
+	appID, err := strconv.Atoi(c.Params("appID"))
+	if err != nil {
+		log.Error("Invalid application ID", slog.String("appID", c.Params("appID"))) // Log error
+		return response.JSON(c, 400, "invalid app id", nil)
+	}
+
+	events, err := h.appService.Events(appID, actorFrom(principal))
+	if err != nil {
+		log.Error("Error retrieving application events", slog.String("error", err.Error())) // Log error
+		return applicationError(c, err)
+	}
+
+	return response.JSON(c, 200, "Application events retrieved successfully", events)
+}
+
+func (h *ApplicationHandler) ApplicationDetail(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token") // Log error
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
 
 	appID, err := strconv.Atoi(c.Params("appID"))
 	if err != nil {
@@ -212,43 +310,44 @@ func (h *ApplicationHandler) UpdateApplicationStoreStatus(c *fiber.Ctx) error {
 		return response.JSON(c, 400, "invalid app id", nil)
 	}
 
-	storeID, err := h.storeService.GetStoreIDByUserID(userID)
+	detail, err := h.appService.Detail(appID, actorFrom(principal))
 	if err != nil {
-		log.Error("Invalid user ID", slog.String("userID", strconv.Itoa(userID))) // Log error
-		return response.JSON(c, 400, "invalid user ID", nil)
+		log.Error("Error retrieving application detail", slog.String("error", err.Error())) // Log error
+		return applicationError(c, err)
 	}
 
-	status := c.Query("update")
-	if status != "accepted" && status != "rejected" {
-		log.Error("Invalid status", slog.String("status", status)) // Log error
-		return response.JSON(c, 400, "invalid status; must be 'accepted' or 'rejected'", nil)
+	return response.JSON(c, 200, "Application retrieved successfully", detail)
+}
+
+func actorFrom(principal *middleware.Principal) appEntity.Actor {
+	role := appEntity.ActorTukang
+	if principal.Role == middleware.RoleStore {
+		role = appEntity.ActorStore
 	}
+	return appEntity.Actor{Role: role, UserID: principal.UserID, StoreID: principal.StoreID}
+}
 
-	if status == "accepted" {
-		if err := h.appService.AcceptApplicationStore(appID, storeID); err != nil {
-			log.Error("Error accepting application", slog.String("error", err.Error())) // Log error
-			return response.JSON(c, 500, "acc store svc", err.Error())
-		}
-		return response.JSON(c, 200, "Application accepted", nil)
-	} else {
-		if err := h.appService.RejectApplicationStore(appID, storeID); err != nil {
-			log.Error("Error rejecting application", slog.String("error", err.Error())) // Log error
-			return response.JSON(c, 500, "reject store svc", err.Error())
-		}
-		return response.JSON(c, 200, "Application rejected", nil)
+func applicationError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, appService.ErrApplicationNotFound):
+		return response.JSON(c, fiber.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, appService.ErrIllegalTransition), errors.Is(err, appService.ErrCapacityReached),
+		errors.Is(err, appService.ErrAlreadyEmployed):
+		return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+	case errors.Is(err, appService.ErrInvalidTerms):
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", err.Error())
 	}
+	return response.JSON(c, 500, "error svc application", err.Error())
 }
 
 func (h *ApplicationHandler) DeleteAppsInUserHandler(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
-
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token") // Log error
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
+	userID := principal.UserID
 
 	appID, err := strconv.Atoi(c.Params("appID"))
 	if err != nil {
@@ -259,7 +358,7 @@ func (h *ApplicationHandler) DeleteAppsInUserHandler(c *fiber.Ctx) error {
 	err = h.appService.DeleteAppsInUser(userID, appID)
 	if err != nil {
 		log.Error("Error deleting application", slog.String("error", err.Error())) // Log error
-		return response.JSON(c, 500, "error svc delete apps", err.Error())
+		return applicationError(c, err)
 	}
 
 	return response.JSON(c, 200, "success delete apps ", nil)
@@ -2,108 +2,236 @@ package repo
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	appEntity "github.com/ghulammuzz/backend-parkerin/internal/applicants/entity"
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
+	"github.com/lib/pq"
 )
 
 type ApplicationRepository interface {
-	Apply(userID, storeID int, isDirectHire bool) error
+	Apply(userID, storeID int, isDirectHire bool, vacancyID *int) error
 	Detail(appID int) (appEntity.ApplicationUserResponseDetail, error)
 	GetApplicationsByStore(storeID int) ([]appEntity.ApplicationResponse, error)
 	GetApplicationsByUser(userID int, isDirectHire bool) ([]appEntity.ApplicationUserResponse, error)
-	UpdateApplicationStatusUser(appID, userID int, status string) error
-	UpdateApplicationStatusStore(appID, storeID int, status string) error
-	CheckApplicantsAlreadyExist(userID, storeID int) (bool, error)
+	GetState(appID int) (appEntity.ApplicationState, error)
+	Transition(appID int, from, to string, actor appEntity.Actor) (bool, error)
+	Accept(app appEntity.ApplicationState, actor appEntity.Actor, terms *empEntity.Terms) (bool, error)
+	Events(appID int) ([]appEntity.ApplicationEvent, error)
 	RejectedAllApplicantsByStoreID(storeID int) error
-	DeleteApplicantsByUserIDAppsID(userID int, appsID int) error
+	RejectPendingByVacancy(vacancyID int) error
+	ExpireSent(isDirectHire bool, appliedBefore int64) (int, error)
 }
 
+var (
+	ErrApplicationNotFound   = errors.New("application not found")
+	ErrHiringCapacityReached = errors.New("store has no hiring capacity left")
+	ErrNotHiring             = errors.New("store or vacancy is not hiring")
+	ErrApplicationExists     = errors.New("application already pending for this tukang and store")
+	ErrAlreadyEmployed       = errors.New("tukang already works at this store")
+)
+
 type applicationRepository struct {
 	db *sql.DB
 }
 
 func (r *applicationRepository) Detail(appID int) (appEntity.ApplicationUserResponseDetail, error) {
-	panic("unimplemented")
-}
-
-func (r *applicationRepository) DeleteApplicantsByUserIDAppsID(userID int, appsID int) error {
 	query := `
-		DELETE from applications
-		WHERE id = $1 AND tukang_id = $2
+		SELECT a.id, a.vacancy_id, a.status, a.is_direct_hire, a.applied_at, a.updated_at,
+		       s.id, s.store_name, s.address, s.url_image, s.working_hours, s.is_hiring,
+		       u.id, u.phone_number, u.name, u.role
+		FROM applications a
+		JOIN stores s ON a.store_id = s.id
+		JOIN users u ON a.tukang_id = u.id
+		WHERE a.id = $1
 	`
-	_, err := r.db.Exec(query, appsID, userID)
+	var app appEntity.ApplicationUserResponseDetail
+	var vacancyID sql.NullInt64
+	err := r.db.QueryRow(query, appID).Scan(
+		&app.ID,
+		&vacancyID,
+		&app.Status,
+		&app.IsDirectHire,
+		&app.AppliedAt,
+		&app.UpdatedAt,
+		&app.StoreID,
+		&app.StoreName,
+		&app.Address,
+		&app.UrlImage,
+		&app.WorkingHours,
+		&app.IsHiring,
+		&app.User.ID,
+		&app.User.PhoneNumber,
+		&app.User.Name,
+		&app.User.Role,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to delete applicants by userID and appsID: %w", err)
+		if err == sql.ErrNoRows {
+			return appEntity.ApplicationUserResponseDetail{}, ErrApplicationNotFound
+		}
+		return appEntity.ApplicationUserResponseDetail{}, fmt.Errorf("failed to get application detail: %w", err)
 	}
-
-	return nil
+	app.VacancyID = nullableID(vacancyID)
+	return app, nil
 }
 
+// RejectedAllApplicantsByStoreID rejects the store's pending applications on
+// the system's behalf, e.g. when it stops hiring or loses verification.
+// Applications already decided are left alone.
 func (r *applicationRepository) RejectedAllApplicantsByStoreID(storeID int) error {
-	query := `
-		UPDATE applications
-		SET status = 'rejected'
-		WHERE store_id = $1 AND status != 'rejected'
-	`
-
-	result, err := r.db.Exec(query, storeID)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	if err := rejectPending(tx, "store_id = $1", storeID, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RejectPendingByVacancy rejects the pending applications for a vacancy that
+// was closed or filled.
+func (r *applicationRepository) RejectPendingByVacancy(vacancyID int) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return nil
+	defer tx.Rollback()
+
+	if err := rejectPending(tx, "vacancy_id = $1", vacancyID, time.Now().Unix()); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (r *applicationRepository) CheckApplicantsAlreadyExist(userID, storeID int) (bool, error) {
-	query := "SELECT 1 FROM applications WHERE tukang_id = $1 AND store_id = $2 LIMIT 1"
+// ExpireSent expires, on the system's behalf, the applications (or, with
+// isDirectHire, the offers) still sent that were made before appliedBefore,
+// returning how many it expired.
+func (r *applicationRepository) ExpireSent(isDirectHire bool, appliedBefore int64) (int, error) {
+	query := `
+		WITH expired AS (
+			UPDATE applications
+			SET status = 'expired', updated_at = $3
+			WHERE status = 'sent' AND is_direct_hire = $1 AND applied_at < $2
+			RETURNING id
+		)
+		INSERT INTO application_events (application_id, from_status, to_status, actor, actor_user_id, created_at)
+		SELECT id, 'sent', 'expired', 'system', NULL, $3 FROM expired
+	`
+	result, err := r.db.Exec(query, isDirectHire, appliedBefore, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire applications: %w", err)
+	}
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(expired), nil
+}
 
-	var exists int
-	err := r.db.QueryRow(query, userID, storeID).Scan(&exists)
+// mult (apply)
+// Apply records a new application. A general application needs the store to
+// be taking them (is_hiring); one for a vacancy needs that vacancy open. A
+// direct-hire offer is the store's own choice, so it ignores is_hiring and
+// only needs the store's hiring capacity not to be filled. The row holding
+// the slots is share-locked so an accept that fills it (and rejects what is
+// pending) cannot interleave with the insert, and the pending application and
+// active employment unique indexes settle concurrent applies.
+func (r *applicationRepository) Apply(userID, storeID int, isDirectHire bool, vacancyID *int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	var hiring bool
+	var storeUserID, capacity, active int
+	if vacancyID != nil {
+		err = tx.QueryRow(`
+			SELECT v.status = 'open', s.user_id
+			FROM vacancies v JOIN stores s ON s.id = v.store_id
+			WHERE v.id = $1 AND v.store_id = $2
+			FOR SHARE OF v
+		`, *vacancyID, storeID).Scan(&hiring, &storeUserID)
+	} else {
+		err = tx.QueryRow(`
+			SELECT s.is_hiring, s.user_id, s.hiring_capacity,
+			       (SELECT COUNT(*) FROM employments e WHERE e.store_id = s.id AND e.status = 'active' AND e.vacancy_id IS NULL)
+			FROM stores s
+			WHERE s.id = $1
+			FOR SHARE
+		`, storeID).Scan(&hiring, &storeUserID, &capacity, &active)
+	}
+	if err == sql.ErrNoRows {
+		return ErrNotHiring
+	}
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, nil
+		return fmt.Errorf("failed to lock hiring slots: %w", err)
+	}
+	if isDirectHire && vacancyID == nil {
+		if active >= capacity {
+			return ErrHiringCapacityReached
 		}
-		return false, err
+	} else if !hiring {
+		return ErrNotHiring
 	}
 
-	return true, nil
-}
+	var employed bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM employments WHERE tukang_id = $1 AND store_id = $2 AND status = 'active')`, userID, storeID).Scan(&employed)
+	if err != nil {
+		return fmt.Errorf("failed to check employment: %w", err)
+	}
+	if employed {
+		return ErrAlreadyEmployed
+	}
 
-// mult (apply)
-func (r *applicationRepository) Apply(userID, storeID int, isDirectHire bool) error {
+	var applicationID int
+	now := time.Now().Unix()
 	query := `
-		INSERT INTO applications (tukang_id, store_id, status, applied_at, updated_at, is_direct_hire)
-		SELECT $1, $2, 'sent', $3, $4, $5
-		FROM stores
-		WHERE id = $2 AND is_hiring = true
+		INSERT INTO applications (tukang_id, store_id, status, applied_at, updated_at, is_direct_hire, vacancy_id)
+		VALUES ($1, $2, 'sent', $3, $3, $4, $5)
 		RETURNING id
 	`
+	if err := tx.QueryRow(query, userID, storeID, now, isDirectHire, vacancyID).Scan(&applicationID); err != nil {
+		if isUniqueViolation(err, "ux_applications_pending") {
+			return ErrApplicationExists
+		}
+		return fmt.Errorf("failed to apply: %w", err)
+	}
 
-	var applicationID int
-	err := r.db.QueryRow(query, userID, storeID, time.Now().Unix(), time.Now().Unix(), isDirectHire).Scan(&applicationID)
-
-	if err != nil {
-		return errors.New("cannot apply: store is not hiring or application exists")
+	actor, actorUserID := appEntity.ActorTukang, userID
+	if isDirectHire {
+		actor, actorUserID = appEntity.ActorStore, storeUserID
 	}
-	return nil
+	eventQuery := `
+		INSERT INTO application_events (application_id, from_status, to_status, actor, actor_user_id, created_at)
+		VALUES ($1, '', $2, $3, $4, $5)
+	`
+	if _, err := tx.Exec(eventQuery, applicationID, appEntity.StatusSent, actor, actorUserID, now); err != nil {
+		return fmt.Errorf("failed to record application event: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// isUniqueViolation reports whether err is postgres rejecting a row because
+// of the named unique index.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
 }
 
 // store (list app by store)
 func (r *applicationRepository) GetApplicationsByStore(storeID int) ([]appEntity.ApplicationResponse, error) {
 	query := `
-		SELECT a.id, u.id, u.name, a.status
+		SELECT a.id, u.id, u.name, a.vacancy_id, a.status
 		FROM applications a
 		JOIN users u ON a.tukang_id = u.id
 		WHERE a.store_id = $1
@@ -117,9 +245,11 @@ func (r *applicationRepository) GetApplicationsByStore(storeID int) ([]appEntity
 	var applications []appEntity.ApplicationResponse
 	for rows.Next() {
 		var app appEntity.ApplicationResponse
-		if err := rows.Scan(&app.ID, &app.UserID, &app.UserName, &app.Status); err != nil {
+		var vacancyID sql.NullInt64
+		if err := rows.Scan(&app.ID, &app.UserID, &app.UserName, &vacancyID, &app.Status); err != nil {
 			return nil, err
 		}
+		app.VacancyID = nullableID(vacancyID)
 		applications = append(applications, app)
 	}
 	return applications, nil
@@ -127,7 +257,7 @@ func (r *applicationRepository) GetApplicationsByStore(storeID int) ([]appEntity
 
 func (r *applicationRepository) GetApplicationsByUser(userID int, isDirectHire bool) ([]appEntity.ApplicationUserResponse, error) {
 	query := `
-		SELECT a.id, a.is_direct_hire, s.id, s.store_name, s.is_hiring, s.address, s.working_hours, s.url_image, a.status
+		SELECT a.id, a.is_direct_hire, a.vacancy_id, s.id, s.store_name, s.is_hiring, s.address, s.working_hours, s.url_image, a.status
 		FROM applications a
 		JOIN stores s ON a.store_id = s.id
 		WHERE a.tukang_id = $1 and a.is_direct_hire = $2
@@ -141,9 +271,11 @@ func (r *applicationRepository) GetApplicationsByUser(userID int, isDirectHire b
 	var applications []appEntity.ApplicationUserResponse
 	for rows.Next() {
 		var app appEntity.ApplicationUserResponse
-		if err := rows.Scan(&app.ID, &app.IsDirectHiring, &app.StoreID, &app.StoreName, &app.IsHiring, &app.Address, &app.WorkingHours, &app.UrlImage, &app.Status); err != nil {
+		var vacancyID sql.NullInt64
+		if err := rows.Scan(&app.ID, &app.IsDirectHiring, &vacancyID, &app.StoreID, &app.StoreName, &app.IsHiring, &app.Address, &app.WorkingHours, &app.UrlImage, &app.Status); err != nil {
 			return nil, err
 		}
+		app.VacancyID = nullableID(vacancyID)
 		applications = append(applications, app)
 	}
 
@@ -154,17 +286,227 @@ func (r *applicationRepository) GetApplicationsByUser(userID int, isDirectHire b
 	return applications, nil
 }
 
-// mult
-func (r *applicationRepository) UpdateApplicationStatusUser(appID, userID int, status string) error {
-	query := `UPDATE applications SET status = $1 WHERE id = $2 AND tukang_id = $3`
-	_, err := r.db.Exec(query, status, appID, userID)
-	return err
+func (r *applicationRepository) GetState(appID int) (appEntity.ApplicationState, error) {
+	query := `
+		SELECT id, tukang_id, store_id, vacancy_id, status, is_direct_hire, updated_at
+		FROM applications
+		WHERE id = $1
+	`
+	var app appEntity.ApplicationState
+	var vacancyID sql.NullInt64
+	err := r.db.QueryRow(query, appID).Scan(&app.ID, &app.TukangID, &app.StoreID, &vacancyID, &app.Status, &app.IsDirectHire, &app.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return appEntity.ApplicationState{}, ErrApplicationNotFound
+		}
+		return appEntity.ApplicationState{}, err
+	}
+	app.VacancyID = nullableID(vacancyID)
+	return app, nil
 }
 
-func (r *applicationRepository) UpdateApplicationStatusStore(appID, storeID int, status string) error {
-	query := `UPDATE applications SET status = $1 WHERE id = $2 AND store_id = $3`
-	_, err := r.db.Exec(query, status, appID, storeID)
-	return err
+// Transition moves the application from `from` to `to` and records the event
+// in one transaction. It reports false without writing anything when the
+// application is no longer in `from`, so concurrent decisions cannot both win.
+func (r *applicationRepository) Transition(appID int, from, to string, actor appEntity.Actor) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	ok, err := transition(tx, appID, from, to, actor, time.Now().Unix())
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Accept moves a sent application to accepted and starts the employment it
+// leads to. The row holding the slots (the vacancy, or the store for a general
+// application) is locked so concurrent accepts see each other's employments;
+// once the last slot is taken it stops hiring and the applications still
+// pending for it are rejected. A tukang accepted twice by the same store (say
+// through a vacancy and a general application at once) is stopped by
+// ux_employments_active.
+func (r *applicationRepository) Accept(app appEntity.ApplicationState, actor appEntity.Actor, terms *empEntity.Terms) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var capacity, active int
+	var capacityQuery, closeQuery, pendingWhere string
+	var closeArgs []interface{}
+	slotsID := app.StoreID
+	if app.VacancyID != nil {
+		slotsID = *app.VacancyID
+		capacityQuery = `
+			SELECT CASE WHEN v.status = 'open' THEN v.slots ELSE 0 END,
+			       (SELECT COUNT(*) FROM employments e WHERE e.vacancy_id = v.id AND e.status = 'active')
+			FROM vacancies v
+			WHERE v.id = $1
+			FOR UPDATE
+		`
+		closeQuery = `UPDATE vacancies SET status = 'filled', updated_at = $2 WHERE id = $1`
+		closeArgs = []interface{}{slotsID, time.Now().Unix()}
+		pendingWhere = "vacancy_id = $1"
+	} else {
+		capacityQuery = `
+			SELECT s.hiring_capacity,
+			       (SELECT COUNT(*) FROM employments e WHERE e.store_id = s.id AND e.status = 'active' AND e.vacancy_id IS NULL)
+			FROM stores s
+			WHERE s.id = $1
+			FOR UPDATE
+		`
+		closeQuery = `UPDATE stores SET is_hiring = false WHERE id = $1`
+		closeArgs = []interface{}{slotsID}
+		pendingWhere = "store_id = $1 AND vacancy_id IS NULL"
+	}
+
+	if err := tx.QueryRow(capacityQuery, slotsID).Scan(&capacity, &active); err != nil {
+		return false, fmt.Errorf("failed to lock hiring slots: %w", err)
+	}
+	if active >= capacity {
+		return false, ErrHiringCapacityReached
+	}
+
+	now := time.Now().Unix()
+	ok, err := transition(tx, app.ID, app.Status, appEntity.StatusAccepted, actor, now)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := startEmployment(tx, app, terms, now); err != nil {
+		if isUniqueViolation(err, "ux_employments_active") {
+			return false, ErrAlreadyEmployed
+		}
+		return false, err
+	}
+
+	if active+1 >= capacity {
+		if _, err := tx.Exec(closeQuery, closeArgs...); err != nil {
+			return false, fmt.Errorf("failed to close hiring: %w", err)
+		}
+		if err := rejectPending(tx, pendingWhere, slotsID, now); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// transition is the compare-and-set behind Transition and Accept.
+func transition(tx *sql.Tx, appID int, from, to string, actor appEntity.Actor, now int64) (bool, error) {
+	result, err := tx.Exec(`UPDATE applications SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4`, to, now, appID, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to update application status: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	var actorUserID *int
+	if actor.UserID != 0 {
+		actorUserID = &actor.UserID
+	}
+	eventQuery := `
+		INSERT INTO application_events (application_id, from_status, to_status, actor, actor_user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.Exec(eventQuery, appID, from, to, actor.Role, actorUserID, now); err != nil {
+		return false, fmt.Errorf("failed to record application event: %w", err)
+	}
+	return true, nil
+}
+
+func startEmployment(tx *sql.Tx, app appEntity.ApplicationState, terms *empEntity.Terms, now int64) error {
+	var shiftPattern interface{}
+	if terms.ShiftPattern != nil {
+		raw, err := json.Marshal(terms.ShiftPattern)
+		if err != nil {
+			return err
+		}
+		shiftPattern = raw
+	}
+
+	query := `
+		INSERT INTO employments (application_id, store_id, tukang_id, vacancy_id, start_date, pay_basis, wage_amount, fee_split_percent, shift_pattern, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE(NULLIF($6, ''), 'per_shift'), $7, $8, $9, $10, $10)
+	`
+	_, err := tx.Exec(query, app.ID, app.StoreID, app.TukangID, app.VacancyID, terms.StartDate, terms.PayBasis, terms.WageAmount, terms.FeeSplitPercent, shiftPattern, now)
+	if err != nil {
+		return fmt.Errorf("failed to start employment: %w", err)
+	}
+	return nil
+}
+
+// rejectPending rejects, on the system's behalf, the sent applications
+// matching where (a condition on applications taking id as $1).
+func rejectPending(tx *sql.Tx, where string, id int, now int64) error {
+	query := fmt.Sprintf(`
+		WITH rejected AS (
+			UPDATE applications
+			SET status = 'rejected', updated_at = $2
+			WHERE %s AND status = 'sent'
+			RETURNING id
+		)
+		INSERT INTO application_events (application_id, from_status, to_status, actor, actor_user_id, created_at)
+		SELECT id, 'sent', 'rejected', 'system', NULL, $2 FROM rejected
+	`, where)
+	if _, err := tx.Exec(query, id, now); err != nil {
+		return fmt.Errorf("failed to reject pending applications: %w", err)
+	}
+	return nil
+}
+
+func (r *applicationRepository) Events(appID int) ([]appEntity.ApplicationEvent, error) {
+	query := `
+		SELECT id, application_id, from_status, to_status, actor, actor_user_id, created_at
+		FROM application_events
+		WHERE application_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []appEntity.ApplicationEvent{}
+	for rows.Next() {
+		var event appEntity.ApplicationEvent
+		var actorUserID sql.NullInt64
+		if err := rows.Scan(&event.ID, &event.ApplicationID, &event.FromStatus, &event.ToStatus, &event.Actor, &actorUserID, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if actorUserID.Valid {
+			id := int(actorUserID.Int64)
+			event.ActorUserID = &id
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func nullableID(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	id := int(v.Int64)
+	return &id
 }
 
 func NewApplicationRepository(db *sql.DB) ApplicationRepository {
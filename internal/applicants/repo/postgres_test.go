@@ -0,0 +1,240 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	appEntity "github.com/ghulammuzz/backend-parkerin/internal/applicants/entity"
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/testdb"
+)
+
+const workers = 20
+
+func vacancy(t *testing.T, db *sql.DB, storeID, slots int) int {
+	t.Helper()
+
+	var id int
+	now := time.Now().Unix()
+	err := db.QueryRow(`
+		INSERT INTO vacancies (store_id, title, shift_start, shift_end, days, slots, created_at, updated_at)
+		VALUES ($1, 'Jukir', '08:00', '16:00', '{mon,tue}', $2, $3, $3)
+		RETURNING id
+	`, storeID, slots, now).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to insert vacancy: %v", err)
+	}
+	return id
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// applyFor applies tukangID to the vacancy and returns the application.
+func applyFor(t *testing.T, r ApplicationRepository, db *sql.DB, tukangID, storeID, vacancyID int) appEntity.ApplicationState {
+	t.Helper()
+
+	if err := r.Apply(tukangID, storeID, false, &vacancyID); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	var appID int
+	if err := db.QueryRow(`SELECT id FROM applications WHERE tukang_id = $1 AND vacancy_id = $2 AND status = 'sent'`, tukangID, vacancyID).Scan(&appID); err != nil {
+		t.Fatal(err)
+	}
+	app, err := r.GetState(appID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func terms() *empEntity.Terms {
+	return &empEntity.Terms{StartDate: time.Now().Format("2006-01-02")}
+}
+
+// concurrently runs fn on workers goroutines released at the same moment.
+func concurrently(fn func(i int)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestApplyConcurrentSamePair(t *testing.T) {
+	db := testdb.Open(t)
+	r := NewApplicationRepository(db)
+	storeID, _ := testdb.Store(t, db)
+	vacancyID := vacancy(t, db, storeID, 1)
+	tukangID := testdb.User(t, db, "tukang")
+
+	var mu sync.Mutex
+	var applied, exists int
+	concurrently(func(int) {
+		err := r.Apply(tukangID, storeID, false, &vacancyID)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err == nil:
+			applied++
+		case errors.Is(err, ErrApplicationExists):
+			exists++
+		default:
+			t.Errorf("apply: %v", err)
+		}
+	})
+
+	if applied != 1 || exists != workers-1 {
+		t.Fatalf("applied %d, refused as existing %d; want 1 and %d", applied, exists, workers-1)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM applications WHERE tukang_id = $1 AND store_id = $2 AND status = 'sent'`, tukangID, storeID); n != 1 {
+		t.Fatalf("%d pending applications, want 1", n)
+	}
+}
+
+func TestAcceptConcurrentSameApplication(t *testing.T) {
+	db := testdb.Open(t)
+	r := NewApplicationRepository(db)
+	storeID, storeUserID := testdb.Store(t, db)
+	vacancyID := vacancy(t, db, storeID, 3)
+	app := applyFor(t, r, db, testdb.User(t, db, "tukang"), storeID, vacancyID)
+	actor := appEntity.Actor{Role: appEntity.ActorStore, UserID: storeUserID, StoreID: storeID}
+
+	var mu sync.Mutex
+	var won int
+	concurrently(func(int) {
+		ok, err := r.Accept(app, actor, terms())
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			t.Errorf("accept: %v", err)
+		}
+		if ok {
+			won++
+		}
+	})
+
+	if won != 1 {
+		t.Fatalf("%d accepts won, want 1", won)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM employments WHERE application_id = $1`, app.ID); n != 1 {
+		t.Fatalf("%d employments, want 1", n)
+	}
+}
+
+func TestAcceptConcurrentNeverOverfills(t *testing.T) {
+	const slots = 2
+
+	db := testdb.Open(t)
+	r := NewApplicationRepository(db)
+	storeID, storeUserID := testdb.Store(t, db)
+	vacancyID := vacancy(t, db, storeID, slots)
+	actor := appEntity.Actor{Role: appEntity.ActorStore, UserID: storeUserID, StoreID: storeID}
+
+	apps := make([]appEntity.ApplicationState, workers)
+	for i := range apps {
+		apps[i] = applyFor(t, r, db, testdb.User(t, db, "tukang"), storeID, vacancyID)
+	}
+
+	var mu sync.Mutex
+	var won int
+	concurrently(func(i int) {
+		ok, err := r.Accept(apps[i], actor, terms())
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil && !errors.Is(err, ErrHiringCapacityReached) {
+			t.Errorf("accept: %v", err)
+		}
+		if ok {
+			won++
+		}
+	})
+
+	if won != slots {
+		t.Fatalf("%d accepts won, want %d", won, slots)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM employments WHERE vacancy_id = $1 AND status = 'active'`, vacancyID); n != slots {
+		t.Fatalf("%d active employments, want %d", n, slots)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM vacancies WHERE id = $1 AND status = 'filled'`, vacancyID); n != 1 {
+		t.Fatal("vacancy is not filled")
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM applications WHERE vacancy_id = $1 AND status = 'sent'`, vacancyID); n != 0 {
+		t.Fatalf("%d applications still pending on a filled vacancy", n)
+	}
+}
+
+func TestApplyRacingLastAccept(t *testing.T) {
+	db := testdb.Open(t)
+	r := NewApplicationRepository(db)
+	storeID, storeUserID := testdb.Store(t, db)
+	vacancyID := vacancy(t, db, storeID, 1)
+	actor := appEntity.Actor{Role: appEntity.ActorStore, UserID: storeUserID, StoreID: storeID}
+	app := applyFor(t, r, db, testdb.User(t, db, "tukang"), storeID, vacancyID)
+
+	tukangs := make([]int, workers)
+	for i := range tukangs {
+		tukangs[i] = testdb.User(t, db, "tukang")
+	}
+
+	concurrently(func(i int) {
+		if i == 0 {
+			if ok, err := r.Accept(app, actor, terms()); err != nil || !ok {
+				t.Errorf("accept = %v, %v; want true", ok, err)
+			}
+			return
+		}
+		if err := r.Apply(tukangs[i], storeID, false, &vacancyID); err != nil && !errors.Is(err, ErrNotHiring) {
+			t.Errorf("apply: %v", err)
+		}
+	})
+
+	// an apply either lost to the accept or was rejected along with the
+	// rest of the pending applications when the last slot went
+	if n := count(t, db, `SELECT COUNT(*) FROM applications WHERE vacancy_id = $1 AND status = 'sent'`, vacancyID); n != 0 {
+		t.Fatalf("%d applications pending on a filled vacancy", n)
+	}
+	if n := count(t, db, `SELECT COUNT(*) FROM employments WHERE vacancy_id = $1 AND status = 'active'`, vacancyID); n != 1 {
+		t.Fatalf("%d active employments, want 1", n)
+	}
+}
+
+func TestApplyDirectHireIgnoresIsHiring(t *testing.T) {
+	db := testdb.Open(t)
+	r := NewApplicationRepository(db)
+	storeID, _ := testdb.Store(t, db)
+	if _, err := db.Exec(`UPDATE stores SET is_hiring = FALSE, hiring_capacity = 2 WHERE id = $1`, storeID); err != nil {
+		t.Fatal(err)
+	}
+	first, second, third := testdb.User(t, db, "tukang"), testdb.User(t, db, "tukang"), testdb.User(t, db, "tukang")
+
+	if err := r.Apply(first, storeID, false, nil); !errors.Is(err, ErrNotHiring) {
+		t.Fatalf("general apply to a store not hiring: got %v, want ErrNotHiring", err)
+	}
+	if err := r.Apply(first, storeID, true, nil); err != nil {
+		t.Fatalf("direct hire to a store not hiring: %v", err)
+	}
+
+	testdb.Employment(t, db, storeID, first)
+	testdb.Employment(t, db, storeID, second)
+	if err := r.Apply(third, storeID, true, nil); !errors.Is(err, ErrHiringCapacityReached) {
+		t.Fatalf("direct hire with capacity filled: got %v, want ErrHiringCapacityReached", err)
+	}
+}
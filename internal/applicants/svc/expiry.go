@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	appRepo "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+// ExpiryResult is what one expiry run did.
+type ExpiryResult struct {
+	Applications int `json:"applications"`
+	Offers       int `json:"offers"`
+}
+
+// ExpireStale returns the expiry job: tukang applications the store has not
+// answered within applicationTTL, and direct-hire offers the tukang has not
+// answered within offerTTL, are moved to expired by the system.
+func ExpireStale(appRepo appRepo.ApplicationRepository, applicationTTL, offerTTL time.Duration) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		now := time.Now()
+		result := ExpiryResult{}
+
+		var err error
+		result.Applications, err = appRepo.ExpireSent(false, now.Add(-applicationTTL).Unix())
+		if err != nil {
+			return result, err
+		}
+		result.Offers, err = appRepo.ExpireSent(true, now.Add(-offerTTL).Unix())
+		if err != nil {
+			return result, err
+		}
+
+		if result.Applications > 0 || result.Offers > 0 {
+			log.Info("stale applications expired", "applications", result.Applications, "offers", result.Offers)
+		}
+		return result, nil
+	}
+}
@@ -3,23 +3,27 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	appEntity "github.com/ghulammuzz/backend-parkerin/internal/applicants/entity"
 	appRepo "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
 	storeRepo "github.com/ghulammuzz/backend-parkerin/internal/store/repo"
 	userRepo "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
+	vacRepo "github.com/ghulammuzz/backend-parkerin/internal/vacancies/repo"
 
 	"github.com/ghulammuzz/backend-parkerin/pkg/log"
 )
 
 type ApplicationService interface {
-	CreateApply(userID, storeID int, isDirectHire bool) error
+	CreateApply(userID, storeID int, isDirectHire bool, vacancyID *int) error
+	ApplyVacancy(userID, vacancyID int) error
 	ReviewApplications(storeID int) ([]appEntity.ApplicationResponse, error)
 	ReviewApplicationsUser(userID int, isDirectHire bool) ([]appEntity.ApplicationUserResponse, error)
-	AcceptApplicationUser(appID, userID int) error
-	RejectApplicationUser(appID, userID int) error
-	AcceptApplicationStore(appID, storeID int) error
-	RejectApplicationStore(appID, storeID int) error
+	ChangeStatus(appID int, actor appEntity.Actor, to string, terms *empEntity.Terms) error
+	Events(appID int, actor appEntity.Actor) ([]appEntity.ApplicationEvent, error)
+	Detail(appID int, actor appEntity.Actor) (*appEntity.ApplicationUserResponseDetail, error)
 	DeleteAppsInUser(userID, appID int) error
 }
 
@@ -27,17 +31,46 @@ type applicationService struct {
 	appRepo   appRepo.ApplicationRepository
 	storeRepo storeRepo.StoreRepository
 	userRepo  userRepo.UserRepository
+	vacRepo   vacRepo.VacancyRepository
 }
 
+// DeleteAppsInUser takes the tukang out of a pending application through the
+// state machine instead of deleting it, so its history stays: their own
+// application is withdrawn and a store's offer is rejected. Decided
+// applications are final and report ErrIllegalTransition.
 func (s *applicationService) DeleteAppsInUser(userID, appID int) error {
-	return s.appRepo.DeleteApplicantsByUserIDAppsID(userID, appID)
+	actor := appEntity.Actor{Role: appEntity.ActorTukang, UserID: userID}
+	app, err := s.partyApplication(appID, actor)
+	if err != nil {
+		return err
+	}
+
+	to := appEntity.StatusWithdrawn
+	if recipient(app) == appEntity.ActorTukang {
+		to = appEntity.StatusRejected
+	}
+	return s.ChangeStatus(appID, actor, to, nil)
 }
 
 func (s *applicationService) ReviewApplicationsUser(userID int, isDirectHire bool) ([]appEntity.ApplicationUserResponse, error) {
 	return s.appRepo.GetApplicationsByUser(userID, isDirectHire)
 }
 
-func (s *applicationService) CreateApply(userID, storeID int, isDirectHire bool) error {
+// ApplyVacancy lets a tukang apply for a specific vacancy.
+func (s *applicationService) ApplyVacancy(userID, vacancyID int) error {
+	vacancy, err := s.vacRepo.Detail(vacancyID)
+	if err != nil {
+		if errors.Is(err, vacRepo.ErrVacancyNotFound) {
+			return ErrVacancyNotFound
+		}
+		return err
+	}
+	return s.CreateApply(userID, vacancy.StoreID, false, &vacancy.ID)
+}
+
+// CreateApply records an application between the tukang and the store,
+// optionally for one of the store's vacancies.
+func (s *applicationService) CreateApply(userID, storeID int, isDirectHire bool, vacancyID *int) error {
 	storeExists, err := s.storeRepo.IsStoreIDValid(storeID)
 	if err != nil {
 		return err
@@ -56,48 +89,160 @@ func (s *applicationService) CreateApply(userID, storeID int, isDirectHire bool)
 		return errors.New("invalid user ID")
 	}
 
-	appExists, err := s.appRepo.CheckApplicantsAlreadyExist(userID, storeID)
+	if vacancyID != nil {
+		vacancy, err := s.vacRepo.Detail(*vacancyID)
+		if err != nil {
+			if errors.Is(err, vacRepo.ErrVacancyNotFound) {
+				return ErrVacancyNotFound
+			}
+			return err
+		}
+		if vacancy.StoreID != storeID {
+			return ErrVacancyNotFound
+		}
+	}
+
+	err = s.appRepo.Apply(userID, storeID, isDirectHire, vacancyID)
+	switch {
+	case errors.Is(err, appRepo.ErrNotHiring):
+		return ErrNotHiring
+	case errors.Is(err, appRepo.ErrHiringCapacityReached):
+		return ErrCapacityReached
+	case errors.Is(err, appRepo.ErrApplicationExists):
+		return ErrApplicationExists
+	case errors.Is(err, appRepo.ErrAlreadyEmployed):
+		return ErrAlreadyEmployed
+	case err != nil:
+		return err
+	}
+
+	return nil
+}
+
+func (s *applicationService) ReviewApplications(storeID int) ([]appEntity.ApplicationResponse, error) {
+	return s.appRepo.GetApplicationsByStore(storeID)
+}
+
+// ChangeStatus moves the application to `to` on behalf of actor, enforcing
+// the state machine in state.go. Accepting starts an employment on terms,
+// which may be nil for the defaults.
+func (s *applicationService) ChangeStatus(appID int, actor appEntity.Actor, to string, terms *empEntity.Terms) error {
+	app, err := s.partyApplication(appID, actor)
 	if err != nil {
-		log.Error("Database error:", err)
 		return err
 	}
-	if appExists {
-		return errors.New("application already exists for this user and store")
+
+	if err := checkTransition(app, actor, to); err != nil {
+		return err
 	}
 
-	err = s.appRepo.Apply(userID, storeID, isDirectHire)
+	var ok bool
+	if to == appEntity.StatusAccepted {
+		if terms == nil {
+			terms, err = s.defaultTerms(app)
+			if err != nil {
+				return err
+			}
+		}
+		if terms.StartDate == "" {
+			terms.StartDate = time.Now().In(jakarta).Format(time.DateOnly)
+		}
+		if terms.ShiftPattern != nil {
+			if err := terms.ShiftPattern.Validate(); err != nil {
+				return fmt.Errorf("%w: shift_pattern: %v", ErrInvalidTerms, err)
+			}
+		}
+		ok, err = s.appRepo.Accept(app, actor, terms)
+		switch {
+		case errors.Is(err, appRepo.ErrHiringCapacityReached):
+			return ErrCapacityReached
+		case errors.Is(err, appRepo.ErrAlreadyEmployed):
+			return ErrAlreadyEmployed
+		}
+	} else {
+		ok, err = s.appRepo.Transition(app.ID, app.Status, to, actor)
+	}
 	if err != nil {
 		return err
 	}
-	// log.Debug(fmt.Sprint(isDirectHire))
+	if !ok {
+		// someone else decided the application between our read and write
+		return ErrIllegalTransition
+	}
 
+	log.Info("application status changed", "application_id", app.ID, "from", app.Status, "to", to, "actor", actor.Role, "actor_user_id", actor.UserID)
 	return nil
 }
 
-func (s *applicationService) ReviewApplications(storeID int) ([]appEntity.ApplicationResponse, error) {
-	return s.appRepo.GetApplicationsByStore(storeID)
-}
+// defaultTerms are used when an acceptance comes without explicit terms: the
+// vacancy's fee and shift if the application targets one, nothing otherwise.
+func (s *applicationService) defaultTerms(app appEntity.ApplicationState) (*empEntity.Terms, error) {
+	terms := &empEntity.Terms{}
+	if app.VacancyID == nil {
+		return terms, nil
+	}
 
-func (s *applicationService) AcceptApplicationUser(appID, userID int) error {
-	return s.appRepo.UpdateApplicationStatusUser(appID, userID, "accepted")
+	vacancy, err := s.vacRepo.Detail(*app.VacancyID)
+	if err != nil {
+		if errors.Is(err, vacRepo.ErrVacancyNotFound) {
+			return terms, nil
+		}
+		return nil, err
+	}
+	terms.PayBasis = empEntity.PayPerShift
+	terms.WageAmount = vacancy.Fee
+	terms.ShiftPattern = vacancy.ShiftPattern()
+	return terms, nil
 }
 
-func (s *applicationService) RejectApplicationUser(appID, userID int) error {
-	return s.appRepo.UpdateApplicationStatusUser(appID, userID, "rejected")
+func (s *applicationService) Events(appID int, actor appEntity.Actor) ([]appEntity.ApplicationEvent, error) {
+	if _, err := s.partyApplication(appID, actor); err != nil {
+		return nil, err
+	}
+	return s.appRepo.Events(appID)
 }
 
-func (s *applicationService) AcceptApplicationStore(appID, storeID int) error {
-	return s.appRepo.UpdateApplicationStatusStore(appID, storeID, "accepted")
+func (s *applicationService) Detail(appID int, actor appEntity.Actor) (*appEntity.ApplicationUserResponseDetail, error) {
+	if _, err := s.partyApplication(appID, actor); err != nil {
+		return nil, err
+	}
+
+	detail, err := s.appRepo.Detail(appID)
+	if err != nil {
+		if errors.Is(err, appRepo.ErrApplicationNotFound) {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, err
+	}
+
+	detail.Events, err = s.appRepo.Events(appID)
+	if err != nil {
+		return nil, err
+	}
+	return &detail, nil
 }
 
-func (s *applicationService) RejectApplicationStore(appID, storeID int) error {
-	return s.appRepo.UpdateApplicationStatusStore(appID, storeID, "rejected")
+// partyApplication loads the application and hides it from anyone who is not
+// one of its parties.
+func (s *applicationService) partyApplication(appID int, actor appEntity.Actor) (appEntity.ApplicationState, error) {
+	app, err := s.appRepo.GetState(appID)
+	if err != nil {
+		if errors.Is(err, appRepo.ErrApplicationNotFound) {
+			return appEntity.ApplicationState{}, ErrApplicationNotFound
+		}
+		return appEntity.ApplicationState{}, err
+	}
+	if !isParty(app, actor) {
+		return appEntity.ApplicationState{}, ErrApplicationNotFound
+	}
+	return app, nil
 }
 
-func NewApplicationService(appRepo appRepo.ApplicationRepository, storeRepo storeRepo.StoreRepository, userRepo userRepo.UserRepository) ApplicationService {
+func NewApplicationService(appRepo appRepo.ApplicationRepository, storeRepo storeRepo.StoreRepository, userRepo userRepo.UserRepository, vacRepo vacRepo.VacancyRepository) ApplicationService {
 	return &applicationService{
 		appRepo:   appRepo,
 		storeRepo: storeRepo,
 		userRepo:  userRepo,
+		vacRepo:   vacRepo,
 	}
 }
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	appEntity "github.com/ghulammuzz/backend-parkerin/internal/applicants/entity"
+)
+
+var (
+	// ErrIllegalTransition is returned when the application's current status
+	// or the actor's side of it does not allow the requested change.
+	ErrIllegalTransition = errors.New("application status change is not allowed")
+	// ErrApplicationNotFound hides applications the actor is not a party to.
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrCapacityReached     = errors.New("store has no hiring capacity left")
+	ErrInvalidTerms        = errors.New("invalid employment terms")
+	ErrVacancyNotFound     = errors.New("vacancy not found")
+	ErrNotHiring           = errors.New("store or vacancy is not hiring")
+	ErrApplicationExists   = errors.New("application already exists for this user and store")
+	ErrAlreadyEmployed     = errors.New("tukang already works at this store")
+)
+
+// jakarta is the zone employment start dates are expressed in (WIB, no DST).
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+// transitions lists, per status, the statuses it may move to. Every status
+// other than sent is final.
+var transitions = map[string][]string{
+	appEntity.StatusSent: {
+		appEntity.StatusAccepted,
+		appEntity.StatusRejected,
+		appEntity.StatusWithdrawn,
+		appEntity.StatusExpired,
+	},
+}
+
+// initiator is the side that created the application: the store for a direct
+// hire, the tukang otherwise.
+func initiator(app appEntity.ApplicationState) string {
+	if app.IsDirectHire {
+		return appEntity.ActorStore
+	}
+	return appEntity.ActorTukang
+}
+
+func recipient(app appEntity.ApplicationState) string {
+	if app.IsDirectHire {
+		return appEntity.ActorTukang
+	}
+	return appEntity.ActorStore
+}
+
+// isParty reports whether actor is the tukang or the store on app.
+func isParty(app appEntity.ApplicationState, actor appEntity.Actor) bool {
+	switch actor.Role {
+	case appEntity.ActorTukang:
+		return app.TukangID == actor.UserID
+	case appEntity.ActorStore:
+		return app.StoreID == actor.StoreID
+	case appEntity.ActorSystem:
+		return true
+	}
+	return false
+}
+
+// checkTransition applies the state machine and the who-may-do-what rules:
+// only the recipient accepts or rejects, only the initiator withdraws, and
+// only the system expires.
+func checkTransition(app appEntity.ApplicationState, actor appEntity.Actor, to string) error {
+	allowed := false
+	for _, next := range transitions[app.Status] {
+		if next == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrIllegalTransition
+	}
+
+	var by string
+	switch to {
+	case appEntity.StatusAccepted, appEntity.StatusRejected:
+		by = recipient(app)
+	case appEntity.StatusWithdrawn:
+		by = initiator(app)
+	case appEntity.StatusExpired:
+		by = appEntity.ActorSystem
+	}
+	if actor.Role != by {
+		return ErrIllegalTransition
+	}
+	return nil
+}
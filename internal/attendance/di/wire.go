@@ -0,0 +1,22 @@
+package di
+
+import (
+	"database/sql"
+
+	"github.com/ghulammuzz/backend-parkerin/config"
+	"github.com/ghulammuzz/backend-parkerin/internal/attendance/handler"
+	attRepo "github.com/ghulammuzz/backend-parkerin/internal/attendance/repo"
+	attSvc "github.com/ghulammuzz/backend-parkerin/internal/attendance/svc"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/wire"
+)
+
+func InitializedAttendanceServiceFake(sb *sql.DB, val *validator.Validate, cfg config.AttendanceConfig) *handler.AttendanceHandler {
+	wire.Build(
+		handler.NewAttendanceHandler,
+		attSvc.NewAttendanceService,
+		attRepo.NewAttendanceRepository,
+	)
+
+	return &handler.AttendanceHandler{}
+}
@@ -0,0 +1,55 @@
+package entity
+
+type Attendance struct {
+	ID                int      `json:"id"`
+	EmploymentID      int      `json:"employment_id"`
+	StoreID           int      `json:"store_id"`
+	StoreName         string   `json:"store_name"`
+	TukangID          int      `json:"tukang_id"`
+	TukangName        string   `json:"tukang_name"`
+	CheckInAt         int64    `json:"check_in_at"`
+	CheckInDeviceAt   int64    `json:"check_in_device_at"`
+	CheckInLatitude   float64  `json:"check_in_latitude"`
+	CheckInLongitude  float64  `json:"check_in_longitude"`
+	CheckInDistanceM  int      `json:"check_in_distance_m"`
+	CheckOutAt        *int64   `json:"check_out_at"`
+	CheckOutDeviceAt  *int64   `json:"check_out_device_at"`
+	CheckOutLatitude  *float64 `json:"check_out_latitude"`
+	CheckOutLongitude *float64 `json:"check_out_longitude"`
+	CheckOutDistanceM *int     `json:"check_out_distance_m"`
+	// CheckOutOutsideGeofence marks a check-out made beyond the radius.
+	CheckOutOutsideGeofence bool `json:"check_out_outside_geofence"`
+	// WorkedMinutes is set once the tukang has checked out.
+	WorkedMinutes *int `json:"worked_minutes"`
+}
+
+// Site is the employment a check-in is made against and where its store is.
+type Site struct {
+	EmploymentID int
+	StoreID      int
+	TukangID     int
+	Status       string
+	Latitude     float64
+	Longitude    float64
+}
+
+// DeviceTime is the phone's clock as unix seconds, kept next to the server
+// time so clock tampering shows up in the records.
+type CheckInRequest struct {
+	EmploymentID int     `json:"employment_id" validate:"required,min=1"`
+	Latitude     float64 `json:"latitude" validate:"latitude"`
+	Longitude    float64 `json:"longitude" validate:"longitude"`
+	DeviceTime   int64   `json:"device_time" validate:"required,min=1"`
+}
+
+type CheckOutRequest struct {
+	Latitude   float64 `json:"latitude" validate:"latitude"`
+	Longitude  float64 `json:"longitude" validate:"longitude"`
+	DeviceTime int64   `json:"device_time" validate:"required,min=1"`
+}
+
+// Range is a half-open [From, To) window of check-in times, unix seconds.
+type Range struct {
+	From int64
+	To   int64
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	attEntity "github.com/ghulammuzz/backend-parkerin/internal/attendance/entity"
+	attRepo "github.com/ghulammuzz/backend-parkerin/internal/attendance/repo"
+	attService "github.com/ghulammuzz/backend-parkerin/internal/attendance/svc"
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	"github.com/ghulammuzz/backend-parkerin/pkg/form"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AttendanceHandler struct {
+	attService attService.AttendanceService
+	val        *validator.Validate
+}
+
+func NewAttendanceHandler(attService attService.AttendanceService, val *validator.Validate) *AttendanceHandler {
+	return &AttendanceHandler{attService, val}
+}
+
+func (h *AttendanceHandler) Router(r fiber.Router) {
+	r.Post("/attendance/check-in", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.CheckIn)
+	r.Post("/attendance/check-out", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.CheckOut)
+	r.Get("/attendance/user", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.ListUserAttendance)
+	r.Get("/attendance/store", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.ListStoreAttendance)
+}
+
+func (h *AttendanceHandler) CheckIn(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	req := new(attEntity.CheckInRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	att, err := h.attService.CheckIn(principal.UserID, req)
+	if err != nil {
+		return attendanceError(c, "Failed to check in", err)
+	}
+
+	return response.JSON(c, fiber.StatusCreated, "Checked in", att)
+}
+
+func (h *AttendanceHandler) CheckOut(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	req := new(attEntity.CheckOutRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	att, err := h.attService.CheckOut(principal.UserID, req)
+	if err != nil {
+		return attendanceError(c, "Failed to check out", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Checked out", att)
+}
+
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD, the last seven days when omitted
+func (h *AttendanceHandler) ListUserAttendance(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	period, err := attService.ParseRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		return attendanceError(c, "Invalid date range", err)
+	}
+
+	attendances, err := h.attService.ListForTukang(principal.UserID, period)
+	if err != nil {
+		return attendanceError(c, "Failed to retrieve attendance", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Attendance retrieved successfully", attendances)
+}
+
+// ?tukang_id=&from=YYYY-MM-DD&to=YYYY-MM-DD; every tukang when tukang_id is
+// omitted
+func (h *AttendanceHandler) ListStoreAttendance(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	tukangID := 0
+	if raw := c.Query("tukang_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id < 1 {
+			log.Error("Invalid tukang ID", slog.String("tukang_id", raw))
+			return response.JSON(c, fiber.StatusBadRequest, "Invalid tukang ID", nil)
+		}
+		tukangID = id
+	}
+
+	period, err := attService.ParseRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		return attendanceError(c, "Invalid date range", err)
+	}
+
+	attendances, err := h.attService.ListForStore(principal.StoreID, tukangID, period)
+	if err != nil {
+		return attendanceError(c, "Failed to retrieve attendance", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Attendance retrieved successfully", attendances)
+}
+
+func attendanceError(c *fiber.Ctx, message string, err error) error {
+	log.Error(message, slog.String("error", err.Error()))
+	switch {
+	case errors.Is(err, attRepo.ErrEmploymentNotFound):
+		return response.JSON(c, fiber.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, attService.ErrInvalidRange):
+		return response.JSON(c, fiber.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, attService.ErrOutsideGeofence):
+		return response.JSON(c, fiber.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, attRepo.ErrAlreadyCheckedIn), errors.Is(err, attRepo.ErrNotCheckedIn),
+		errors.Is(err, attService.ErrEmploymentNotActive), errors.Is(err, attService.ErrStoreLocationMissing):
+		return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+	}
+	return response.JSON(c, fiber.StatusInternalServerError, message, err.Error())
+}
@@ -0,0 +1,210 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	attEntity "github.com/ghulammuzz/backend-parkerin/internal/attendance/entity"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrEmploymentNotFound = errors.New("employment not found")
+	ErrAlreadyCheckedIn   = errors.New("tukang is already checked in")
+	ErrNotCheckedIn       = errors.New("tukang is not checked in")
+)
+
+type AttendanceRepository interface {
+	Site(employmentID int) (*attEntity.Site, error)
+	Open(tukangID int) (*attEntity.Attendance, *attEntity.Site, error)
+	CheckIn(att *attEntity.Attendance) error
+	CheckOut(att *attEntity.Attendance) (bool, error)
+	ListByStore(storeID, tukangID int, period attEntity.Range) ([]attEntity.Attendance, error)
+	ListByTukang(tukangID int, period attEntity.Range) ([]attEntity.Attendance, error)
+	ListByEmployment(employmentID int, period attEntity.Range) ([]attEntity.Attendance, error)
+}
+
+type attendanceRepository struct {
+	db *sql.DB
+}
+
+const selectAttendance = `
+	SELECT a.id, a.employment_id, a.store_id, s.store_name, a.tukang_id, u.name,
+	       a.check_in_at, a.check_in_device_at, a.check_in_latitude, a.check_in_longitude, a.check_in_distance_m,
+	       a.check_out_at, a.check_out_device_at, a.check_out_latitude, a.check_out_longitude, a.check_out_distance_m,
+	       a.check_out_outside_geofence
+	FROM attendances a
+	JOIN stores s ON a.store_id = s.id
+	JOIN users u ON a.tukang_id = u.id
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAttendance(row rowScanner) (*attEntity.Attendance, error) {
+	att := &attEntity.Attendance{}
+	var outAt, outDeviceAt, outDistance sql.NullInt64
+	var outLat, outLng sql.NullFloat64
+	err := row.Scan(
+		&att.ID,
+		&att.EmploymentID,
+		&att.StoreID,
+		&att.StoreName,
+		&att.TukangID,
+		&att.TukangName,
+		&att.CheckInAt,
+		&att.CheckInDeviceAt,
+		&att.CheckInLatitude,
+		&att.CheckInLongitude,
+		&att.CheckInDistanceM,
+		&outAt,
+		&outDeviceAt,
+		&outLat,
+		&outLng,
+		&outDistance,
+		&att.CheckOutOutsideGeofence,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if outAt.Valid {
+		att.CheckOutAt = &outAt.Int64
+		att.CheckOutDeviceAt = &outDeviceAt.Int64
+		att.CheckOutLatitude = &outLat.Float64
+		att.CheckOutLongitude = &outLng.Float64
+		distance := int(outDistance.Int64)
+		att.CheckOutDistanceM = &distance
+		worked := int((outAt.Int64 - att.CheckInAt) / 60)
+		att.WorkedMinutes = &worked
+	}
+	return att, nil
+}
+
+func (r *attendanceRepository) Site(employmentID int) (*attEntity.Site, error) {
+	query := `
+		SELECT e.id, e.store_id, e.tukang_id, e.status, s.latitude, s.longitude
+		FROM employments e
+		JOIN stores s ON e.store_id = s.id
+		WHERE e.id = $1
+	`
+	site := &attEntity.Site{}
+	err := r.db.QueryRow(query, employmentID).Scan(&site.EmploymentID, &site.StoreID, &site.TukangID, &site.Status, &site.Latitude, &site.Longitude)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEmploymentNotFound
+		}
+		return nil, err
+	}
+	return site, nil
+}
+
+// Open returns the tukang's attendance that has not been checked out yet and
+// the site it was checked in at.
+func (r *attendanceRepository) Open(tukangID int) (*attEntity.Attendance, *attEntity.Site, error) {
+	att, err := scanAttendance(r.db.QueryRow(selectAttendance+` WHERE a.tukang_id = $1 AND a.check_out_at IS NULL`, tukangID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrNotCheckedIn
+		}
+		return nil, nil, err
+	}
+
+	site, err := r.Site(att.EmploymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return att, site, nil
+}
+
+func (r *attendanceRepository) CheckIn(att *attEntity.Attendance) error {
+	query := `
+		INSERT INTO attendances (employment_id, store_id, tukang_id, check_in_at, check_in_device_at,
+		                         check_in_latitude, check_in_longitude, check_in_distance_m)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	err := r.db.QueryRow(query, att.EmploymentID, att.StoreID, att.TukangID, att.CheckInAt, att.CheckInDeviceAt,
+		att.CheckInLatitude, att.CheckInLongitude, att.CheckInDistanceM).Scan(&att.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "ux_attendances_open" {
+			return ErrAlreadyCheckedIn
+		}
+		return fmt.Errorf("failed to check in: %w", err)
+	}
+	return nil
+}
+
+// CheckOut closes the attendance. It reports false when it was already
+// checked out.
+func (r *attendanceRepository) CheckOut(att *attEntity.Attendance) (bool, error) {
+	query := `
+		UPDATE attendances
+		SET check_out_at = $1, check_out_device_at = $2, check_out_latitude = $3,
+		    check_out_longitude = $4, check_out_distance_m = $5, check_out_outside_geofence = $6
+		WHERE id = $7 AND check_out_at IS NULL
+	`
+	result, err := r.db.Exec(query, att.CheckOutAt, att.CheckOutDeviceAt, att.CheckOutLatitude, att.CheckOutLongitude,
+		att.CheckOutDistanceM, att.CheckOutOutsideGeofence, att.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check out: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// ListByStore returns the store's attendances checked in within period,
+// limited to one tukang unless tukangID is 0.
+func (r *attendanceRepository) ListByStore(storeID, tukangID int, period attEntity.Range) ([]attEntity.Attendance, error) {
+	query := selectAttendance + `
+		WHERE a.store_id = $1 AND ($2 = 0 OR a.tukang_id = $2)
+		  AND a.check_in_at >= $3 AND a.check_in_at < $4
+		ORDER BY a.check_in_at DESC
+	`
+	return r.list(query, storeID, tukangID, period.From, period.To)
+}
+
+func (r *attendanceRepository) ListByTukang(tukangID int, period attEntity.Range) ([]attEntity.Attendance, error) {
+	query := selectAttendance + `
+		WHERE a.tukang_id = $1 AND a.check_in_at >= $2 AND a.check_in_at < $3
+		ORDER BY a.check_in_at DESC
+	`
+	return r.list(query, tukangID, period.From, period.To)
+}
+
+// ListByEmployment returns the attendances of the employment checked in
+// within period, oldest first.
+func (r *attendanceRepository) ListByEmployment(employmentID int, period attEntity.Range) ([]attEntity.Attendance, error) {
+	query := selectAttendance + `
+		WHERE a.employment_id = $1 AND a.check_in_at >= $2 AND a.check_in_at < $3
+		ORDER BY a.check_in_at
+	`
+	return r.list(query, employmentID, period.From, period.To)
+}
+
+func (r *attendanceRepository) list(query string, args ...interface{}) ([]attEntity.Attendance, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attendances := []attEntity.Attendance{}
+	for rows.Next() {
+		att, err := scanAttendance(rows)
+		if err != nil {
+			return nil, err
+		}
+		attendances = append(attendances, *att)
+	}
+	return attendances, rows.Err()
+}
+
+func NewAttendanceRepository(db *sql.DB) AttendanceRepository {
+	return &attendanceRepository{db: db}
+}
@@ -0,0 +1,198 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ghulammuzz/backend-parkerin/config"
+	attEntity "github.com/ghulammuzz/backend-parkerin/internal/attendance/entity"
+	attRepo "github.com/ghulammuzz/backend-parkerin/internal/attendance/repo"
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/geo"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+var (
+	ErrEmploymentNotActive  = errors.New("employment is not active")
+	ErrOutsideGeofence      = errors.New("too far from the store")
+	ErrStoreLocationMissing = errors.New("store has no location set")
+	ErrInvalidRange         = errors.New("invalid date range")
+)
+
+// maxRangeDays bounds how much attendance one request may list.
+const maxRangeDays = 93
+
+// maxClockSkew is how far the device clock may drift from the server before
+// the check is flagged in the logs; the check itself is still recorded.
+const maxClockSkew = 5 * time.Minute
+
+// jakarta is the zone attendance dates are expressed in (WIB, no DST).
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+type AttendanceService interface {
+	CheckIn(tukangID int, req *attEntity.CheckInRequest) (*attEntity.Attendance, error)
+	CheckOut(tukangID int, req *attEntity.CheckOutRequest) (*attEntity.Attendance, error)
+	ListForStore(storeID, tukangID int, period attEntity.Range) ([]attEntity.Attendance, error)
+	ListForTukang(tukangID int, period attEntity.Range) ([]attEntity.Attendance, error)
+}
+
+type attendanceService struct {
+	attRepo attRepo.AttendanceRepository
+	cfg     config.AttendanceConfig
+}
+
+func (s *attendanceService) CheckIn(tukangID int, req *attEntity.CheckInRequest) (*attEntity.Attendance, error) {
+	site, err := s.attRepo.Site(req.EmploymentID)
+	if err != nil {
+		return nil, err
+	}
+	if site.TukangID != tukangID {
+		return nil, attRepo.ErrEmploymentNotFound
+	}
+	if site.Status != empEntity.StatusActive {
+		return nil, ErrEmploymentNotActive
+	}
+
+	distance, err := s.checkGeofence(site, req.Latitude, req.Longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	att := &attEntity.Attendance{
+		EmploymentID:     site.EmploymentID,
+		StoreID:          site.StoreID,
+		TukangID:         tukangID,
+		CheckInAt:        now,
+		CheckInDeviceAt:  req.DeviceTime,
+		CheckInLatitude:  req.Latitude,
+		CheckInLongitude: req.Longitude,
+		CheckInDistanceM: distance,
+	}
+	if err := s.attRepo.CheckIn(att); err != nil {
+		return nil, err
+	}
+
+	warnSkew("check-in", att.ID, now, req.DeviceTime)
+	return att, nil
+}
+
+// CheckOut closes the tukang's open attendance, wherever it was opened. It is
+// allowed even if the employment ended in the meantime, and beyond the
+// geofence: a tukang who already left must still be able to close the shift,
+// so the check-out is recorded with its distance and flagged instead.
+func (s *attendanceService) CheckOut(tukangID int, req *attEntity.CheckOutRequest) (*attEntity.Attendance, error) {
+	att, site, err := s.attRepo.Open(tukangID)
+	if err != nil {
+		return nil, err
+	}
+
+	meters, err := distanceFrom(site, req.Latitude, req.Longitude)
+	if err != nil {
+		return nil, err
+	}
+	distance := int(math.Round(meters))
+
+	now := time.Now().Unix()
+	worked := int((now - att.CheckInAt) / 60)
+	att.CheckOutAt = &now
+	att.CheckOutDeviceAt = &req.DeviceTime
+	att.CheckOutLatitude = &req.Latitude
+	att.CheckOutLongitude = &req.Longitude
+	att.CheckOutDistanceM = &distance
+	att.CheckOutOutsideGeofence = meters > s.cfg.RadiusMeters
+	att.WorkedMinutes = &worked
+
+	ok, err := s.attRepo.CheckOut(att)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, attRepo.ErrNotCheckedIn
+	}
+
+	if att.CheckOutOutsideGeofence {
+		log.Warn("attendance check-out outside geofence", "attendance_id", att.ID, "distance_m", distance)
+	}
+	warnSkew("check-out", att.ID, now, req.DeviceTime)
+	return att, nil
+}
+
+func (s *attendanceService) ListForStore(storeID, tukangID int, period attEntity.Range) ([]attEntity.Attendance, error) {
+	return s.attRepo.ListByStore(storeID, tukangID, period)
+}
+
+func (s *attendanceService) ListForTukang(tukangID int, period attEntity.Range) ([]attEntity.Attendance, error) {
+	return s.attRepo.ListByTukang(tukangID, period)
+}
+
+// checkGeofence returns the distance in meters from the store, or
+// ErrOutsideGeofence when it is beyond the configured radius.
+func (s *attendanceService) checkGeofence(site *attEntity.Site, lat, lng float64) (int, error) {
+	distance, err := distanceFrom(site, lat, lng)
+	if err != nil {
+		return 0, err
+	}
+	if distance > s.cfg.RadiusMeters {
+		return 0, fmt.Errorf("%w: %.0fm away, at most %.0fm allowed", ErrOutsideGeofence, distance, s.cfg.RadiusMeters)
+	}
+	return int(math.Round(distance)), nil
+}
+
+// distanceFrom returns how far lat, lng is from the store, in meters.
+func distanceFrom(site *attEntity.Site, lat, lng float64) (float64, error) {
+	// stores registered before coordinates were required were saved at 0,0
+	if site.Latitude == 0 && site.Longitude == 0 {
+		return 0, ErrStoreLocationMissing
+	}
+	return geo.DistanceKm(site.Latitude, site.Longitude, lat, lng) * 1000, nil
+}
+
+func warnSkew(check string, attendanceID int, serverTime, deviceTime int64) {
+	skew := time.Duration(serverTime-deviceTime) * time.Second
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		log.Warn("attendance device clock skew", "check", check, "attendance_id", attendanceID, "skew_seconds", int64(skew.Seconds()))
+	}
+}
+
+// ParseRange turns from and to (YYYY-MM-DD, WIB, both inclusive) into a
+// Range. Either may be empty: to defaults to today and from to six days
+// before to.
+func ParseRange(from, to string) (attEntity.Range, error) {
+	end := time.Now().In(jakarta)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, jakarta)
+	if to != "" {
+		t, err := time.ParseInLocation(time.DateOnly, to, jakarta)
+		if err != nil {
+			return attEntity.Range{}, fmt.Errorf("%w: to: %v", ErrInvalidRange, err)
+		}
+		end = t
+	}
+
+	start := end.AddDate(0, 0, -6)
+	if from != "" {
+		t, err := time.ParseInLocation(time.DateOnly, from, jakarta)
+		if err != nil {
+			return attEntity.Range{}, fmt.Errorf("%w: from: %v", ErrInvalidRange, err)
+		}
+		start = t
+	}
+
+	end = end.AddDate(0, 0, 1)
+	if !start.Before(end) {
+		return attEntity.Range{}, fmt.Errorf("%w: from is after to", ErrInvalidRange)
+	}
+	if end.Sub(start) > maxRangeDays*24*time.Hour {
+		return attEntity.Range{}, fmt.Errorf("%w: at most %d days", ErrInvalidRange, maxRangeDays)
+	}
+	return attEntity.Range{From: start.Unix(), To: end.Unix()}, nil
+}
+
+func NewAttendanceService(attRepo attRepo.AttendanceRepository, cfg config.AttendanceConfig) AttendanceService {
+	return &attendanceService{
+		attRepo: attRepo,
+		cfg:     cfg,
+	}
+}
@@ -0,0 +1,21 @@
+package di
+
+import (
+	"database/sql"
+
+	"github.com/ghulammuzz/backend-parkerin/internal/deposits/handler"
+	depRepo "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
+	depSvc "github.com/ghulammuzz/backend-parkerin/internal/deposits/svc"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/wire"
+)
+
+func InitializedDepositServiceFake(sb *sql.DB, val *validator.Validate) *handler.DepositHandler {
+	wire.Build(
+		handler.NewDepositHandler,
+		depSvc.NewDepositService,
+		depRepo.NewDepositRepository,
+	)
+
+	return &handler.DepositHandler{}
+}
@@ -0,0 +1,75 @@
+package entity
+
+const (
+	StatusSubmitted = "submitted"
+	StatusConfirmed = "confirmed"
+	StatusDisputed  = "disputed"
+)
+
+// Deposit is the cash handed over for one shift. Collected is the cash the
+// shift's parking tickets charged and Digital what was paid by QRIS straight
+// to the store. TukangShare is the part of both the tukang keeps under a
+// revenue-share employment, taken out of the cash; Expected is the cash left,
+// which is what Amount should be. Amounts are Rupiah.
+type Deposit struct {
+	ID           int    `json:"id"`
+	StoreID      int    `json:"store_id"`
+	StoreName    string `json:"store_name"`
+	TukangID     int    `json:"tukang_id"`
+	TukangName   string `json:"tukang_name"`
+	EmploymentID int    `json:"employment_id"`
+	AttendanceID int    `json:"attendance_id"`
+	ShiftStart   int64  `json:"shift_start"`
+	ShiftEnd     int64  `json:"shift_end"`
+	Collected    int64  `json:"collected"`
+	Digital      int64  `json:"digital"`
+	TukangShare  int64  `json:"tukang_share"`
+	Expected     int64  `json:"expected"`
+	Amount       int64  `json:"amount"`
+	// Discrepancy is Amount minus Expected: negative when cash is short.
+	Discrepancy int64  `json:"discrepancy"`
+	Flagged     bool   `json:"flagged"`
+	Status      string `json:"status"`
+	Note        string `json:"note"`
+	StoreNote   string `json:"store_note"`
+	SubmittedAt int64  `json:"submitted_at"`
+	DecidedAt   *int64 `json:"decided_at"`
+}
+
+// Shift is a finished attendance a deposit can be made for, with what its
+// tickets collected in cash and by QRIS.
+type Shift struct {
+	AttendanceID    int
+	EmploymentID    int
+	StoreID         int
+	TukangID        int
+	CheckInAt       int64
+	CheckOutAt      *int64
+	PayBasis        string
+	FeeSplitPercent int
+	Collected       int64
+	Digital         int64
+}
+
+// Summary is what the dashboards show about deposits.
+type Summary struct {
+	Submitted        int   `json:"submitted"`
+	Disputed         int   `json:"disputed"`
+	Flagged          int   `json:"flagged"`
+	TotalDiscrepancy int64 `json:"total_discrepancy"`
+}
+
+type SubmitRequest struct {
+	AttendanceID int    `json:"attendance_id" validate:"required,min=1"`
+	Amount       int64  `json:"amount" validate:"min=0"`
+	Note         string `json:"note" validate:"max=500"`
+}
+
+type ResubmitRequest struct {
+	Amount int64  `json:"amount" validate:"min=0"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+type DisputeRequest struct {
+	Note string `json:"note" validate:"required,min=3,max=500"`
+}
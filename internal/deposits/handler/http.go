@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	depEntity "github.com/ghulammuzz/backend-parkerin/internal/deposits/entity"
+	depRepo "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
+	depService "github.com/ghulammuzz/backend-parkerin/internal/deposits/svc"
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	"github.com/ghulammuzz/backend-parkerin/pkg/form"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DepositHandler struct {
+	depService depService.DepositService
+	val        *validator.Validate
+}
+
+func NewDepositHandler(depService depService.DepositService, val *validator.Validate) *DepositHandler {
+	return &DepositHandler{depService, val}
+}
+
+func (h *DepositHandler) Router(r fiber.Router) {
+	r.Post("/deposits", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.Submit)
+	r.Put("/deposits/:id", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.Resubmit)
+	r.Put("/deposits/:id/confirm", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.Confirm)
+	r.Put("/deposits/:id/dispute", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.Dispute)
+	r.Get("/deposits/store", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.ListStoreDeposits)
+	r.Get("/deposits/user", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.ListUserDeposits)
+}
+
+func (h *DepositHandler) Submit(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	req := new(depEntity.SubmitRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	deposit, err := h.depService.Submit(principal.UserID, req)
+	if err != nil {
+		return depositError(c, "Failed to submit deposit", err)
+	}
+
+	return response.JSON(c, fiber.StatusCreated, "Deposit submitted", deposit)
+}
+
+func (h *DepositHandler) Resubmit(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid deposit ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid deposit ID", nil)
+	}
+
+	req := new(depEntity.ResubmitRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	deposit, err := h.depService.Resubmit(principal.UserID, id, req)
+	if err != nil {
+		return depositError(c, "Failed to resubmit deposit", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Deposit resubmitted", deposit)
+}
+
+func (h *DepositHandler) Confirm(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid deposit ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid deposit ID", nil)
+	}
+
+	deposit, err := h.depService.Confirm(principal.StoreID, id)
+	if err != nil {
+		return depositError(c, "Failed to confirm deposit", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Deposit confirmed", deposit)
+}
+
+func (h *DepositHandler) Dispute(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid deposit ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid deposit ID", nil)
+	}
+
+	req := new(depEntity.DisputeRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	deposit, err := h.depService.Dispute(principal.StoreID, id, req.Note)
+	if err != nil {
+		return depositError(c, "Failed to dispute deposit", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Deposit disputed", deposit)
+}
+
+// ?status=submitted|confirmed|disputed|flagged, all when omitted
+func (h *DepositHandler) ListStoreDeposits(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	deposits, err := h.depService.ListForStore(principal.StoreID, c.Query("status"))
+	if err != nil {
+		return depositError(c, "Failed to retrieve deposits", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Deposits retrieved successfully", deposits)
+}
+
+func (h *DepositHandler) ListUserDeposits(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	deposits, err := h.depService.ListForTukang(principal.UserID, c.Query("status"))
+	if err != nil {
+		return depositError(c, "Failed to retrieve deposits", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Deposits retrieved successfully", deposits)
+}
+
+func depositError(c *fiber.Ctx, message string, err error) error {
+	log.Error(message, slog.String("error", err.Error()))
+	switch {
+	case errors.Is(err, depRepo.ErrDepositNotFound), errors.Is(err, depRepo.ErrShiftNotFound):
+		return response.JSON(c, fiber.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, depRepo.ErrAlreadySubmitted), errors.Is(err, depService.ErrShiftNotEnded),
+		errors.Is(err, depService.ErrDepositDecided):
+		return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+	}
+	return response.JSON(c, fiber.StatusInternalServerError, message, err.Error())
+}
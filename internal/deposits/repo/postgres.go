@@ -0,0 +1,231 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	depEntity "github.com/ghulammuzz/backend-parkerin/internal/deposits/entity"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDepositNotFound  = errors.New("deposit not found")
+	ErrShiftNotFound    = errors.New("shift not found")
+	ErrAlreadySubmitted = errors.New("a deposit was already submitted for this shift")
+)
+
+type DepositRepository interface {
+	Shift(attendanceID int) (*depEntity.Shift, error)
+	Create(deposit *depEntity.Deposit) error
+	Detail(id int) (*depEntity.Deposit, error)
+	Decide(id int, status, storeNote string) (bool, error)
+	Resubmit(id int, amount int64, note string) (bool, error)
+	List(storeID, tukangID int, status string) ([]depEntity.Deposit, error)
+	Summary(storeID, tukangID int) (*depEntity.Summary, error)
+}
+
+type depositRepository struct {
+	db *sql.DB
+}
+
+// flagged marks deposits needing attention: disputed, or submitted with an
+// amount that does not match.
+const flagged = `(d.status = 'disputed' OR (d.status = 'submitted' AND d.amount <> d.expected))`
+
+const selectDeposit = `
+	SELECT d.id, d.store_id, s.store_name, d.tukang_id, u.name, d.employment_id, d.attendance_id,
+	       a.check_in_at, a.check_out_at, d.collected, d.digital, d.tukang_share, d.expected, d.amount, ` + flagged + `,
+	       d.status, d.note, d.store_note, d.submitted_at, d.decided_at
+	FROM deposits d
+	JOIN stores s ON d.store_id = s.id
+	JOIN users u ON d.tukang_id = u.id
+	JOIN attendances a ON d.attendance_id = a.id
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeposit(row rowScanner) (*depEntity.Deposit, error) {
+	d := &depEntity.Deposit{}
+	var decidedAt sql.NullInt64
+	err := row.Scan(
+		&d.ID,
+		&d.StoreID,
+		&d.StoreName,
+		&d.TukangID,
+		&d.TukangName,
+		&d.EmploymentID,
+		&d.AttendanceID,
+		&d.ShiftStart,
+		&d.ShiftEnd,
+		&d.Collected,
+		&d.Digital,
+		&d.TukangShare,
+		&d.Expected,
+		&d.Amount,
+		&d.Flagged,
+		&d.Status,
+		&d.Note,
+		&d.StoreNote,
+		&d.SubmittedAt,
+		&decidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.Discrepancy = d.Amount - d.Expected
+	if decidedAt.Valid {
+		d.DecidedAt = &decidedAt.Int64
+	}
+	return d, nil
+}
+
+// Shift loads the attendance with the fees its tukang collected at exit
+// between check-in and check-out, cash and QRIS apart.
+func (r *depositRepository) Shift(attendanceID int) (*depEntity.Shift, error) {
+	query := `
+		SELECT a.id, a.employment_id, a.store_id, a.tukang_id, a.check_in_at, a.check_out_at,
+		       e.pay_basis, e.fee_split_percent,
+		       COALESCE(SUM(t.fee) FILTER (WHERE t.payment_method = 'cash'), 0),
+		       COALESCE(SUM(t.fee) FILTER (WHERE t.payment_method = 'qris'), 0)
+		FROM attendances a
+		JOIN employments e ON a.employment_id = e.id
+		LEFT JOIN parking_tickets t ON t.exit_employment_id = a.employment_id AND t.status = 'closed'
+		     AND t.exit_at >= a.check_in_at AND t.exit_at <= a.check_out_at
+		WHERE a.id = $1
+		GROUP BY a.id, e.id
+	`
+	shift := &depEntity.Shift{}
+	var checkOutAt sql.NullInt64
+	err := r.db.QueryRow(query, attendanceID).Scan(&shift.AttendanceID, &shift.EmploymentID, &shift.StoreID, &shift.TukangID,
+		&shift.CheckInAt, &checkOutAt, &shift.PayBasis, &shift.FeeSplitPercent, &shift.Collected, &shift.Digital)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+	if checkOutAt.Valid {
+		shift.CheckOutAt = &checkOutAt.Int64
+	}
+	return shift, nil
+}
+
+func (r *depositRepository) Create(d *depEntity.Deposit) error {
+	query := `
+		INSERT INTO deposits (store_id, tukang_id, employment_id, attendance_id, collected, digital, tukang_share, expected,
+		                      amount, status, note, submitted_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'submitted', $10, $11, $11, $11)
+		RETURNING id
+	`
+	err := r.db.QueryRow(query, d.StoreID, d.TukangID, d.EmploymentID, d.AttendanceID, d.Collected, d.Digital, d.TukangShare, d.Expected,
+		d.Amount, d.Note, d.SubmittedAt).Scan(&d.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrAlreadySubmitted
+		}
+		return fmt.Errorf("failed to submit deposit: %w", err)
+	}
+	return nil
+}
+
+func (r *depositRepository) Detail(id int) (*depEntity.Deposit, error) {
+	d, err := scanDeposit(r.db.QueryRow(selectDeposit+` WHERE d.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDepositNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// Decide confirms or disputes a submitted deposit. It reports false when the
+// deposit was no longer waiting for the store.
+func (r *depositRepository) Decide(id int, status, storeNote string) (bool, error) {
+	now := time.Now().Unix()
+	query := `
+		UPDATE deposits
+		SET status = $1, store_note = $2, decided_at = $3, updated_at = $3
+		WHERE id = $4 AND status = 'submitted'
+	`
+	result, err := r.db.Exec(query, status, storeNote, now, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to decide deposit: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// Resubmit corrects a disputed deposit and hands it back to the store. It
+// reports false when the deposit was not disputed.
+func (r *depositRepository) Resubmit(id int, amount int64, note string) (bool, error) {
+	now := time.Now().Unix()
+	query := `
+		UPDATE deposits
+		SET status = 'submitted', amount = $1, note = $2, submitted_at = $3, decided_at = NULL, updated_at = $3
+		WHERE id = $4 AND status = 'disputed'
+	`
+	result, err := r.db.Exec(query, amount, note, now, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to resubmit deposit: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// List returns deposits of the store or the tukang (whichever is not 0),
+// newest first, optionally with the given status or "flagged".
+func (r *depositRepository) List(storeID, tukangID int, status string) ([]depEntity.Deposit, error) {
+	query := selectDeposit + `
+		WHERE ($1 = 0 OR d.store_id = $1) AND ($2 = 0 OR d.tukang_id = $2)
+		  AND ($3 = '' OR d.status = $3 OR ($3 = 'flagged' AND ` + flagged + `))
+		ORDER BY d.submitted_at DESC
+	`
+	rows, err := r.db.Query(query, storeID, tukangID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deposits := []depEntity.Deposit{}
+	for rows.Next() {
+		d, err := scanDeposit(rows)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, *d)
+	}
+	return deposits, rows.Err()
+}
+
+func (r *depositRepository) Summary(storeID, tukangID int) (*depEntity.Summary, error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE d.status = 'submitted'),
+		       COUNT(*) FILTER (WHERE d.status = 'disputed'),
+		       COUNT(*) FILTER (WHERE ` + flagged + `),
+		       COALESCE(SUM(d.amount - d.expected) FILTER (WHERE ` + flagged + `), 0)
+		FROM deposits d
+		WHERE ($1 = 0 OR d.store_id = $1) AND ($2 = 0 OR d.tukang_id = $2)
+	`
+	summary := &depEntity.Summary{}
+	err := r.db.QueryRow(query, storeID, tukangID).Scan(&summary.Submitted, &summary.Disputed, &summary.Flagged, &summary.TotalDiscrepancy)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func NewDepositRepository(db *sql.DB) DepositRepository {
+	return &depositRepository{db: db}
+}
@@ -0,0 +1,142 @@
+package svc
+
+import (
+	"errors"
+	"time"
+
+	depEntity "github.com/ghulammuzz/backend-parkerin/internal/deposits/entity"
+	depRepo "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+var (
+	ErrShiftNotEnded = errors.New("check out before submitting the deposit")
+	// ErrDepositDecided is returned when the deposit is not in the state the
+	// action needs: only submitted deposits are confirmed or disputed, only
+	// disputed ones resubmitted.
+	ErrDepositDecided = errors.New("deposit cannot be changed in its current status")
+)
+
+type DepositService interface {
+	Submit(tukangID int, req *depEntity.SubmitRequest) (*depEntity.Deposit, error)
+	Resubmit(tukangID, id int, req *depEntity.ResubmitRequest) (*depEntity.Deposit, error)
+	Confirm(storeID, id int) (*depEntity.Deposit, error)
+	Dispute(storeID, id int, note string) (*depEntity.Deposit, error)
+	ListForStore(storeID int, status string) ([]depEntity.Deposit, error)
+	ListForTukang(tukangID int, status string) ([]depEntity.Deposit, error)
+}
+
+type depositService struct {
+	depRepo depRepo.DepositRepository
+}
+
+// Submit records the cash handed over for a finished shift and compares it
+// with the cash the shift's tickets collected, less the tukang's share under a
+// revenue-share employment.
+func (s *depositService) Submit(tukangID int, req *depEntity.SubmitRequest) (*depEntity.Deposit, error) {
+	shift, err := s.depRepo.Shift(req.AttendanceID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.TukangID != tukangID {
+		return nil, depRepo.ErrShiftNotFound
+	}
+	if shift.CheckOutAt == nil {
+		return nil, ErrShiftNotEnded
+	}
+
+	var share int64
+	if shift.PayBasis == empEntity.PayRevenueShare {
+		share = (shift.Collected + shift.Digital) * int64(shift.FeeSplitPercent) / 100
+	}
+	// a shift paid mostly by QRIS can owe the tukang more than the cash in
+	// hand; that is settled with the pay, not the deposit
+	expected := shift.Collected - share
+	if expected < 0 {
+		expected = 0
+	}
+
+	d := &depEntity.Deposit{
+		StoreID:      shift.StoreID,
+		TukangID:     tukangID,
+		EmploymentID: shift.EmploymentID,
+		AttendanceID: shift.AttendanceID,
+		Collected:    shift.Collected,
+		Digital:      shift.Digital,
+		TukangShare:  share,
+		Expected:     expected,
+		Amount:       req.Amount,
+		Note:         req.Note,
+		SubmittedAt:  time.Now().Unix(),
+	}
+	if err := s.depRepo.Create(d); err != nil {
+		return nil, err
+	}
+
+	if d.Amount != d.Expected {
+		log.Warn("deposit does not match collected fees", "deposit_id", d.ID, "store_id", d.StoreID, "tukang_id", tukangID,
+			"expected", d.Expected, "amount", d.Amount)
+	}
+	return s.depRepo.Detail(d.ID)
+}
+
+func (s *depositService) Resubmit(tukangID, id int, req *depEntity.ResubmitRequest) (*depEntity.Deposit, error) {
+	d, err := s.depRepo.Detail(id)
+	if err != nil {
+		return nil, err
+	}
+	if d.TukangID != tukangID {
+		return nil, depRepo.ErrDepositNotFound
+	}
+
+	ok, err := s.depRepo.Resubmit(id, req.Amount, req.Note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDepositDecided
+	}
+	return s.depRepo.Detail(id)
+}
+
+func (s *depositService) Confirm(storeID, id int) (*depEntity.Deposit, error) {
+	return s.decide(storeID, id, depEntity.StatusConfirmed, "")
+}
+
+func (s *depositService) Dispute(storeID, id int, note string) (*depEntity.Deposit, error) {
+	return s.decide(storeID, id, depEntity.StatusDisputed, note)
+}
+
+func (s *depositService) decide(storeID, id int, status, note string) (*depEntity.Deposit, error) {
+	d, err := s.depRepo.Detail(id)
+	if err != nil {
+		return nil, err
+	}
+	if d.StoreID != storeID {
+		return nil, depRepo.ErrDepositNotFound
+	}
+
+	ok, err := s.depRepo.Decide(id, status, note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDepositDecided
+	}
+
+	log.Info("deposit decided", "deposit_id", id, "store_id", storeID, "status", status, "discrepancy", d.Discrepancy)
+	return s.depRepo.Detail(id)
+}
+
+func (s *depositService) ListForStore(storeID int, status string) ([]depEntity.Deposit, error) {
+	return s.depRepo.List(storeID, 0, status)
+}
+
+func (s *depositService) ListForTukang(tukangID int, status string) ([]depEntity.Deposit, error) {
+	return s.depRepo.List(0, tukangID, status)
+}
+
+func NewDepositService(depRepo depRepo.DepositRepository) DepositService {
+	return &depositService{depRepo: depRepo}
+}
@@ -0,0 +1,21 @@
+package di
+
+import (
+	"database/sql"
+
+	"github.com/ghulammuzz/backend-parkerin/internal/employments/handler"
+	empRepo "github.com/ghulammuzz/backend-parkerin/internal/employments/repo"
+	empSvc "github.com/ghulammuzz/backend-parkerin/internal/employments/svc"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/wire"
+)
+
+func InitializedEmploymentServiceFake(sb *sql.DB, val *validator.Validate) *handler.EmploymentHandler {
+	wire.Build(
+		handler.NewEmploymentHandler,
+		empSvc.NewEmploymentService,
+		empRepo.NewEmploymentRepository,
+	)
+
+	return &handler.EmploymentHandler{}
+}
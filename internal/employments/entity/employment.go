@@ -0,0 +1,54 @@
+package entity
+
+import "github.com/ghulammuzz/backend-parkerin/pkg/schedule"
+
+const (
+	StatusActive   = "active"
+	StatusEnded    = "ended"
+	StatusResigned = "resigned"
+)
+
+const (
+	PayPerShift     = "per_shift"
+	PayHourly       = "hourly"
+	PayRevenueShare = "revenue_share"
+)
+
+type Employment struct {
+	ID              int                `json:"id"`
+	ApplicationID   *int               `json:"application_id"`
+	StoreID         int                `json:"store_id"`
+	StoreName       string             `json:"store_name"`
+	TukangID        int                `json:"tukang_id"`
+	TukangName      string             `json:"tukang_name"`
+	Status          string             `json:"status"`
+	StartDate       string             `json:"start_date"`
+	PayBasis        string             `json:"pay_basis"`
+	WageAmount      int64              `json:"wage_amount"`
+	FeeSplitPercent int                `json:"fee_split_percent"`
+	ShiftPattern    *schedule.Schedule `json:"shift_pattern"`
+	EndReason       string             `json:"end_reason"`
+	EndedAt         *int64             `json:"ended_at"`
+	CreatedAt       int64              `json:"created_at"`
+	UpdatedAt       int64              `json:"updated_at"`
+}
+
+// Terms are what the store and tukang agreed on: a wage in Rupiah paid per
+// shift or per hour (PayBasis), or instead the tukang's percentage of parking
+// revenue, and the weekly shift pattern. An empty StartDate means the day the
+// application is accepted, an empty PayBasis per shift.
+type Terms struct {
+	StartDate       string             `json:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	PayBasis        string             `json:"pay_basis" validate:"omitempty,oneof=per_shift hourly revenue_share"`
+	WageAmount      int64              `json:"wage_amount" validate:"min=0"`
+	FeeSplitPercent int                `json:"fee_split_percent" validate:"min=0,max=100"`
+	ShiftPattern    *schedule.Schedule `json:"shift_pattern,omitempty"`
+}
+
+type EndEmploymentRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+type ResignRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
+	empRepo "github.com/ghulammuzz/backend-parkerin/internal/employments/repo"
+	empService "github.com/ghulammuzz/backend-parkerin/internal/employments/svc"
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	"github.com/ghulammuzz/backend-parkerin/pkg/form"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type EmploymentHandler struct {
+	empService empService.EmploymentService
+	val        *validator.Validate
+}
+
+func NewEmploymentHandler(empService empService.EmploymentService, val *validator.Validate) *EmploymentHandler {
+	return &EmploymentHandler{empService, val}
+}
+
+func (h *EmploymentHandler) Router(r fiber.Router) {
+	r.Get("/employments/store", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.ListStoreEmployments)
+	r.Get("/employments/user", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.ListUserEmployments)
+	r.Put("/employments/:id/terms", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.UpdateTerms)
+	r.Put("/employments/:id/end", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.EndEmployment)
+	r.Put("/employments/:id/resign", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.Resign)
+}
+
+// ?status=active|past, both when omitted
+func (h *EmploymentHandler) ListStoreEmployments(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	employments, err := h.empService.ListForStore(principal.StoreID, c.Query("status"))
+	if err != nil {
+		log.Error("Failed to retrieve employments", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve employments", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Employments retrieved successfully", employments)
+}
+
+func (h *EmploymentHandler) ListUserEmployments(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	employments, err := h.empService.ListForTukang(principal.UserID, c.Query("status"))
+	if err != nil {
+		log.Error("Failed to retrieve employments", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve employments", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Employments retrieved successfully", employments)
+}
+
+func (h *EmploymentHandler) UpdateTerms(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid employment ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid employment ID", nil)
+	}
+
+	req := new(empEntity.Terms)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	emp, err := h.empService.UpdateTerms(principal.StoreID, id, req)
+	if err != nil {
+		return h.employmentError(c, "Failed to update employment terms", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Employment terms updated", emp)
+}
+
+func (h *EmploymentHandler) EndEmployment(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid employment ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid employment ID", nil)
+	}
+
+	req := new(empEntity.EndEmploymentRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	if err := h.empService.End(principal.StoreID, id, req.Reason); err != nil {
+		return h.employmentError(c, "Failed to end employment", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Employment ended", nil)
+}
+
+func (h *EmploymentHandler) Resign(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid employment ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid employment ID", nil)
+	}
+
+	// the reason is optional, so an empty body is fine
+	req := new(empEntity.ResignRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			log.Error("Payload error", slog.String("error", err.Error()))
+			return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+		}
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	if err := h.empService.Resign(principal.UserID, id, req.Reason); err != nil {
+		return h.employmentError(c, "Failed to resign", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Resigned from employment", nil)
+}
+
+func (h *EmploymentHandler) employmentError(c *fiber.Ctx, message string, err error) error {
+	log.Error(message, slog.String("error", err.Error()))
+	switch {
+	case errors.Is(err, empRepo.ErrEmploymentNotFound):
+		return response.JSON(c, fiber.StatusNotFound, "Employment not found", nil)
+	case errors.Is(err, empService.ErrEmploymentNotActive):
+		return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+	case errors.Is(err, empService.ErrInvalidTerms):
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", err.Error())
+	}
+	return response.JSON(c, fiber.StatusInternalServerError, message, err.Error())
+}
@@ -0,0 +1,202 @@
+package repo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/schedule"
+)
+
+var ErrEmploymentNotFound = errors.New("employment not found")
+
+type EmploymentRepository interface {
+	ListByStore(storeID int, status string) ([]empEntity.Employment, error)
+	ListByTukang(tukangID int, status string) ([]empEntity.Employment, error)
+	ListForPeriod(storeID, tukangID int, from, to int64) ([]empEntity.Employment, error)
+	Detail(id int) (*empEntity.Employment, error)
+	Finish(id int, status, reason string) (bool, error)
+	UpdateTerms(id int, terms *empEntity.Terms) (bool, error)
+}
+
+type employmentRepository struct {
+	db *sql.DB
+}
+
+const selectEmployment = `
+	SELECT e.id, e.application_id, e.store_id, s.store_name, e.tukang_id, u.name, e.status,
+	       TO_CHAR(e.start_date, 'YYYY-MM-DD'), e.pay_basis, e.wage_amount, e.fee_split_percent, e.shift_pattern,
+	       e.end_reason, e.ended_at, e.created_at, e.updated_at
+	FROM employments e
+	JOIN stores s ON e.store_id = s.id
+	JOIN users u ON e.tukang_id = u.id
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEmployment(row rowScanner) (*empEntity.Employment, error) {
+	emp := &empEntity.Employment{}
+	var applicationID, endedAt sql.NullInt64
+	var shiftPattern []byte
+	err := row.Scan(
+		&emp.ID,
+		&applicationID,
+		&emp.StoreID,
+		&emp.StoreName,
+		&emp.TukangID,
+		&emp.TukangName,
+		&emp.Status,
+		&emp.StartDate,
+		&emp.PayBasis,
+		&emp.WageAmount,
+		&emp.FeeSplitPercent,
+		&shiftPattern,
+		&emp.EndReason,
+		&endedAt,
+		&emp.CreatedAt,
+		&emp.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if applicationID.Valid {
+		id := int(applicationID.Int64)
+		emp.ApplicationID = &id
+	}
+	if endedAt.Valid {
+		emp.EndedAt = &endedAt.Int64
+	}
+	if shiftPattern != nil {
+		emp.ShiftPattern = &schedule.Schedule{}
+		if err := json.Unmarshal(shiftPattern, emp.ShiftPattern); err != nil {
+			return nil, fmt.Errorf("failed to decode shift pattern: %w", err)
+		}
+	}
+	return emp, nil
+}
+
+// list returns employments matching column = id. status "active" keeps the
+// current ones, "past" the finished ones, anything else returns both.
+func (r *employmentRepository) list(column string, id int, status string) ([]empEntity.Employment, error) {
+	query := selectEmployment + fmt.Sprintf(`
+		WHERE e.%s = $1
+		  AND ($2 = '' OR ($2 = 'active') = (e.status = 'active'))
+		ORDER BY e.status = 'active' DESC, e.start_date DESC, e.id DESC
+	`, column)
+
+	filter := ""
+	if status == "active" || status == "past" {
+		filter = status
+	}
+
+	return r.query(query, id, filter)
+}
+
+func (r *employmentRepository) query(query string, args ...interface{}) ([]empEntity.Employment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	employments := []empEntity.Employment{}
+	for rows.Next() {
+		emp, err := scanEmployment(rows)
+		if err != nil {
+			return nil, err
+		}
+		employments = append(employments, *emp)
+	}
+	return employments, rows.Err()
+}
+
+func (r *employmentRepository) ListByStore(storeID int, status string) ([]empEntity.Employment, error) {
+	return r.list("store_id", storeID, status)
+}
+
+func (r *employmentRepository) ListByTukang(tukangID int, status string) ([]empEntity.Employment, error) {
+	return r.list("tukang_id", tukangID, status)
+}
+
+// ListForPeriod returns the employments that were running at some point in
+// [from, to) (unix seconds): started before to and not finished before from.
+// storeID and tukangID narrow the result unless they are 0.
+func (r *employmentRepository) ListForPeriod(storeID, tukangID int, from, to int64) ([]empEntity.Employment, error) {
+	query := selectEmployment + `
+		WHERE ($1 = 0 OR e.store_id = $1) AND ($2 = 0 OR e.tukang_id = $2)
+		  AND e.start_date < (TO_TIMESTAMP($4) AT TIME ZONE 'Asia/Jakarta')::DATE
+		  AND (e.ended_at IS NULL OR e.ended_at >= $3)
+		ORDER BY e.start_date, e.id
+	`
+	return r.query(query, storeID, tukangID, from, to)
+}
+
+func (r *employmentRepository) Detail(id int) (*empEntity.Employment, error) {
+	emp, err := scanEmployment(r.db.QueryRow(selectEmployment+` WHERE e.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEmploymentNotFound
+		}
+		return nil, err
+	}
+	return emp, nil
+}
+
+// Finish ends an active employment with status ended or resigned. It reports
+// false when the employment was no longer active.
+func (r *employmentRepository) Finish(id int, status, reason string) (bool, error) {
+	now := time.Now().Unix()
+	query := `
+		UPDATE employments
+		SET status = $1, end_reason = $2, ended_at = $3, updated_at = $3
+		WHERE id = $4 AND status = 'active'
+	`
+	result, err := r.db.Exec(query, status, reason, now, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to finish employment: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// UpdateTerms changes the agreed terms of an active employment. It reports
+// false when the employment was no longer active.
+func (r *employmentRepository) UpdateTerms(id int, terms *empEntity.Terms) (bool, error) {
+	var shiftPattern interface{}
+	if terms.ShiftPattern != nil {
+		raw, err := json.Marshal(terms.ShiftPattern)
+		if err != nil {
+			return false, err
+		}
+		shiftPattern = raw
+	}
+
+	query := `
+		UPDATE employments
+		SET start_date = COALESCE(NULLIF($1, '')::DATE, start_date), wage_amount = $2,
+		    fee_split_percent = $3, shift_pattern = $4, updated_at = $5,
+		    pay_basis = COALESCE(NULLIF($7, ''), pay_basis)
+		WHERE id = $6 AND status = 'active'
+	`
+	result, err := r.db.Exec(query, terms.StartDate, terms.WageAmount, terms.FeeSplitPercent, shiftPattern, time.Now().Unix(), id, terms.PayBasis)
+	if err != nil {
+		return false, fmt.Errorf("failed to update employment terms: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func NewEmploymentRepository(db *sql.DB) EmploymentRepository {
+	return &employmentRepository{db: db}
+}
@@ -0,0 +1,98 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
+	empRepo "github.com/ghulammuzz/backend-parkerin/internal/employments/repo"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+var (
+	ErrEmploymentNotActive = errors.New("employment has already ended")
+	ErrInvalidTerms        = errors.New("invalid employment terms")
+)
+
+type EmploymentService interface {
+	ListForStore(storeID int, status string) ([]empEntity.Employment, error)
+	ListForTukang(tukangID int, status string) ([]empEntity.Employment, error)
+	End(storeID, id int, reason string) error
+	Resign(tukangID, id int, reason string) error
+	UpdateTerms(storeID, id int, terms *empEntity.Terms) (*empEntity.Employment, error)
+}
+
+type employmentService struct {
+	empRepo empRepo.EmploymentRepository
+}
+
+func (s *employmentService) ListForStore(storeID int, status string) ([]empEntity.Employment, error) {
+	return s.empRepo.ListByStore(storeID, status)
+}
+
+func (s *employmentService) ListForTukang(tukangID int, status string) ([]empEntity.Employment, error) {
+	return s.empRepo.ListByTukang(tukangID, status)
+}
+
+func (s *employmentService) End(storeID, id int, reason string) error {
+	emp, err := s.empRepo.Detail(id)
+	if err != nil {
+		return err
+	}
+	if emp.StoreID != storeID {
+		return empRepo.ErrEmploymentNotFound
+	}
+	return s.finish(emp, empEntity.StatusEnded, reason)
+}
+
+func (s *employmentService) Resign(tukangID, id int, reason string) error {
+	emp, err := s.empRepo.Detail(id)
+	if err != nil {
+		return err
+	}
+	if emp.TukangID != tukangID {
+		return empRepo.ErrEmploymentNotFound
+	}
+	return s.finish(emp, empEntity.StatusResigned, reason)
+}
+
+func (s *employmentService) finish(emp *empEntity.Employment, status, reason string) error {
+	ok, err := s.empRepo.Finish(emp.ID, status, reason)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrEmploymentNotActive
+	}
+	log.Info("employment finished", "employment_id", emp.ID, "store_id", emp.StoreID, "tukang_id", emp.TukangID, "status", status)
+	return nil
+}
+
+func (s *employmentService) UpdateTerms(storeID, id int, terms *empEntity.Terms) (*empEntity.Employment, error) {
+	if terms.ShiftPattern != nil {
+		if err := terms.ShiftPattern.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: shift_pattern: %v", ErrInvalidTerms, err)
+		}
+	}
+
+	emp, err := s.empRepo.Detail(id)
+	if err != nil {
+		return nil, err
+	}
+	if emp.StoreID != storeID {
+		return nil, empRepo.ErrEmploymentNotFound
+	}
+
+	ok, err := s.empRepo.UpdateTerms(id, terms)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrEmploymentNotActive
+	}
+	return s.empRepo.Detail(id)
+}
+
+func NewEmploymentService(empRepo empRepo.EmploymentRepository) EmploymentService {
+	return &employmentService{empRepo: empRepo}
+}
@@ -0,0 +1,16 @@
+package health
+
+import (
+	"github.com/ghulammuzz/backend-parkerin/internal/scheduler"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Jobs reports the last run of every background job on this replica.
+func Jobs(s *scheduler.Scheduler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status": "ok",
+			"jobs":   s.Status(),
+		})
+	}
+}
@@ -5,10 +5,23 @@ import (
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
 	"github.com/ghulammuzz/backend-parkerin/pkg/response"
 	"github.com/gofiber/fiber/v2"
 )
 
+// DenyList reports whether an access token's jti has been revoked.
+type DenyList interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+var denyList DenyList
+
+// UseDenyList makes JWTProtected reject revoked tokens. Call it once at startup.
+func UseDenyList(d DenyList) {
+	denyList = d
+}
+
 func JWTProtected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		tokenString := c.Get("Authorization")
@@ -19,7 +32,8 @@ func JWTProtected() fiber.Handler {
 
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		claims := &Claims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fiber.ErrUnauthorized
 			}
@@ -29,9 +43,55 @@ func JWTProtected() fiber.Handler {
 		if err != nil || !token.Valid {
 			return response.JSON(c, 401, "Unauthorized", nil)
 		}
+		if claims.UserID < 1 || claims.Role == "" || claims.Id == "" || claims.ExpiresAt == 0 {
+			return response.JSON(c, 401, "Unauthorized", nil)
+		}
+
+		if denyList != nil {
+			revoked, err := denyList.IsRevoked(claims.Id)
+			if err != nil {
+				log.Error("Failed to check token deny-list", "error", err.Error())
+				return response.JSON(c, 500, "Failed to verify token", nil)
+			}
+			if revoked {
+				return response.JSON(c, 401, "Token revoked", nil)
+			}
+		}
 
 		c.Locals("user", token)
+		c.Locals(principalKey, &Principal{
+			UserID:    claims.UserID,
+			Role:      claims.Role,
+			StoreID:   claims.StoreID,
+			JTI:       claims.Id,
+			ExpiresAt: claims.ExpiresAt,
+		})
 
 		return c.Next()
 	}
 }
+
+// RequireRole lets the request through only when the principal has one of
+// roles. It must run after JWTProtected. Store callers must also carry a
+// store ID, so handlers behind RequireRole(RoleStore) can rely on it.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			return response.JSON(c, 401, "Unauthorized", nil)
+		}
+
+		for _, role := range roles {
+			if principal.Role != role {
+				continue
+			}
+			if role == RoleStore && principal.StoreID < 1 {
+				return response.JSON(c, 403, "Store profile not found", nil)
+			}
+			return c.Next()
+		}
+
+		log.Error("Forbidden role", "role", principal.Role, "path", c.Path())
+		return response.JSON(c, 403, "Forbidden", nil)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	RoleTukang = "tukang"
+	RoleStore  = "store"
+	RoleAdmin  = "admin"
+)
+
+// Claims is the payload of every access token issued by the users service.
+// StandardClaims carries exp and jti.
+type Claims struct {
+	Apps    string `json:"apps"`
+	UserID  int    `json:"user_id"`
+	Role    string `json:"role"`
+	StoreID int    `json:"store_id,omitempty"`
+	jwt.StandardClaims
+}
+
+// Principal is the authenticated caller, set by JWTProtected.
+type Principal struct {
+	UserID    int
+	Role      string
+	StoreID   int
+	JTI       string
+	ExpiresAt int64
+}
+
+const principalKey = "principal"
+
+// GetPrincipal returns the caller stored by JWTProtected. ok is false when the
+// route is not protected.
+func GetPrincipal(c *fiber.Ctx) (*Principal, bool) {
+	p, ok := c.Locals(principalKey).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,327 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey is the pg_advisory_lock key held while migrating, so two pods
+// starting at the same time never apply the same migration twice.
+const lockKey int64 = 7274757
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt int64
+}
+
+// Load reads the embedded migrations, named <version>_<name>.(up|down).sql,
+// ordered by version.
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		fileName := e.Name()
+		base := strings.TrimSuffix(fileName, ".sql")
+		direction := base[strings.LastIndex(base, ".")+1:]
+		base = strings.TrimSuffix(base, "."+direction)
+
+		parts := strings.SplitN(base, "_", 2)
+		if len(parts) != 2 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", fileName)
+		}
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", fileName, err)
+		}
+
+		content, err := files.ReadFile("sql/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func ensureTable(ctx context.Context, q interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			applied_at BIGINT NOT NULL
+		)
+	`
+	_, err := q.ExecContext(ctx, query)
+	return err
+}
+
+func applied(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}) (map[int]int64, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int]int64{}
+	for rows.Next() {
+		var version int
+		var appliedAt int64
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock.
+func withLock(db *sql.DB, fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(ctx, conn)
+}
+
+func runInTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up applies every pending migration in order and returns the versions applied.
+func Up(db *sql.DB) ([]int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []int
+	err = withLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := versions[m.Version]; ok {
+				continue
+			}
+			err := runInTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+					m.Version, m.Name, time.Now().Unix(),
+				)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up failed: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m.Version)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the latest applied migration. It returns 0 when nothing is applied.
+func Down(db *sql.DB) (int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return 0, err
+	}
+
+	var reverted int
+	err = withLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if _, ok := versions[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+			}
+			err := runInTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, m.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down failed: %w", m.Version, m.Name, err)
+			}
+			reverted = m.Version
+			return nil
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+func List(db *sql.DB) ([]Status, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if err := ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+	versions, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := versions[m.Version]
+		statuses = append(statuses, Status{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// EnsureUpToDate returns an error when the database is missing any embedded migration.
+func EnsureUpToDate(db *sql.DB) error {
+	statuses, err := List(db)
+	if err != nil {
+		return fmt.Errorf("failed to read migration status: %w", err)
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, fmt.Sprintf("%d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind, pending migrations: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Run executes a migrate command (up/down/status) and writes a report to w.
+func Run(db *sql.DB, command string, w io.Writer) error {
+	switch command {
+	case "up":
+		versions, err := Up(db)
+		for _, v := range versions {
+			fmt.Fprintf(w, "applied %d\n", v)
+		}
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			fmt.Fprintln(w, "no pending migrations")
+		}
+	case "down":
+		version, err := Down(db)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			fmt.Fprintln(w, "no applied migrations")
+		} else {
+			fmt.Fprintf(w, "reverted %d\n", version)
+		}
+	case "status":
+		statuses, err := List(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied at " + time.Unix(s.AppliedAt, 0).Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q (use up/down/status)", command)
+	}
+	return nil
+}
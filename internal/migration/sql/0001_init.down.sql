@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS applications;
+DROP TABLE IF EXISTS stores;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS users (
+    id           SERIAL PRIMARY KEY,
+    phone_number VARCHAR(20)  NOT NULL UNIQUE,
+    name         VARCHAR(50)  NOT NULL,
+    password     VARCHAR(255) NOT NULL,
+    role         VARCHAR(20)  NOT NULL CHECK (role IN ('tukang', 'store')),
+    is_verified  BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at   BIGINT       NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_role_created_at ON users (role, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS stores (
+    id            SERIAL PRIMARY KEY,
+    user_id       INTEGER          NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    store_name    VARCHAR(255)     NOT NULL,
+    address       VARCHAR(500)     NOT NULL,
+    latitude      DOUBLE PRECISION NOT NULL,
+    longitude     DOUBLE PRECISION NOT NULL,
+    working_hours VARCHAR(100)     NOT NULL DEFAULT '',
+    url_image     TEXT             NOT NULL DEFAULT '',
+    is_hiring     BOOLEAN          NOT NULL DEFAULT FALSE,
+    is_paid       BOOLEAN          NOT NULL DEFAULT FALSE,
+    created_at    BIGINT           NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_stores_is_hiring_created_at ON stores (is_hiring, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS applications (
+    id             SERIAL PRIMARY KEY,
+    tukang_id      INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    store_id       INTEGER     NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    status         VARCHAR(20) NOT NULL DEFAULT 'sent',
+    is_direct_hire BOOLEAN     NOT NULL DEFAULT FALSE,
+    applied_at     BIGINT      NOT NULL,
+    updated_at     BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_applications_store_id ON applications (store_id);
+CREATE INDEX IF NOT EXISTS idx_applications_tukang_id ON applications (tukang_id, is_direct_hire);
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS transactions (
+    id               SERIAL PRIMARY KEY,
+    order_id         VARCHAR(64) NOT NULL UNIQUE,
+    user_id          INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    package_id       INTEGER     NOT NULL,
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending',
+    amount           INTEGER     NOT NULL,
+    transaction_time TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    payment_url      TEXT        NOT NULL DEFAULT '',
+    midtrans_id      VARCHAR(64) NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_status_created_at ON transactions (status, created_at);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INTEGER     NOT NULL UNIQUE REFERENCES transactions (id) ON DELETE CASCADE,
+    payment_method VARCHAR(50) NOT NULL DEFAULT '',
+    payment_status VARCHAR(50) NOT NULL DEFAULT '',
+    payment_time   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    raw_response   TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS fk_transactions_package_id;
+DROP TABLE IF EXISTS packages;
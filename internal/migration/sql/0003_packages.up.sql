@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS packages (
+    id            SERIAL PRIMARY KEY,
+    name          VARCHAR(100) NOT NULL,
+    price         INTEGER      NOT NULL CHECK (price > 0),
+    duration_days INTEGER      NOT NULL CHECK (duration_days > 0),
+    is_active     BOOLEAN      NOT NULL DEFAULT TRUE,
+    target_role   VARCHAR(20)  NOT NULL CHECK (target_role IN ('tukang', 'store')),
+    created_at    BIGINT       NOT NULL,
+    updated_at    BIGINT       NOT NULL
+);
+
+-- the two plans previously hard-coded in PaymentService
+INSERT INTO packages (id, name, price, duration_days, is_active, target_role, created_at, updated_at)
+VALUES
+    (1, 'Paket Harian', 3000, 1, TRUE, 'store', EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT),
+    (2, 'Paket Bulanan', 50000, 30, TRUE, 'store', EXTRACT(EPOCH FROM NOW())::BIGINT, EXTRACT(EPOCH FROM NOW())::BIGINT)
+ON CONFLICT (id) DO NOTHING;
+
+SELECT setval(pg_get_serial_sequence('packages', 'id'), (SELECT MAX(id) FROM packages));
+
+ALTER TABLE transactions
+    ADD CONSTRAINT fk_transactions_package_id FOREIGN KEY (package_id) REFERENCES packages (id);
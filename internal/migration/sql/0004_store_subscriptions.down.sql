@@ -0,0 +1,9 @@
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS is_paid BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE stores s
+SET is_paid = EXISTS (
+    SELECT 1 FROM store_subscriptions ss
+    WHERE ss.store_id = s.id AND ss.ends_at > EXTRACT(EPOCH FROM NOW())::BIGINT
+);
+
+DROP TABLE IF EXISTS store_subscriptions;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS store_subscriptions (
+    id             SERIAL PRIMARY KEY,
+    store_id       INTEGER NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions (id) ON DELETE CASCADE,
+    package_id     INTEGER NOT NULL REFERENCES packages (id),
+    starts_at      BIGINT  NOT NULL,
+    ends_at        BIGINT  NOT NULL CHECK (ends_at > starts_at),
+    created_at     BIGINT  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_store_subscriptions_store_id_ends_at ON store_subscriptions (store_id, ends_at);
+
+-- is_paid is now derived from store_subscriptions
+ALTER TABLE stores DROP COLUMN IF EXISTS is_paid;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id                SERIAL PRIMARY KEY,
+    user_id           INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id         VARCHAR(64) NOT NULL,
+    token_hash        VARCHAR(64) NOT NULL UNIQUE,
+    access_jti        VARCHAR(64) NOT NULL,
+    access_expires_at BIGINT      NOT NULL,
+    expires_at        BIGINT      NOT NULL,
+    revoked_at        BIGINT,
+    created_at        BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_access_jti ON refresh_tokens (access_jti);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        VARCHAR(64) PRIMARY KEY,
+    user_id    INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at BIGINT      NOT NULL,
+    revoked_at BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS otp_codes (
+    id           SERIAL PRIMARY KEY,
+    phone_number VARCHAR(20) NOT NULL,
+    purpose      VARCHAR(30) NOT NULL,
+    code_hash    VARCHAR(64) NOT NULL,
+    ip_address   VARCHAR(64) NOT NULL DEFAULT '',
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    expires_at   BIGINT      NOT NULL,
+    consumed_at  BIGINT,
+    created_at   BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_otp_codes_phone_purpose ON otp_codes (phone_number, purpose, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_otp_codes_ip_created_at ON otp_codes (ip_address, created_at);
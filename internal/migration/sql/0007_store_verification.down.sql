@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS store_verification_events;
+ALTER TABLE stores DROP COLUMN IF EXISTS verification_status;
+
+-- NOT VALID keeps any existing admin rows instead of failing the rollback
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('tukang', 'store')) NOT VALID;
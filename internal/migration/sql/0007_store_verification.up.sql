@@ -0,0 +1,27 @@
+-- admins are promoted by hand: UPDATE users SET role = 'admin' WHERE phone_number = '...';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('tukang', 'store', 'admin'));
+
+ALTER TABLE stores
+    ADD COLUMN IF NOT EXISTS verification_status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (verification_status IN ('pending', 'approved', 'rejected', 'suspended'));
+
+-- stores that already passed the old photo-upload verification keep their badge
+UPDATE stores s
+SET verification_status = 'approved'
+FROM users u
+WHERE s.user_id = u.id AND u.is_verified = TRUE AND s.url_image <> '';
+
+CREATE INDEX IF NOT EXISTS idx_stores_verification_status ON stores (verification_status, created_at);
+
+CREATE TABLE IF NOT EXISTS store_verification_events (
+    id          SERIAL PRIMARY KEY,
+    store_id    INTEGER     NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status   VARCHAR(20) NOT NULL,
+    reason      TEXT        NOT NULL DEFAULT '',
+    admin_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at  BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_store_verification_events_store_id ON store_verification_events (store_id, created_at);
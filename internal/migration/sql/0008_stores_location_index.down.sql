@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_stores_hiring_lat_lng;
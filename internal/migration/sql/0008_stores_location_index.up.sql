@@ -0,0 +1,2 @@
+-- bounding-box prefilter for GET /stores?lat=&lng=&radius_km=
+CREATE INDEX IF NOT EXISTS idx_stores_hiring_lat_lng ON stores (latitude, longitude) WHERE is_hiring = TRUE;
@@ -0,0 +1,2 @@
+ALTER TABLE stores ALTER COLUMN working_hours TYPE VARCHAR(100) USING LEFT(working_hours, 100);
+ALTER TABLE stores DROP COLUMN IF EXISTS working_schedule;
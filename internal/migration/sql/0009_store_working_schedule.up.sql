@@ -0,0 +1,3 @@
+-- structured weekly hours; working_hours keeps the rendered legacy string
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS working_schedule JSONB;
+ALTER TABLE stores ALTER COLUMN working_hours TYPE TEXT;
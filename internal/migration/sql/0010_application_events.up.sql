@@ -0,0 +1,26 @@
+-- transition history for applications; from_status is '' for the initial 'sent'
+CREATE TABLE IF NOT EXISTS application_events (
+    id             SERIAL PRIMARY KEY,
+    application_id INTEGER     NOT NULL REFERENCES applications (id) ON DELETE CASCADE,
+    from_status    VARCHAR(20) NOT NULL,
+    to_status      VARCHAR(20) NOT NULL,
+    actor          VARCHAR(20) NOT NULL CHECK (actor IN ('tukang', 'store', 'system')),
+    actor_user_id  INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    created_at     BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_events_application_id ON application_events (application_id, created_at);
+
+ALTER TABLE applications DROP CONSTRAINT IF EXISTS applications_status_check;
+ALTER TABLE applications ADD CONSTRAINT applications_status_check
+    CHECK (status IN ('sent', 'accepted', 'rejected', 'withdrawn', 'expired'));
+
+-- seed history for applications created before events were recorded
+INSERT INTO application_events (application_id, from_status, to_status, actor, actor_user_id, created_at)
+SELECT a.id, '', 'sent',
+       CASE WHEN a.is_direct_hire THEN 'store' ELSE 'tukang' END,
+       CASE WHEN a.is_direct_hire THEN s.user_id ELSE a.tukang_id END,
+       a.applied_at
+FROM applications a
+JOIN stores s ON a.store_id = s.id
+WHERE NOT EXISTS (SELECT 1 FROM application_events e WHERE e.application_id = a.id);
@@ -0,0 +1,2 @@
+ALTER TABLE stores DROP COLUMN IF EXISTS hiring_capacity;
+DROP TABLE IF EXISTS employments;
@@ -0,0 +1,39 @@
+-- a tukang working at a store, created when an application is accepted
+CREATE TABLE IF NOT EXISTS employments (
+    id                SERIAL PRIMARY KEY,
+    application_id    INTEGER UNIQUE REFERENCES applications (id) ON DELETE SET NULL,
+    store_id          INTEGER     NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    tukang_id         INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status            VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'ended', 'resigned')),
+    start_date        DATE        NOT NULL,
+    wage_amount       BIGINT      NOT NULL DEFAULT 0 CHECK (wage_amount >= 0),
+    fee_split_percent SMALLINT    NOT NULL DEFAULT 0 CHECK (fee_split_percent BETWEEN 0 AND 100),
+    shift_pattern     JSONB,
+    end_reason        TEXT        NOT NULL DEFAULT '',
+    ended_at          BIGINT,
+    created_at        BIGINT      NOT NULL,
+    updated_at        BIGINT      NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_employments_active ON employments (tukang_id, store_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_employments_store_id ON employments (store_id, status);
+CREATE INDEX IF NOT EXISTS idx_employments_tukang_id ON employments (tukang_id, status);
+
+-- how many tukang a store wants working at once
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS hiring_capacity INTEGER NOT NULL DEFAULT 1 CHECK (hiring_capacity > 0);
+
+-- applications accepted before employments existed
+INSERT INTO employments (application_id, store_id, tukang_id, start_date, created_at, updated_at)
+SELECT DISTINCT ON (a.tukang_id, a.store_id)
+       a.id, a.store_id, a.tukang_id,
+       (TO_TIMESTAMP(a.updated_at) AT TIME ZONE 'Asia/Jakarta')::DATE,
+       a.updated_at, a.updated_at
+FROM applications a
+WHERE a.status = 'accepted'
+  AND NOT EXISTS (SELECT 1 FROM employments e WHERE e.application_id = a.id)
+ORDER BY a.tukang_id, a.store_id, a.updated_at DESC;
+
+UPDATE stores s
+SET hiring_capacity = e.active
+FROM (SELECT store_id, COUNT(*) AS active FROM employments WHERE status = 'active' GROUP BY store_id) e
+WHERE s.id = e.store_id AND s.hiring_capacity < e.active;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_stores_lat_lng;
+CREATE INDEX IF NOT EXISTS idx_stores_hiring_lat_lng ON stores (latitude, longitude) WHERE is_hiring = TRUE;
+
+ALTER TABLE employments DROP COLUMN IF EXISTS vacancy_id;
+ALTER TABLE applications DROP COLUMN IF EXISTS vacancy_id;
+DROP TABLE IF EXISTS vacancies;
@@ -0,0 +1,28 @@
+-- openings a store advertises; applications and employments may point at one
+CREATE TABLE IF NOT EXISTS vacancies (
+    id           SERIAL PRIMARY KEY,
+    store_id     INTEGER      NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    title        VARCHAR(100) NOT NULL,
+    shift_start  VARCHAR(5)   NOT NULL,
+    shift_end    VARCHAR(5)   NOT NULL,
+    days         TEXT[]       NOT NULL,
+    slots        INTEGER      NOT NULL CHECK (slots > 0),
+    fee          BIGINT       NOT NULL DEFAULT 0 CHECK (fee >= 0),
+    requirements TEXT         NOT NULL DEFAULT '',
+    status       VARCHAR(20)  NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'filled', 'closed')),
+    created_at   BIGINT       NOT NULL,
+    updated_at   BIGINT       NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_vacancies_store_id ON vacancies (store_id, status);
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS vacancy_id INTEGER REFERENCES vacancies (id) ON DELETE SET NULL;
+ALTER TABLE employments ADD COLUMN IF NOT EXISTS vacancy_id INTEGER REFERENCES vacancies (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_applications_vacancy_id ON applications (vacancy_id) WHERE vacancy_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_employments_vacancy_id ON employments (vacancy_id, status) WHERE vacancy_id IS NOT NULL;
+
+-- a store with open vacancies is hiring even when is_hiring is off, so the
+-- nearby prefilter can no longer be limited to is_hiring rows
+DROP INDEX IF EXISTS idx_stores_hiring_lat_lng;
+CREATE INDEX IF NOT EXISTS idx_stores_lat_lng ON stores (latitude, longitude);
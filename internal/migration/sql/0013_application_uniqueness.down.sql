@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ux_applications_pending;
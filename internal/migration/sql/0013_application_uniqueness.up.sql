@@ -0,0 +1,19 @@
+-- duplicate pending applications for the same pair could slip through the
+-- old check-then-insert; keep the newest and expire the rest
+WITH ranked AS (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY tukang_id, store_id ORDER BY applied_at DESC, id DESC) AS rn
+    FROM applications
+    WHERE status = 'sent'
+), expired AS (
+    UPDATE applications a
+    SET status = 'expired', updated_at = EXTRACT(EPOCH FROM NOW())::BIGINT
+    FROM ranked r
+    WHERE a.id = r.id AND r.rn > 1
+    RETURNING a.id, a.updated_at
+)
+INSERT INTO application_events (application_id, from_status, to_status, actor, actor_user_id, created_at)
+SELECT id, 'sent', 'expired', 'system', NULL, updated_at FROM expired;
+
+-- at most one pending application per tukang and store; together with
+-- ux_employments_active this is the "one active relationship" rule
+CREATE UNIQUE INDEX IF NOT EXISTS ux_applications_pending ON applications (tukang_id, store_id) WHERE status = 'sent';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_applications_sent_applied_at;
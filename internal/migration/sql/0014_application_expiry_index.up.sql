@@ -0,0 +1,2 @@
+-- the expiry job scans pending applications by age
+CREATE INDEX IF NOT EXISTS idx_applications_sent_applied_at ON applications (is_direct_hire, applied_at) WHERE status = 'sent';
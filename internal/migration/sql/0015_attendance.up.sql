@@ -0,0 +1,24 @@
+-- a tukang's shift at a store: checked in and, once finished, checked out.
+-- *_at is server time, *_device_at what the phone reported.
+CREATE TABLE IF NOT EXISTS attendances (
+    id                   SERIAL PRIMARY KEY,
+    employment_id        INTEGER          NOT NULL REFERENCES employments (id) ON DELETE CASCADE,
+    store_id             INTEGER          NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    tukang_id            INTEGER          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    check_in_at          BIGINT           NOT NULL,
+    check_in_device_at   BIGINT           NOT NULL,
+    check_in_latitude    DOUBLE PRECISION NOT NULL,
+    check_in_longitude   DOUBLE PRECISION NOT NULL,
+    check_in_distance_m  INTEGER          NOT NULL,
+    check_out_at         BIGINT,
+    check_out_device_at  BIGINT,
+    check_out_latitude   DOUBLE PRECISION,
+    check_out_longitude  DOUBLE PRECISION,
+    check_out_distance_m INTEGER,
+    CHECK (check_out_at IS NULL OR check_out_at >= check_in_at)
+);
+
+-- a tukang can only be checked in at one place at a time
+CREATE UNIQUE INDEX IF NOT EXISTS ux_attendances_open ON attendances (tukang_id) WHERE check_out_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_attendances_store_id ON attendances (store_id, check_in_at);
+CREATE INDEX IF NOT EXISTS idx_attendances_tukang_id ON attendances (tukang_id, check_in_at);
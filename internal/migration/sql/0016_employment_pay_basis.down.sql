@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_attendances_employment_id;
+ALTER TABLE employments DROP COLUMN IF EXISTS pay_basis;
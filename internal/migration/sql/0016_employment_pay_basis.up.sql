@@ -0,0 +1,6 @@
+-- how wage_amount is paid: per worked shift, per hour, or not at all when the
+-- tukang is paid fee_split_percent of the parking revenue
+ALTER TABLE employments ADD COLUMN IF NOT EXISTS pay_basis VARCHAR(20) NOT NULL DEFAULT 'per_shift'
+    CHECK (pay_basis IN ('per_shift', 'hourly', 'revenue_share'));
+
+CREATE INDEX IF NOT EXISTS idx_attendances_employment_id ON attendances (employment_id, check_in_at);
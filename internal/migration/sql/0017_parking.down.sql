@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS parking_tickets;
+DROP TABLE IF EXISTS parking_tariffs;
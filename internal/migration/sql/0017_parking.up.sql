@@ -0,0 +1,38 @@
+-- what a store charges per vehicle; stores without a row use the defaults
+CREATE TABLE IF NOT EXISTS parking_tariffs (
+    store_id     INTEGER     NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    vehicle_type VARCHAR(10) NOT NULL CHECK (vehicle_type IN ('motor', 'mobil')),
+    flat_fee     BIGINT      NOT NULL CHECK (flat_fee >= 0),
+    updated_at   BIGINT      NOT NULL,
+    PRIMARY KEY (store_id, vehicle_type)
+);
+
+-- a vehicle parked in front of a store, entered and later exited by an
+-- on-shift tukang. idempotency_key is generated by the app per ticket so a
+-- retried entry returns the same row.
+CREATE TABLE IF NOT EXISTS parking_tickets (
+    id                    SERIAL PRIMARY KEY,
+    store_id              INTEGER     NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    idempotency_key       VARCHAR(64) NOT NULL,
+    plate                 VARCHAR(15) NOT NULL,
+    vehicle_type          VARCHAR(10) NOT NULL,
+    status                VARCHAR(10) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    entry_tukang_id       INTEGER     NOT NULL REFERENCES users (id),
+    entry_employment_id   INTEGER     NOT NULL REFERENCES employments (id),
+    entry_at              BIGINT      NOT NULL,
+    entry_device_at       BIGINT      NOT NULL,
+    exit_tukang_id        INTEGER REFERENCES users (id),
+    exit_employment_id    INTEGER REFERENCES employments (id),
+    exit_at               BIGINT,
+    exit_device_at        BIGINT,
+    fee                   BIGINT CHECK (fee >= 0),
+    created_at            BIGINT      NOT NULL,
+    updated_at            BIGINT      NOT NULL,
+    CHECK (status = 'open' OR (exit_at IS NOT NULL AND fee IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_parking_tickets_key ON parking_tickets (store_id, idempotency_key);
+-- the same vehicle cannot be parked twice at one store
+CREATE UNIQUE INDEX IF NOT EXISTS ux_parking_tickets_open_plate ON parking_tickets (store_id, plate) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_parking_tickets_store_exit ON parking_tickets (store_id, exit_at) WHERE status = 'closed';
+CREATE INDEX IF NOT EXISTS idx_parking_tickets_exit_employment ON parking_tickets (exit_employment_id, exit_at) WHERE status = 'closed';
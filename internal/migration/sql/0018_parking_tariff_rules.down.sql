@@ -0,0 +1,14 @@
+DELETE FROM parking_tariffs WHERE vehicle_type = 'truk';
+
+ALTER TABLE parking_tariffs
+    DROP COLUMN IF EXISTS mode,
+    DROP COLUMN IF EXISTS first_hour_fee,
+    DROP COLUMN IF EXISTS next_hour_fee,
+    DROP COLUMN IF EXISTS daily_cap,
+    DROP COLUMN IF EXISTS grace_minutes,
+    DROP COLUMN IF EXISTS night_surcharge,
+    DROP COLUMN IF EXISTS night_start,
+    DROP COLUMN IF EXISTS night_end;
+
+ALTER TABLE parking_tariffs DROP CONSTRAINT IF EXISTS parking_tariffs_vehicle_type_check;
+ALTER TABLE parking_tariffs ADD CONSTRAINT parking_tariffs_vehicle_type_check CHECK (vehicle_type IN ('motor', 'mobil'));
@@ -0,0 +1,14 @@
+-- tariffs grow from a flat fee into full rules (see pkg/tariff); existing
+-- rows stay flat
+ALTER TABLE parking_tariffs DROP CONSTRAINT IF EXISTS parking_tariffs_vehicle_type_check;
+ALTER TABLE parking_tariffs ADD CONSTRAINT parking_tariffs_vehicle_type_check CHECK (vehicle_type IN ('motor', 'mobil', 'truk'));
+
+ALTER TABLE parking_tariffs
+    ADD COLUMN IF NOT EXISTS mode            VARCHAR(15) NOT NULL DEFAULT 'flat' CHECK (mode IN ('flat', 'progressive')),
+    ADD COLUMN IF NOT EXISTS first_hour_fee  BIGINT      NOT NULL DEFAULT 0 CHECK (first_hour_fee >= 0),
+    ADD COLUMN IF NOT EXISTS next_hour_fee   BIGINT      NOT NULL DEFAULT 0 CHECK (next_hour_fee >= 0),
+    ADD COLUMN IF NOT EXISTS daily_cap       BIGINT      NOT NULL DEFAULT 0 CHECK (daily_cap >= 0),
+    ADD COLUMN IF NOT EXISTS grace_minutes   INTEGER     NOT NULL DEFAULT 0 CHECK (grace_minutes >= 0),
+    ADD COLUMN IF NOT EXISTS night_surcharge BIGINT      NOT NULL DEFAULT 0 CHECK (night_surcharge >= 0),
+    ADD COLUMN IF NOT EXISTS night_start     VARCHAR(5)  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS night_end       VARCHAR(5)  NOT NULL DEFAULT '';
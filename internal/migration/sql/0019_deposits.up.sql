@@ -0,0 +1,24 @@
+-- cash a tukang hands to the store owner at the end of a shift ("setoran").
+-- collected, tukang_share and expected are worked out from the shift's
+-- parking tickets when the deposit is submitted.
+CREATE TABLE IF NOT EXISTS deposits (
+    id            SERIAL PRIMARY KEY,
+    store_id      INTEGER     NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    tukang_id     INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    employment_id INTEGER     NOT NULL REFERENCES employments (id) ON DELETE CASCADE,
+    attendance_id INTEGER     NOT NULL UNIQUE REFERENCES attendances (id) ON DELETE CASCADE,
+    collected     BIGINT      NOT NULL CHECK (collected >= 0),
+    tukang_share  BIGINT      NOT NULL CHECK (tukang_share >= 0),
+    expected      BIGINT      NOT NULL CHECK (expected >= 0),
+    amount        BIGINT      NOT NULL CHECK (amount >= 0),
+    status        VARCHAR(20) NOT NULL DEFAULT 'submitted' CHECK (status IN ('submitted', 'confirmed', 'disputed')),
+    note          TEXT        NOT NULL DEFAULT '',
+    store_note    TEXT        NOT NULL DEFAULT '',
+    submitted_at  BIGINT      NOT NULL,
+    decided_at    BIGINT,
+    created_at    BIGINT      NOT NULL,
+    updated_at    BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_deposits_store_id ON deposits (store_id, status);
+CREATE INDEX IF NOT EXISTS idx_deposits_tukang_id ON deposits (tukang_id, status);
@@ -0,0 +1,3 @@
+ALTER TABLE deposits DROP COLUMN IF EXISTS digital;
+DROP TABLE IF EXISTS parking_payments;
+ALTER TABLE parking_tickets DROP COLUMN IF EXISTS payment_method;
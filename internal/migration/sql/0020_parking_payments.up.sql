@@ -0,0 +1,32 @@
+-- how a closed ticket was paid; cash is held by the tukang until it is
+-- deposited, qris settles to the store through Midtrans
+ALTER TABLE parking_tickets ADD COLUMN IF NOT EXISTS payment_method VARCHAR(10)
+    CHECK (payment_method IN ('cash', 'qris'));
+UPDATE parking_tickets SET payment_method = 'cash' WHERE status = 'closed' AND payment_method IS NULL;
+
+-- a dynamic QRIS charge for one ticket. The fee is fixed when the QR is
+-- generated; the Midtrans notification for order_id closes the ticket with it.
+CREATE TABLE IF NOT EXISTS parking_payments (
+    id            SERIAL PRIMARY KEY,
+    ticket_id     INTEGER     NOT NULL REFERENCES parking_tickets (id) ON DELETE CASCADE,
+    store_id      INTEGER     NOT NULL REFERENCES stores (id) ON DELETE CASCADE,
+    tukang_id     INTEGER     NOT NULL REFERENCES users (id),
+    employment_id INTEGER     NOT NULL REFERENCES employments (id),
+    order_id      VARCHAR(64) NOT NULL UNIQUE,
+    amount        BIGINT      NOT NULL CHECK (amount > 0),
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'paid', 'failed', 'expired', 'cancelled', 'refunded')),
+    qr_string     TEXT        NOT NULL DEFAULT '',
+    qr_url        TEXT        NOT NULL DEFAULT '',
+    midtrans_id   VARCHAR(64) NOT NULL DEFAULT '',
+    expires_at    BIGINT,
+    paid_at       BIGINT,
+    raw_response  TEXT        NOT NULL DEFAULT '',
+    created_at    BIGINT      NOT NULL,
+    updated_at    BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_parking_payments_ticket ON parking_payments (ticket_id, status);
+
+-- QRIS collected during a shift is already with the store
+ALTER TABLE deposits ADD COLUMN IF NOT EXISTS digital BIGINT NOT NULL DEFAULT 0 CHECK (digital >= 0);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_parking_payments_status_created_at;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_parking_payments_status_created_at ON parking_payments (status, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE parking_payments DROP COLUMN IF EXISTS last_reconciled_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS last_reconciled_at;
@@ -0,0 +1,2 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS last_reconciled_at TIMESTAMPTZ;
+ALTER TABLE parking_payments ADD COLUMN IF NOT EXISTS last_reconciled_at BIGINT;
@@ -0,0 +1 @@
+ALTER TABLE attendances DROP COLUMN IF EXISTS check_out_outside_geofence;
@@ -0,0 +1,3 @@
+-- a check-out beyond the geofence is recorded rather than refused, so a
+-- tukang who already left can still close the shift; this marks it.
+ALTER TABLE attendances ADD COLUMN IF NOT EXISTS check_out_outside_geofence BOOLEAN NOT NULL DEFAULT FALSE;
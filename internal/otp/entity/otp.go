@@ -0,0 +1,38 @@
+package entity
+
+const (
+	PurposeVerifyPhone   = "verify_phone"
+	PurposeResetPassword = "reset_password"
+)
+
+type OTPCode struct {
+	ID          int
+	PhoneNumber string
+	Purpose     string
+	CodeHash    string
+	IPAddress   string
+	Attempts    int
+	ExpiresAt   int64
+	ConsumedAt  *int64
+	CreatedAt   int64
+}
+
+// SendLimits bound how often codes are sent: one per phone number and purpose
+// every ResendAfter seconds, and at most PerPhone per phone number and PerIP
+// per IP address within Window seconds.
+type SendLimits struct {
+	ResendAfter int64
+	Window      int64
+	PerPhone    int
+	PerIP       int
+}
+
+// req
+type SendOTPRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,e164"`
+}
+
+type VerifyOTPRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,e164"`
+	Code        string `json:"code" validate:"required,len=6,numeric"`
+}
@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	otpEntity "github.com/ghulammuzz/backend-parkerin/internal/otp/entity"
+)
+
+var ErrOTPNotFound = errors.New("no active code")
+
+// Advisory lock classes (the first key of the two-key form) under which Create
+// locks a phone number and an IP address.
+const (
+	phoneLockClass = 7274761
+	ipLockClass    = 7274762
+)
+
+type OTPRepository interface {
+	Create(code *otpEntity.OTPCode, limits otpEntity.SendLimits) (bool, error)
+	Latest(phoneNumber, purpose string) (*otpEntity.OTPCode, error)
+	IncrementAttempts(id, maxAttempts int) (bool, error)
+	Consume(id, maxAttempts int) (bool, error)
+}
+
+type otpRepository struct {
+	db *sql.DB
+}
+
+// Create records code unless its phone number or IP address is over limits,
+// in which case it reports false. The limits are checked by the insert itself
+// while the phone number and IP are locked for the transaction, so concurrent
+// requests are counted one after another instead of all passing one count.
+func (r *otpRepository) Create(code *otpEntity.OTPCode, limits otpEntity.SendLimits) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// always the phone number before the IP, so two requests never wait on
+	// each other
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1::INT, hashtext($2))`, phoneLockClass, code.PhoneNumber); err != nil {
+		return false, fmt.Errorf("failed to lock phone number: %w", err)
+	}
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1::INT, hashtext($2))`, ipLockClass, code.IPAddress); err != nil {
+		return false, fmt.Errorf("failed to lock ip address: %w", err)
+	}
+
+	query := `
+		INSERT INTO otp_codes (phone_number, purpose, code_hash, ip_address, expires_at, created_at)
+		SELECT $1::VARCHAR, $2::VARCHAR, $3::VARCHAR, $4::VARCHAR, $5::BIGINT, $6::BIGINT
+		WHERE NOT EXISTS (SELECT 1 FROM otp_codes WHERE phone_number = $1 AND purpose = $2 AND created_at > $6::BIGINT - $7::BIGINT)
+		  AND (SELECT COUNT(*) FROM otp_codes WHERE phone_number = $1 AND created_at > $6::BIGINT - $8::BIGINT) < $9::INT
+		  AND (SELECT COUNT(*) FROM otp_codes WHERE ip_address = $4 AND created_at > $6::BIGINT - $8::BIGINT) < $10::INT
+		RETURNING id
+	`
+	code.CreatedAt = time.Now().Unix()
+	err = tx.QueryRow(query, code.PhoneNumber, code.Purpose, code.CodeHash, code.IPAddress, code.ExpiresAt, code.CreatedAt,
+		limits.ResendAfter, limits.Window, limits.PerPhone, limits.PerIP).Scan(&code.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create otp code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Latest returns the newest unconsumed code for the phone number and purpose.
+// Requesting a new code therefore invalidates the older ones.
+func (r *otpRepository) Latest(phoneNumber, purpose string) (*otpEntity.OTPCode, error) {
+	query := `
+		SELECT id, phone_number, purpose, code_hash, ip_address, attempts, expires_at, created_at
+		FROM otp_codes
+		WHERE phone_number = $1 AND purpose = $2 AND consumed_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+	code := &otpEntity.OTPCode{}
+	err := r.db.QueryRow(query, phoneNumber, purpose).Scan(
+		&code.ID,
+		&code.PhoneNumber,
+		&code.Purpose,
+		&code.CodeHash,
+		&code.IPAddress,
+		&code.Attempts,
+		&code.ExpiresAt,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrOTPNotFound
+		}
+		return nil, err
+	}
+	return code, nil
+}
+
+// IncrementAttempts records a wrong guess. The limit is checked in the same
+// statement, so concurrent guesses cannot all slip under it; false means the
+// code is used up or already consumed.
+func (r *otpRepository) IncrementAttempts(id, maxAttempts int) (bool, error) {
+	query := `
+		UPDATE otp_codes SET attempts = attempts + 1
+		WHERE id = $1 AND attempts < $2 AND consumed_at IS NULL
+		RETURNING attempts
+	`
+	var attempts int
+	err := r.db.QueryRow(query, id, maxAttempts).Scan(&attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Consume marks the code used. It reports false if another request consumed
+// it first or the wrong guesses reached maxAttempts in the meantime.
+func (r *otpRepository) Consume(id, maxAttempts int) (bool, error) {
+	query := `UPDATE otp_codes SET consumed_at = $1 WHERE id = $2 AND consumed_at IS NULL AND attempts < $3`
+	result, err := r.db.Exec(query, time.Now().Unix(), id, maxAttempts)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func NewOTPRepository(db *sql.DB) OTPRepository {
+	return &otpRepository{db: db}
+}
@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	otpEntity "github.com/ghulammuzz/backend-parkerin/internal/otp/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/testdb"
+)
+
+const workers = 20
+
+func TestCreateConcurrentLimits(t *testing.T) {
+	cases := []struct {
+		name   string
+		phone  func(i int) string
+		ip     func(i int) string
+		limits otpEntity.SendLimits
+		want   int
+	}{
+		{
+			name:   "per phone",
+			phone:  func(int) string { return "+6281200000001" },
+			ip:     func(i int) string { return fmt.Sprintf("10.0.0.%d", i) },
+			limits: otpEntity.SendLimits{Window: 3600, PerPhone: 5, PerIP: 100},
+			want:   5,
+		},
+		{
+			name:   "per ip",
+			phone:  func(i int) string { return fmt.Sprintf("+62812000001%02d", i) },
+			ip:     func(int) string { return "10.0.0.1" },
+			limits: otpEntity.SendLimits{Window: 3600, PerPhone: 100, PerIP: 3},
+			want:   3,
+		},
+		{
+			name:   "resend",
+			phone:  func(int) string { return "+6281200000002" },
+			ip:     func(i int) string { return fmt.Sprintf("10.0.1.%d", i) },
+			limits: otpEntity.SendLimits{ResendAfter: 60, Window: 3600, PerPhone: 100, PerIP: 100},
+			want:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := testdb.Open(t)
+			r := NewOTPRepository(db)
+
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			created := 0
+			start := make(chan struct{})
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					ok, err := r.Create(&otpEntity.OTPCode{
+						PhoneNumber: tc.phone(i),
+						Purpose:     otpEntity.PurposeVerifyPhone,
+						CodeHash:    "hash",
+						IPAddress:   tc.ip(i),
+						ExpiresAt:   1 << 40,
+					}, tc.limits)
+					mu.Lock()
+					defer mu.Unlock()
+					if err != nil {
+						t.Errorf("create: %v", err)
+					}
+					if ok {
+						created++
+					}
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+
+			if created != tc.want {
+				t.Fatalf("%d codes created, want %d", created, tc.want)
+			}
+			var rows int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM otp_codes`).Scan(&rows); err != nil {
+				t.Fatal(err)
+			}
+			if rows != tc.want {
+				t.Fatalf("%d rows, want %d", rows, tc.want)
+			}
+		})
+	}
+}
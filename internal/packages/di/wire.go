@@ -0,0 +1,21 @@
+package di
+
+import (
+	"database/sql"
+
+	"github.com/ghulammuzz/backend-parkerin/internal/packages/handler"
+	pkgRepo "github.com/ghulammuzz/backend-parkerin/internal/packages/repo"
+	pkgSvc "github.com/ghulammuzz/backend-parkerin/internal/packages/svc"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/wire"
+)
+
+func InitializedPackageServiceFake(sb *sql.DB, val *validator.Validate) *handler.PackageHandler {
+	wire.Build(
+		handler.NewPackageHandler,
+		pkgSvc.NewPackageService,
+		pkgRepo.NewPackageRepository,
+	)
+
+	return &handler.PackageHandler{}
+}
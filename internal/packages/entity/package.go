@@ -0,0 +1,20 @@
+package entity
+
+type Package struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Price        int    `json:"price"`
+	DurationDays int    `json:"duration_days"`
+	IsActive     bool   `json:"is_active"`
+	TargetRole   string `json:"target_role"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+type PackageRequest struct {
+	Name         string `json:"name" validate:"required,min=2,max=100"`
+	Price        int    `json:"price" validate:"required,min=1"`
+	DurationDays int    `json:"duration_days" validate:"required,min=1,max=366"`
+	IsActive     *bool  `json:"is_active"`
+	TargetRole   string `json:"target_role" validate:"required,oneof=tukang store"`
+}
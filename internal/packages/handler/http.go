@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	pkgEntity "github.com/ghulammuzz/backend-parkerin/internal/packages/entity"
+	pkgRepo "github.com/ghulammuzz/backend-parkerin/internal/packages/repo"
+	pkgService "github.com/ghulammuzz/backend-parkerin/internal/packages/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/form"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PackageHandler struct {
+	pkgService pkgService.PackageService
+	val        *validator.Validate
+}
+
+func NewPackageHandler(pkgService pkgService.PackageService, val *validator.Validate) *PackageHandler {
+	return &PackageHandler{pkgService, val}
+}
+
+func (h *PackageHandler) Router(r fiber.Router) {
+	r.Get("/packages", h.ListPackages)
+	r.Get("/packages/:id", h.DetailPackage)
+	r.Post("/packages", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleAdmin), h.CreatePackage)
+	r.Put("/packages/:id", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleAdmin), h.UpdatePackage)
+	r.Delete("/packages/:id", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleAdmin), h.DeactivatePackage)
+}
+
+func (h *PackageHandler) ListPackages(c *fiber.Ctx) error {
+	all, err := strconv.ParseBool(c.Query("all", "false"))
+	if err != nil {
+		log.Error("Invalid all parameter", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid all parameter", nil)
+	}
+
+	packages, err := h.pkgService.ListPackages(!all)
+	if err != nil {
+		log.Error("Failed to retrieve package list", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve package list", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Package list retrieved successfully", packages)
+}
+
+func (h *PackageHandler) DetailPackage(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid package ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid package ID", nil)
+	}
+
+	pkg, err := h.pkgService.GetPackage(id)
+	if err != nil {
+		return h.packageError(c, "Failed to retrieve package", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Package retrieved successfully", pkg)
+}
+
+func (h *PackageHandler) CreatePackage(c *fiber.Ctx) error {
+	req := new(pkgEntity.PackageRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	pkg, err := h.pkgService.CreatePackage(req)
+	if err != nil {
+		log.Error("Failed to create package", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to create package", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusCreated, "Package created successfully", pkg)
+}
+
+func (h *PackageHandler) UpdatePackage(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid package ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid package ID", nil)
+	}
+
+	req := new(pkgEntity.PackageRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	pkg, err := h.pkgService.UpdatePackage(id, req)
+	if err != nil {
+		return h.packageError(c, "Failed to update package", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Package updated successfully", pkg)
+}
+
+func (h *PackageHandler) DeactivatePackage(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid package ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid package ID", nil)
+	}
+
+	if err := h.pkgService.DeactivatePackage(id); err != nil {
+		return h.packageError(c, "Failed to deactivate package", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Package deactivated", nil)
+}
+
+func (h *PackageHandler) packageError(c *fiber.Ctx, message string, err error) error {
+	log.Error(message, slog.String("error", err.Error()))
+	if errors.Is(err, pkgRepo.ErrPackageNotFound) {
+		return response.JSON(c, fiber.StatusNotFound, "Package not found", nil)
+	}
+	return response.JSON(c, fiber.StatusInternalServerError, message, err.Error())
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	pkgEntity "github.com/ghulammuzz/backend-parkerin/internal/packages/entity"
+)
+
+var ErrPackageNotFound = errors.New("package not found")
+
+type PackageRepository interface {
+	List(onlyActive bool) ([]pkgEntity.Package, error)
+	Detail(id int) (*pkgEntity.Package, error)
+	Create(pkg *pkgEntity.Package) error
+	Update(pkg *pkgEntity.Package) error
+	SetActive(id int, isActive bool) error
+}
+
+type packageRepository struct {
+	db *sql.DB
+}
+
+func (r *packageRepository) List(onlyActive bool) ([]pkgEntity.Package, error) {
+	query := `
+		SELECT id, name, price, duration_days, is_active, target_role, created_at, updated_at
+		FROM packages
+		WHERE is_active = true OR $1 = false
+		ORDER BY price ASC
+	`
+	rows, err := r.db.Query(query, onlyActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	packages := []pkgEntity.Package{}
+	for rows.Next() {
+		pkg := pkgEntity.Package{}
+		if err := rows.Scan(
+			&pkg.ID,
+			&pkg.Name,
+			&pkg.Price,
+			&pkg.DurationDays,
+			&pkg.IsActive,
+			&pkg.TargetRole,
+			&pkg.CreatedAt,
+			&pkg.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		packages = append(packages, pkg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return packages, nil
+}
+
+func (r *packageRepository) Detail(id int) (*pkgEntity.Package, error) {
+	query := `
+		SELECT id, name, price, duration_days, is_active, target_role, created_at, updated_at
+		FROM packages
+		WHERE id = $1
+	`
+	pkg := &pkgEntity.Package{}
+	err := r.db.QueryRow(query, id).Scan(
+		&pkg.ID,
+		&pkg.Name,
+		&pkg.Price,
+		&pkg.DurationDays,
+		&pkg.IsActive,
+		&pkg.TargetRole,
+		&pkg.CreatedAt,
+		&pkg.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+	return pkg, nil
+}
+
+func (r *packageRepository) Create(pkg *pkgEntity.Package) error {
+	query := `
+		INSERT INTO packages (name, price, duration_days, is_active, target_role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id
+	`
+	pkg.CreatedAt = time.Now().Unix()
+	pkg.UpdatedAt = pkg.CreatedAt
+
+	err := r.db.QueryRow(query, pkg.Name, pkg.Price, pkg.DurationDays, pkg.IsActive, pkg.TargetRole, pkg.CreatedAt).Scan(&pkg.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create package: %w", err)
+	}
+	return nil
+}
+
+func (r *packageRepository) Update(pkg *pkgEntity.Package) error {
+	query := `
+		UPDATE packages
+		SET name = $1, price = $2, duration_days = $3, is_active = $4, target_role = $5, updated_at = $6
+		WHERE id = $7
+	`
+	pkg.UpdatedAt = time.Now().Unix()
+
+	result, err := r.db.Exec(query, pkg.Name, pkg.Price, pkg.DurationDays, pkg.IsActive, pkg.TargetRole, pkg.UpdatedAt, pkg.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update package: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrPackageNotFound
+	}
+	return nil
+}
+
+func (r *packageRepository) SetActive(id int, isActive bool) error {
+	query := `UPDATE packages SET is_active = $1, updated_at = $2 WHERE id = $3`
+	result, err := r.db.Exec(query, isActive, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update package status: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrPackageNotFound
+	}
+	return nil
+}
+
+func NewPackageRepository(db *sql.DB) PackageRepository {
+	return &packageRepository{db: db}
+}
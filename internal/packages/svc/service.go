@@ -0,0 +1,72 @@
+package svc
+
+import (
+	pkgEntity "github.com/ghulammuzz/backend-parkerin/internal/packages/entity"
+	pkgRepo "github.com/ghulammuzz/backend-parkerin/internal/packages/repo"
+)
+
+type PackageService interface {
+	ListPackages(onlyActive bool) ([]pkgEntity.Package, error)
+	GetPackage(id int) (*pkgEntity.Package, error)
+	CreatePackage(req *pkgEntity.PackageRequest) (*pkgEntity.Package, error)
+	UpdatePackage(id int, req *pkgEntity.PackageRequest) (*pkgEntity.Package, error)
+	DeactivatePackage(id int) error
+}
+
+type packageService struct {
+	pkgRepo pkgRepo.PackageRepository
+}
+
+func (s *packageService) ListPackages(onlyActive bool) ([]pkgEntity.Package, error) {
+	return s.pkgRepo.List(onlyActive)
+}
+
+func (s *packageService) GetPackage(id int) (*pkgEntity.Package, error) {
+	return s.pkgRepo.Detail(id)
+}
+
+func (s *packageService) CreatePackage(req *pkgEntity.PackageRequest) (*pkgEntity.Package, error) {
+	pkg := &pkgEntity.Package{
+		Name:         req.Name,
+		Price:        req.Price,
+		DurationDays: req.DurationDays,
+		IsActive:     true,
+		TargetRole:   req.TargetRole,
+	}
+	if req.IsActive != nil {
+		pkg.IsActive = *req.IsActive
+	}
+
+	if err := s.pkgRepo.Create(pkg); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+func (s *packageService) UpdatePackage(id int, req *pkgEntity.PackageRequest) (*pkgEntity.Package, error) {
+	pkg, err := s.pkgRepo.Detail(id)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg.Name = req.Name
+	pkg.Price = req.Price
+	pkg.DurationDays = req.DurationDays
+	pkg.TargetRole = req.TargetRole
+	if req.IsActive != nil {
+		pkg.IsActive = *req.IsActive
+	}
+
+	if err := s.pkgRepo.Update(pkg); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+func (s *packageService) DeactivatePackage(id int) error {
+	return s.pkgRepo.SetActive(id, false)
+}
+
+func NewPackageService(pkgRepo pkgRepo.PackageRepository) PackageService {
+	return &packageService{pkgRepo: pkgRepo}
+}
@@ -0,0 +1,26 @@
+package di
+
+import (
+	"database/sql"
+
+	"github.com/ghulammuzz/backend-parkerin/config"
+
+	attRepo "github.com/ghulammuzz/backend-parkerin/internal/attendance/repo"
+	"github.com/ghulammuzz/backend-parkerin/internal/parking/handler"
+	parkRepo "github.com/ghulammuzz/backend-parkerin/internal/parking/repo"
+	parkSvc "github.com/ghulammuzz/backend-parkerin/internal/parking/svc"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/wire"
+)
+
+func InitializedParkingServiceFake(sb *sql.DB, val *validator.Validate, gw gateway.PaymentGateway, cfg config.PaymentConfig) *handler.ParkingHandler {
+	wire.Build(
+		handler.NewParkingHandler,
+		parkSvc.NewParkingService,
+		parkRepo.NewParkingRepository,
+		attRepo.NewAttendanceRepository,
+	)
+
+	return &handler.ParkingHandler{}
+}
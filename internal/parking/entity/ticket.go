@@ -0,0 +1,106 @@
+package entity
+
+import "github.com/ghulammuzz/backend-parkerin/pkg/tariff"
+
+const (
+	VehicleMotor = "motor"
+	VehicleMobil = "mobil"
+	VehicleTruk  = "truk"
+)
+
+// VehicleTypes lists every vehicle type in display order.
+var VehicleTypes = []string{VehicleMotor, VehicleMobil, VehicleTruk}
+
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+// How a closed ticket was paid: cash stays with the tukang until deposited,
+// QRIS is settled to the store by Midtrans.
+const (
+	PaymentCash = "cash"
+	PaymentQRIS = "qris"
+)
+
+// DefaultTariffs are charged by stores that have not set a tariff for the
+// vehicle type: a flat fee in Rupiah.
+var DefaultTariffs = map[string]tariff.Rule{
+	VehicleMotor: {VehicleType: VehicleMotor, Mode: tariff.ModeFlat, FlatFee: 2000},
+	VehicleMobil: {VehicleType: VehicleMobil, Mode: tariff.ModeFlat, FlatFee: 5000},
+	VehicleTruk:  {VehicleType: VehicleTruk, Mode: tariff.ModeFlat, FlatFee: 10000},
+}
+
+type Ticket struct {
+	ID                int     `json:"id"`
+	StoreID           int     `json:"store_id"`
+	IdempotencyKey    string  `json:"idempotency_key"`
+	Plate             string  `json:"plate"`
+	VehicleType       string  `json:"vehicle_type"`
+	Status            string  `json:"status"`
+	EntryTukangID     int     `json:"entry_tukang_id"`
+	EntryEmploymentID int     `json:"entry_employment_id"`
+	EntryAt           int64   `json:"entry_at"`
+	EntryDeviceAt     int64   `json:"entry_device_at"`
+	ExitTukangID      *int    `json:"exit_tukang_id"`
+	ExitEmploymentID  *int    `json:"exit_employment_id"`
+	ExitAt            *int64  `json:"exit_at"`
+	ExitDeviceAt      *int64  `json:"exit_device_at"`
+	Fee               *int64  `json:"fee"`
+	PaymentMethod     *string `json:"payment_method"`
+	CreatedAt         int64   `json:"created_at"`
+	UpdatedAt         int64   `json:"updated_at"`
+}
+
+// Payment is a dynamic QRIS charge for a ticket's fee, generated by the tukang
+// at exit and shown to the motorist. The ticket closes when Midtrans reports it
+// paid; Status follows the payment package's transaction statuses.
+type Payment struct {
+	ID           int    `json:"id"`
+	TicketID     int    `json:"ticket_id"`
+	StoreID      int    `json:"store_id"`
+	TukangID     int    `json:"tukang_id"`
+	EmploymentID int    `json:"employment_id"`
+	OrderID      string `json:"order_id"`
+	Amount       int64  `json:"amount"`
+	Status       string `json:"status"`
+	QRString     string `json:"qr_string"`
+	QRURL        string `json:"qr_url"`
+	MidtransID   string `json:"-"`
+	ExpiresAt    *int64 `json:"expires_at"`
+	PaidAt       *int64 `json:"paid_at"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+// IdempotencyKey is generated by the app once per ticket and resent on
+// retries; DeviceTime is the phone's clock as unix seconds.
+type EntryRequest struct {
+	IdempotencyKey string `json:"idempotency_key" validate:"required,min=8,max=64"`
+	Plate          string `json:"plate" validate:"required,min=3,max=15"`
+	VehicleType    string `json:"vehicle_type" validate:"required,oneof=motor mobil truk"`
+	DeviceTime     int64  `json:"device_time" validate:"required,min=1"`
+}
+
+type ExitRequest struct {
+	DeviceTime int64 `json:"device_time" validate:"required,min=1"`
+}
+
+// QuoteRequest prices a stay at a store; ExitAt defaults to now. Times are
+// unix seconds.
+type QuoteRequest struct {
+	StoreID     int    `query:"store_id" validate:"required,min=1"`
+	VehicleType string `query:"vehicle_type" validate:"required,oneof=motor mobil truk"`
+	EntryAt     int64  `query:"entry_at" validate:"required,min=1"`
+	ExitAt      int64  `query:"exit_at" validate:"omitempty,min=1"`
+}
+
+// DailyTotal is what a store collected on one day (WIB), counted by exit.
+// Cash and QRIS split Revenue by how it was paid.
+type DailyTotal struct {
+	Date    string           `json:"date"`
+	Tickets int              `json:"tickets"`
+	Revenue int64            `json:"revenue"`
+	Cash    int64            `json:"cash"`
+	QRIS    int64            `json:"qris"`
+	ByType  map[string]int64 `json:"by_vehicle_type"`
+}
@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	attService "github.com/ghulammuzz/backend-parkerin/internal/attendance/svc"
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	parkEntity "github.com/ghulammuzz/backend-parkerin/internal/parking/entity"
+	parkRepo "github.com/ghulammuzz/backend-parkerin/internal/parking/repo"
+	parkService "github.com/ghulammuzz/backend-parkerin/internal/parking/svc"
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	payService "github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/form"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/ghulammuzz/backend-parkerin/pkg/tariff"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ParkingHandler struct {
+	parkService parkService.ParkingService
+	val         *validator.Validate
+}
+
+func NewParkingHandler(parkService parkService.ParkingService, val *validator.Validate) *ParkingHandler {
+	return &ParkingHandler{parkService, val}
+}
+
+func (h *ParkingHandler) Router(r fiber.Router) {
+	r.Post("/parking/entries", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.Enter)
+	r.Post("/parking/tickets/:id/exit", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.Exit)
+	r.Post("/parking/tickets/:id/qris", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.ChargeQRIS)
+	r.Get("/parking/tickets/open", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.OpenTickets)
+	r.Get("/parking/payments/:id", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.Payment)
+	r.Post("/parking/payments/notification", h.Notification)
+	r.Post("/admin/parking/payments/:orderID/reconcile", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleAdmin), h.ReconcilePayment)
+	r.Get("/parking/tariffs", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.Tariffs)
+	r.Put("/parking/tariffs", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.SetTariff)
+	r.Get("/parking/quote", h.Quote)
+	r.Get("/parking/store/daily", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.DailyTotals)
+}
+
+func (h *ParkingHandler) Enter(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	req := new(parkEntity.EntryRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	ticket, created, err := h.parkService.Enter(principal.UserID, req)
+	if err != nil {
+		return parkingError(c, "Failed to record entry", err)
+	}
+
+	if !created {
+		return response.JSON(c, fiber.StatusOK, "Entry already recorded", ticket)
+	}
+	return response.JSON(c, fiber.StatusCreated, "Entry recorded", ticket)
+}
+
+func (h *ParkingHandler) Exit(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid ticket ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid ticket ID", nil)
+	}
+
+	req := new(parkEntity.ExitRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	ticket, err := h.parkService.Exit(principal.UserID, id, req)
+	if err != nil {
+		return parkingError(c, "Failed to record exit", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Exit recorded", ticket)
+}
+
+func (h *ParkingHandler) ChargeQRIS(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid ticket ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid ticket ID", nil)
+	}
+
+	payment, err := h.parkService.ChargeQRIS(principal.UserID, id)
+	if err != nil {
+		return parkingError(c, "Failed to create qris charge", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "QRIS charge created", payment)
+}
+
+func (h *ParkingHandler) Payment(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid payment ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid payment ID", nil)
+	}
+
+	payment, err := h.parkService.Payment(principal.UserID, id)
+	if err != nil {
+		return parkingError(c, "Failed to retrieve payment", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Payment retrieved successfully", payment)
+}
+
+// Notification receives Midtrans notifications for QRIS charges.
+func (h *ParkingHandler) Notification(c *fiber.Ctx) error {
+	notif := new(paymentEntity.NotificationRequest)
+	if err := c.BodyParser(notif); err != nil {
+		log.Error("Invalid notification payload", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+
+	if err := h.parkService.HandleNotification(c.Body()); err != nil {
+		log.Error("Error handling qris notification", slog.String("order_id", notif.OrderID), slog.String("error", err.Error()))
+		switch {
+		case errors.Is(err, payService.ErrInvalidSignature):
+			return response.JSON(c, fiber.StatusUnauthorized, "invalid signature", nil)
+		case errors.Is(err, parkRepo.ErrPaymentNotFound):
+			return response.JSON(c, fiber.StatusNotFound, "payment not found", nil)
+		case errors.Is(err, payService.ErrAmountMismatch), errors.Is(err, payService.ErrUnknownStatus):
+			return response.JSON(c, fiber.StatusBadRequest, err.Error(), nil)
+		}
+		return response.JSON(c, fiber.StatusInternalServerError, "error handling notification", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "notification processed", nil)
+}
+
+// ReconcilePayment lets an admin settle one QRIS charge from the gateway's
+// status API instead of waiting for the reconciliation job.
+func (h *ParkingHandler) ReconcilePayment(c *fiber.Ctx) error {
+	rec, err := h.parkService.ReconcilePayment(c.Params("orderID"))
+	if err != nil {
+		return parkingError(c, "Failed to reconcile payment", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Payment reconciled successfully", rec)
+}
+
+func (h *ParkingHandler) OpenTickets(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	tickets, err := h.parkService.OpenTickets(principal.UserID)
+	if err != nil {
+		return parkingError(c, "Failed to retrieve tickets", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Tickets retrieved successfully", tickets)
+}
+
+func (h *ParkingHandler) Tariffs(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	tariffs, err := h.parkService.Tariffs(principal.StoreID)
+	if err != nil {
+		return parkingError(c, "Failed to retrieve tariffs", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Tariffs retrieved successfully", tariffs)
+}
+
+func (h *ParkingHandler) SetTariff(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	req := new(tariff.Rule)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	if err := h.parkService.SetTariff(principal.StoreID, req); err != nil {
+		return parkingError(c, "Failed to save tariff", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Tariff saved", nil)
+}
+
+// ?store_id=&vehicle_type=&entry_at=&exit_at= (unix seconds, exit_at defaults
+// to now)
+func (h *ParkingHandler) Quote(c *fiber.Ctx) error {
+	req := new(parkEntity.QuoteRequest)
+	if err := c.QueryParser(req); err != nil {
+		log.Error("Query error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid query", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	breakdown, err := h.parkService.Quote(req)
+	if err != nil {
+		return parkingError(c, "Failed to quote", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Quote computed", breakdown)
+}
+
+// ?from=YYYY-MM-DD&to=YYYY-MM-DD, the last seven days when omitted
+func (h *ParkingHandler) DailyTotals(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	period, err := attService.ParseRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		return parkingError(c, "Invalid date range", err)
+	}
+
+	totals, err := h.parkService.DailyTotals(principal.StoreID, period)
+	if err != nil {
+		return parkingError(c, "Failed to retrieve totals", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Totals retrieved successfully", totals)
+}
+
+func parkingError(c *fiber.Ctx, message string, err error) error {
+	log.Error(message, slog.String("error", err.Error()))
+	switch {
+	case errors.Is(err, parkRepo.ErrTicketNotFound), errors.Is(err, parkRepo.ErrPaymentNotFound):
+		return response.JSON(c, fiber.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, attService.ErrInvalidRange), errors.Is(err, parkService.ErrInvalidTariff), errors.Is(err, parkService.ErrInvalidQuote):
+		return response.JSON(c, fiber.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, parkService.ErrNotOnShift):
+		return response.JSON(c, fiber.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, parkRepo.ErrVehicleParked), errors.Is(err, parkService.ErrKeyReused),
+		errors.Is(err, parkService.ErrTicketClosed), errors.Is(err, parkService.ErrNothingToCharge),
+		errors.Is(err, payService.ErrAmountMismatch):
+		return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+	case errors.Is(err, parkService.ErrGateway), errors.Is(err, payService.ErrUnknownStatus):
+		return response.JSON(c, fiber.StatusBadGateway, err.Error(), nil)
+	}
+	return response.JSON(c, fiber.StatusInternalServerError, message, err.Error())
+}
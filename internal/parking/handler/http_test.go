@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"database/sql"
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ghulammuzz/backend-parkerin/config"
+	attRepo "github.com/ghulammuzz/backend-parkerin/internal/attendance/repo"
+	parkEntity "github.com/ghulammuzz/backend-parkerin/internal/parking/entity"
+	parkRepo "github.com/ghulammuzz/backend-parkerin/internal/parking/repo"
+	parkService "github.com/ghulammuzz/backend-parkerin/internal/parking/svc"
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/fake"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	"github.com/ghulammuzz/backend-parkerin/internal/testdb"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+const serverKey = "test-server-key"
+
+// onShift checks tukangID in at a new store and returns the store and the
+// employment the shift belongs to.
+func onShift(t *testing.T, db *sql.DB, tukangID int) (storeID, employmentID int) {
+	t.Helper()
+
+	storeID, _ = testdb.Store(t, db)
+	employmentID = testdb.Employment(t, db, storeID, tukangID)
+	now := time.Now().Unix()
+	_, err := db.Exec(`
+		INSERT INTO attendances (employment_id, store_id, tukang_id, check_in_at, check_in_device_at,
+		                         check_in_latitude, check_in_longitude, check_in_distance_m)
+		VALUES ($1, $2, $3, $4, $4, -6.2, 106.8, 5)
+	`, employmentID, storeID, tukangID, now-3*3600)
+	if err != nil {
+		t.Fatalf("failed to check in: %v", err)
+	}
+	return storeID, employmentID
+}
+
+// openTicket parks a motorbike, at the default flat tariff, an hour ago.
+func openTicket(t *testing.T, db *sql.DB, storeID, tukangID, employmentID int) int {
+	t.Helper()
+
+	var id int
+	entryAt := time.Now().Add(-time.Hour).Unix()
+	err := db.QueryRow(`
+		INSERT INTO parking_tickets (store_id, idempotency_key, plate, vehicle_type, entry_tukang_id, entry_employment_id,
+		                             entry_at, entry_device_at, created_at, updated_at)
+		VALUES ($1, 'e2e-1', 'B1234XYZ', $2, $3, $4, $5, $5, $5, $5)
+		RETURNING id
+	`, storeID, parkEntity.VehicleMotor, tukangID, employmentID, entryAt).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to open ticket: %v", err)
+	}
+	return id
+}
+
+// TestQRISSettlement pays a ticket's QR end to end: the charge goes through
+// the Midtrans gateway to fake.Server, which posts the signed settlement to
+// the parking webhook.
+func TestQRISSettlement(t *testing.T) {
+	db := testdb.Open(t)
+	tukangID := testdb.User(t, db, "tukang")
+	storeID, employmentID := onShift(t, db, tukangID)
+	ticketID := openTicket(t, db, storeID, tukangID, employmentID)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhook := "http://" + ln.Addr().String() + "/parking/payments/notification"
+
+	midtrans := fake.NewServer(serverKey, "")
+	api := httptest.NewServer(midtrans)
+	t.Cleanup(api.Close)
+
+	repo := parkRepo.NewParkingRepository(db)
+	svc := parkService.NewParkingService(repo, attRepo.NewAttendanceRepository(db),
+		gateway.NewMidtrans(serverKey, false, api.URL), config.PaymentConfig{ParkingNotificationURL: webhook})
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	NewParkingHandler(svc, validator.New()).Router(app)
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+
+	payment, err := svc.ChargeQRIS(tukangID, ticketID)
+	if err != nil {
+		t.Fatalf("charge qris: %v", err)
+	}
+	fee := parkEntity.DefaultTariffs[parkEntity.VehicleMotor].FlatFee
+	if payment.Amount != fee || payment.QRString == "" {
+		t.Fatalf("charge = %d with qr %q, want %d with a qr", payment.Amount, payment.QRString, fee)
+	}
+
+	code, err := midtrans.Settle(payment.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != fiber.StatusOK {
+		t.Fatalf("webhook answered %d, want 200", code)
+	}
+
+	ticket, err := repo.Detail(ticketID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket.Status != parkEntity.StatusClosed {
+		t.Fatalf("ticket is %s, want closed", ticket.Status)
+	}
+	if ticket.PaymentMethod == nil || *ticket.PaymentMethod != "qris" {
+		t.Fatalf("ticket payment method = %v, want qris", ticket.PaymentMethod)
+	}
+	if ticket.Fee == nil || *ticket.Fee != fee {
+		t.Fatalf("ticket fee = %v, want %d", ticket.Fee, fee)
+	}
+	if ticket.ExitTukangID == nil || *ticket.ExitTukangID != tukangID ||
+		ticket.ExitEmploymentID == nil || *ticket.ExitEmploymentID != employmentID {
+		t.Fatalf("ticket credited to tukang %v employment %v, want %d and %d",
+			ticket.ExitTukangID, ticket.ExitEmploymentID, tukangID, employmentID)
+	}
+
+	paid, err := repo.PaymentByOrderID(payment.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.Status != paymentEntity.StatusPaid {
+		t.Fatalf("payment is %s, want paid", paid.Status)
+	}
+
+	// Midtrans retries notifications; a repeat must change nothing
+	if code, err = midtrans.Settle(payment.OrderID); err != nil {
+		t.Fatal(err)
+	}
+	if code != fiber.StatusOK {
+		t.Fatalf("duplicate webhook answered %d, want 200", code)
+	}
+
+	again, err := repo.Detail(ticketID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, ticket) {
+		t.Fatalf("duplicate notification changed the ticket:\n%+v\nwant\n%+v", again, ticket)
+	}
+	paidAgain, err := repo.PaymentByOrderID(payment.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paidAgain, paid) {
+		t.Fatalf("duplicate notification changed the payment:\n%+v\nwant\n%+v", paidAgain, paid)
+	}
+}
@@ -0,0 +1,236 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	parkEntity "github.com/ghulammuzz/backend-parkerin/internal/parking/entity"
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+)
+
+const selectPayment = `
+	SELECT id, ticket_id, store_id, tukang_id, employment_id, order_id, amount, status,
+	       qr_string, qr_url, midtrans_id, expires_at, paid_at, created_at
+	FROM parking_payments
+`
+
+func scanPayment(row rowScanner) (*parkEntity.Payment, error) {
+	p := &parkEntity.Payment{}
+	var expiresAt, paidAt sql.NullInt64
+	err := row.Scan(
+		&p.ID,
+		&p.TicketID,
+		&p.StoreID,
+		&p.TukangID,
+		&p.EmploymentID,
+		&p.OrderID,
+		&p.Amount,
+		&p.Status,
+		&p.QRString,
+		&p.QRURL,
+		&p.MidtransID,
+		&expiresAt,
+		&paidAt,
+		&p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		p.ExpiresAt = &expiresAt.Int64
+	}
+	if paidAt.Valid {
+		p.PaidAt = &paidAt.Int64
+	}
+	return p, nil
+}
+
+func (r *parkingRepository) CreatePayment(payment *parkEntity.Payment) error {
+	query := `
+		INSERT INTO parking_payments (ticket_id, store_id, tukang_id, employment_id, order_id, amount, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING id
+	`
+	err := r.db.QueryRow(query, payment.TicketID, payment.StoreID, payment.TukangID, payment.EmploymentID, payment.OrderID,
+		payment.Amount, payment.Status, payment.CreatedAt).Scan(&payment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+	return nil
+}
+
+// SetPaymentQR stores what Midtrans returned for the charge.
+func (r *parkingRepository) SetPaymentQR(payment *parkEntity.Payment) error {
+	query := `
+		UPDATE parking_payments
+		SET qr_string = $1, qr_url = $2, midtrans_id = $3, expires_at = $4, updated_at = $5
+		WHERE id = $6
+	`
+	_, err := r.db.Exec(query, payment.QRString, payment.QRURL, payment.MidtransID, payment.ExpiresAt, time.Now().Unix(), payment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save payment qr: %w", err)
+	}
+	return nil
+}
+
+// PendingPayment returns the newest unexpired pending charge for the ticket
+// with the given amount, so asking for a QR again shows the same one.
+func (r *parkingRepository) PendingPayment(ticketID int, amount int64) (*parkEntity.Payment, error) {
+	query := selectPayment + `
+		WHERE ticket_id = $1 AND amount = $2 AND status = 'pending' AND qr_string <> ''
+		  AND (expires_at IS NULL OR expires_at > $3)
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	payment, err := scanPayment(r.db.QueryRow(query, ticketID, amount, time.Now().Unix()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return payment, nil
+}
+
+// PendingPayments returns every charge on the ticket still waiting to be paid,
+// expired QRs included until their notification arrives.
+func (r *parkingRepository) PendingPayments(ticketID int) ([]parkEntity.Payment, error) {
+	rows, err := r.db.Query(selectPayment+` WHERE ticket_id = $1 AND status = 'pending' ORDER BY id`, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []parkEntity.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+	return payments, rows.Err()
+}
+
+func (r *parkingRepository) PaymentDetail(id int) (*parkEntity.Payment, error) {
+	payment, err := scanPayment(r.db.QueryRow(selectPayment+` WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (r *parkingRepository) PaymentByOrderID(orderID string) (*parkEntity.Payment, error) {
+	payment, err := scanPayment(r.db.QueryRow(selectPayment+` WHERE order_id = $1`, orderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	return payment, nil
+}
+
+// UnsettledPayments returns up to limit pending charges created before the
+// given unix time, never reconciled ones first and then those checked longest
+// ago, like the package transactions.
+func (r *parkingRepository) UnsettledPayments(before int64, limit int) ([]parkEntity.Payment, error) {
+	query := selectPayment + `
+		WHERE status = 'pending' AND created_at < $1
+		ORDER BY last_reconciled_at NULLS FIRST, created_at
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unsettled payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []parkEntity.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+	return payments, rows.Err()
+}
+
+func (r *parkingRepository) MarkPaymentReconciled(id int) error {
+	_, err := r.db.Exec(`UPDATE parking_payments SET last_reconciled_at = $1 WHERE id = $2`, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark payment reconciled: %w", err)
+	}
+	return nil
+}
+
+// ApplyPayment moves the charge from its current status to toStatus. Like the
+// package transactions, the update only matches while the row is still in
+// payment.Status, so a duplicate notification reports applied false. A paid
+// charge closes the ticket as QRIS in the same transaction, crediting the
+// tukang who generated the QR; closed is false when the ticket had already
+// been closed some other way.
+func (r *parkingRepository) ApplyPayment(payment *parkEntity.Payment, toStatus, raw string) (bool, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	var paidAt *int64
+	if toStatus == paymentEntity.StatusPaid {
+		paidAt = &now
+	}
+
+	updateQuery := `
+		UPDATE parking_payments
+		SET status = $1, midtrans_id = COALESCE(NULLIF($2, ''), midtrans_id), paid_at = COALESCE($3, paid_at),
+		    raw_response = $4, updated_at = $5
+		WHERE id = $6 AND status = $7
+	`
+	result, err := tx.Exec(updateQuery, toStatus, payment.MidtransID, paidAt, raw, now, payment.ID, payment.Status)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to update payment status: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, false, err
+	}
+	if rowsAffected == 0 {
+		return false, false, nil
+	}
+
+	closed := false
+	if paidAt != nil {
+		closeQuery := `
+			UPDATE parking_tickets
+			SET status = 'closed', exit_tukang_id = $1, exit_employment_id = $2, exit_at = $3,
+			    exit_device_at = $3, fee = $4, payment_method = 'qris', updated_at = $3
+			WHERE id = $5 AND status = 'open'
+		`
+		result, err := tx.Exec(closeQuery, payment.TukangID, payment.EmploymentID, now, payment.Amount, payment.TicketID)
+		if err != nil {
+			return false, false, fmt.Errorf("failed to close ticket: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return false, false, err
+		}
+		closed = rowsAffected > 0
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, false, err
+	}
+	payment.Status = toStatus
+	if paidAt != nil {
+		payment.PaidAt = paidAt
+	}
+	return true, closed, nil
+}
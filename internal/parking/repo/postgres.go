@@ -0,0 +1,296 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	attEntity "github.com/ghulammuzz/backend-parkerin/internal/attendance/entity"
+	parkEntity "github.com/ghulammuzz/backend-parkerin/internal/parking/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/tariff"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTicketNotFound  = errors.New("ticket not found")
+	ErrVehicleParked   = errors.New("vehicle already has an open ticket at this store")
+	ErrPaymentNotFound = errors.New("payment not found")
+)
+
+type ParkingRepository interface {
+	Tariffs(storeID int) ([]tariff.Rule, error)
+	UpsertTariff(storeID int, rule *tariff.Rule) error
+	Enter(ticket *parkEntity.Ticket) (bool, error)
+	Detail(id int) (*parkEntity.Ticket, error)
+	Exit(ticket *parkEntity.Ticket) (bool, error)
+	ListOpen(storeID int) ([]parkEntity.Ticket, error)
+	DailyTotals(storeID int, period attEntity.Range) ([]parkEntity.DailyTotal, error)
+	Revenue(employmentID int, period attEntity.Range) (int64, error)
+	CreatePayment(payment *parkEntity.Payment) error
+	SetPaymentQR(payment *parkEntity.Payment) error
+	PendingPayment(ticketID int, amount int64) (*parkEntity.Payment, error)
+	PendingPayments(ticketID int) ([]parkEntity.Payment, error)
+	PaymentDetail(id int) (*parkEntity.Payment, error)
+	PaymentByOrderID(orderID string) (*parkEntity.Payment, error)
+	ApplyPayment(payment *parkEntity.Payment, toStatus, raw string) (applied, closed bool, err error)
+	UnsettledPayments(before int64, limit int) ([]parkEntity.Payment, error)
+	MarkPaymentReconciled(id int) error
+}
+
+type parkingRepository struct {
+	db *sql.DB
+}
+
+const selectTicket = `
+	SELECT id, store_id, idempotency_key, plate, vehicle_type, status,
+	       entry_tukang_id, entry_employment_id, entry_at, entry_device_at,
+	       exit_tukang_id, exit_employment_id, exit_at, exit_device_at, fee, payment_method,
+	       created_at, updated_at
+	FROM parking_tickets
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTicket(row rowScanner) (*parkEntity.Ticket, error) {
+	t := &parkEntity.Ticket{}
+	var exitTukangID, exitEmploymentID, exitAt, exitDeviceAt, fee sql.NullInt64
+	var paymentMethod sql.NullString
+	err := row.Scan(
+		&t.ID,
+		&t.StoreID,
+		&t.IdempotencyKey,
+		&t.Plate,
+		&t.VehicleType,
+		&t.Status,
+		&t.EntryTukangID,
+		&t.EntryEmploymentID,
+		&t.EntryAt,
+		&t.EntryDeviceAt,
+		&exitTukangID,
+		&exitEmploymentID,
+		&exitAt,
+		&exitDeviceAt,
+		&fee,
+		&paymentMethod,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if exitTukangID.Valid {
+		id := int(exitTukangID.Int64)
+		t.ExitTukangID = &id
+	}
+	if exitEmploymentID.Valid {
+		id := int(exitEmploymentID.Int64)
+		t.ExitEmploymentID = &id
+	}
+	if exitAt.Valid {
+		t.ExitAt = &exitAt.Int64
+	}
+	if exitDeviceAt.Valid {
+		t.ExitDeviceAt = &exitDeviceAt.Int64
+	}
+	if fee.Valid {
+		t.Fee = &fee.Int64
+	}
+	if paymentMethod.Valid {
+		t.PaymentMethod = &paymentMethod.String
+	}
+	return t, nil
+}
+
+func (r *parkingRepository) Tariffs(storeID int) ([]tariff.Rule, error) {
+	query := `
+		SELECT vehicle_type, mode, flat_fee, first_hour_fee, next_hour_fee, daily_cap,
+		       grace_minutes, night_surcharge, night_start, night_end
+		FROM parking_tariffs
+		WHERE store_id = $1
+		ORDER BY vehicle_type
+	`
+	rows, err := r.db.Query(query, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []tariff.Rule{}
+	for rows.Next() {
+		var rule tariff.Rule
+		err := rows.Scan(&rule.VehicleType, &rule.Mode, &rule.FlatFee, &rule.FirstHourFee, &rule.NextHourFee, &rule.DailyCap,
+			&rule.GraceMinutes, &rule.NightSurcharge, &rule.NightStart, &rule.NightEnd)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *parkingRepository) UpsertTariff(storeID int, rule *tariff.Rule) error {
+	query := `
+		INSERT INTO parking_tariffs (store_id, vehicle_type, mode, flat_fee, first_hour_fee, next_hour_fee, daily_cap,
+		                             grace_minutes, night_surcharge, night_start, night_end, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (store_id, vehicle_type) DO UPDATE
+		SET mode = EXCLUDED.mode, flat_fee = EXCLUDED.flat_fee, first_hour_fee = EXCLUDED.first_hour_fee,
+		    next_hour_fee = EXCLUDED.next_hour_fee, daily_cap = EXCLUDED.daily_cap, grace_minutes = EXCLUDED.grace_minutes,
+		    night_surcharge = EXCLUDED.night_surcharge, night_start = EXCLUDED.night_start, night_end = EXCLUDED.night_end,
+		    updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Exec(query, storeID, rule.VehicleType, rule.Mode, rule.FlatFee, rule.FirstHourFee, rule.NextHourFee, rule.DailyCap,
+		rule.GraceMinutes, rule.NightSurcharge, rule.NightStart, rule.NightEnd, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save tariff: %w", err)
+	}
+	return nil
+}
+
+// Enter records the ticket, reporting false (and filling ticket with the
+// stored row) when one with the same idempotency key already exists.
+func (r *parkingRepository) Enter(ticket *parkEntity.Ticket) (bool, error) {
+	query := `
+		INSERT INTO parking_tickets (store_id, idempotency_key, plate, vehicle_type, status, entry_tukang_id,
+		                             entry_employment_id, entry_at, entry_device_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'open', $5, $6, $7, $8, $7, $7)
+		ON CONFLICT (store_id, idempotency_key) DO NOTHING
+		RETURNING id
+	`
+	err := r.db.QueryRow(query, ticket.StoreID, ticket.IdempotencyKey, ticket.Plate, ticket.VehicleType, ticket.EntryTukangID,
+		ticket.EntryEmploymentID, ticket.EntryAt, ticket.EntryDeviceAt).Scan(&ticket.ID)
+	if err == nil {
+		ticket.Status = parkEntity.StatusOpen
+		ticket.CreatedAt = ticket.EntryAt
+		ticket.UpdatedAt = ticket.EntryAt
+		return true, nil
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "ux_parking_tickets_open_plate" {
+		return false, ErrVehicleParked
+	}
+	if err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to record entry: %w", err)
+	}
+
+	existing, err := scanTicket(r.db.QueryRow(selectTicket+` WHERE store_id = $1 AND idempotency_key = $2`, ticket.StoreID, ticket.IdempotencyKey))
+	if err != nil {
+		return false, fmt.Errorf("failed to load existing ticket: %w", err)
+	}
+	*ticket = *existing
+	return false, nil
+}
+
+func (r *parkingRepository) Detail(id int) (*parkEntity.Ticket, error) {
+	ticket, err := scanTicket(r.db.QueryRow(selectTicket+` WHERE id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTicketNotFound
+		}
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// Exit closes an open ticket. It reports false when the ticket was already
+// closed.
+func (r *parkingRepository) Exit(ticket *parkEntity.Ticket) (bool, error) {
+	query := `
+		UPDATE parking_tickets
+		SET status = 'closed', exit_tukang_id = $1, exit_employment_id = $2, exit_at = $3,
+		    exit_device_at = $4, fee = $5, payment_method = $6, updated_at = $3
+		WHERE id = $7 AND status = 'open'
+	`
+	result, err := r.db.Exec(query, ticket.ExitTukangID, ticket.ExitEmploymentID, ticket.ExitAt, ticket.ExitDeviceAt, ticket.Fee,
+		ticket.PaymentMethod, ticket.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to record exit: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *parkingRepository) ListOpen(storeID int) ([]parkEntity.Ticket, error) {
+	rows, err := r.db.Query(selectTicket+` WHERE store_id = $1 AND status = 'open' ORDER BY entry_at`, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []parkEntity.Ticket{}
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, *ticket)
+	}
+	return tickets, rows.Err()
+}
+
+// DailyTotals sums the fees of tickets closed within period per WIB day.
+// Days without tickets are left out.
+func (r *parkingRepository) DailyTotals(storeID int, period attEntity.Range) ([]parkEntity.DailyTotal, error) {
+	query := `
+		SELECT TO_CHAR(TO_TIMESTAMP(exit_at) AT TIME ZONE 'Asia/Jakarta', 'YYYY-MM-DD') AS day,
+		       vehicle_type, payment_method, COUNT(*), COALESCE(SUM(fee), 0)
+		FROM parking_tickets
+		WHERE store_id = $1 AND status = 'closed' AND exit_at >= $2 AND exit_at < $3
+		GROUP BY day, vehicle_type, payment_method
+		ORDER BY day
+	`
+	rows, err := r.db.Query(query, storeID, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []parkEntity.DailyTotal{}
+	for rows.Next() {
+		var day, vehicleType, paymentMethod string
+		var tickets int
+		var revenue int64
+		if err := rows.Scan(&day, &vehicleType, &paymentMethod, &tickets, &revenue); err != nil {
+			return nil, err
+		}
+		if n := len(totals); n == 0 || totals[n-1].Date != day {
+			totals = append(totals, parkEntity.DailyTotal{Date: day, ByType: map[string]int64{}})
+		}
+		total := &totals[len(totals)-1]
+		total.Tickets += tickets
+		total.Revenue += revenue
+		total.ByType[vehicleType] += revenue
+		if paymentMethod == parkEntity.PaymentQRIS {
+			total.QRIS += revenue
+		} else {
+			total.Cash += revenue
+		}
+	}
+	return totals, rows.Err()
+}
+
+// Revenue is the fees collected (at exit) under the employment within
+// period.
+func (r *parkingRepository) Revenue(employmentID int, period attEntity.Range) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(fee), 0)
+		FROM parking_tickets
+		WHERE exit_employment_id = $1 AND status = 'closed' AND exit_at >= $2 AND exit_at < $3
+	`
+	var revenue int64
+	if err := r.db.QueryRow(query, employmentID, period.From, period.To).Scan(&revenue); err != nil {
+		return 0, err
+	}
+	return revenue, nil
+}
+
+func NewParkingRepository(db *sql.DB) ParkingRepository {
+	return &parkingRepository{db: db}
+}
@@ -0,0 +1,245 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	parkEntity "github.com/ghulammuzz/backend-parkerin/internal/parking/entity"
+	parkRepo "github.com/ghulammuzz/backend-parkerin/internal/parking/repo"
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	paySvc "github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+var (
+	ErrTicketClosed = errors.New("ticket is already closed")
+	// ErrNothingToCharge is returned for a stay still inside the grace
+	// period; close it as cash instead.
+	ErrNothingToCharge = errors.New("ticket has no fee to charge")
+	ErrGateway         = gateway.ErrGateway
+)
+
+// qrisExpiry is how long a generated QR can be paid.
+const qrisExpiry = 15 * time.Minute
+
+// ChargeQRIS generates a dynamic QRIS for the ticket's fee as of now. Asking
+// again while the fee is unchanged returns the QR already generated.
+func (s *parkingService) ChargeQRIS(tukangID, ticketID int) (*parkEntity.Payment, error) {
+	att, err := s.onShift(tukangID)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.parkRepo.Detail(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.StoreID != att.StoreID {
+		return nil, parkRepo.ErrTicketNotFound
+	}
+	if ticket.Status == parkEntity.StatusClosed {
+		return nil, ErrTicketClosed
+	}
+
+	now := time.Now()
+	breakdown, err := s.quote(ticket.StoreID, ticket.VehicleType, time.Unix(ticket.EntryAt, 0), now)
+	if err != nil {
+		return nil, err
+	}
+	if breakdown.Total == 0 {
+		return nil, ErrNothingToCharge
+	}
+
+	existing, err := s.parkRepo.PendingPayment(ticket.ID, breakdown.Total)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, parkRepo.ErrPaymentNotFound) {
+		return nil, err
+	}
+
+	// the fee moved on; the older QR must not stay payable next to the new one
+	if err := s.cancelPending(ticket.ID); err != nil {
+		return nil, err
+	}
+
+	payment := &parkEntity.Payment{
+		TicketID:     ticket.ID,
+		StoreID:      ticket.StoreID,
+		TukangID:     tukangID,
+		EmploymentID: att.EmploymentID,
+		OrderID:      fmt.Sprintf("PRK-%d-%d", ticket.ID, now.UnixMilli()),
+		Amount:       breakdown.Total,
+		Status:       paymentEntity.StatusPending,
+		CreatedAt:    now.Unix(),
+	}
+	if err := s.parkRepo.CreatePayment(payment); err != nil {
+		return nil, err
+	}
+
+	charge, err := s.gateway.CreateCharge(&gateway.ChargeRequest{
+		OrderID:         payment.OrderID,
+		Amount:          payment.Amount,
+		Method:          gateway.MethodQRIS,
+		NotificationURL: s.cfg.ParkingNotificationURL,
+		Expiry:          qrisExpiry,
+		Items: []gateway.Item{
+			{
+				ID:    fmt.Sprintf("TICKET-%d", ticket.ID),
+				Qty:   1,
+				Price: payment.Amount,
+				Name:  fmt.Sprintf("Parkir %s %s", ticket.VehicleType, ticket.Plate),
+			},
+		},
+	})
+	if err != nil {
+		// the order never reached the gateway, so nothing can settle it
+		if _, _, err := s.parkRepo.ApplyPayment(payment, paymentEntity.StatusFailed, err.Error()); err != nil {
+			log.Error("failed to mark qris charge failed", "order_id", payment.OrderID, "error", err.Error())
+		}
+		return nil, err
+	}
+
+	payment.QRString = charge.QRString
+	payment.QRURL = charge.QRURL
+	payment.MidtransID = charge.TransactionID
+	expiresAt := now.Add(qrisExpiry).Unix()
+	if charge.ExpiresAt != nil {
+		expiresAt = charge.ExpiresAt.Unix()
+	}
+	payment.ExpiresAt = &expiresAt
+
+	if err := s.parkRepo.SetPaymentQR(payment); err != nil {
+		return nil, err
+	}
+
+	log.Info("qris charge created", "order_id", payment.OrderID, "ticket_id", ticket.ID, "tukang_id", tukangID, "amount", payment.Amount)
+	return payment, nil
+}
+
+// cancelPending cancels the ticket's outstanding charges at the gateway. One
+// the gateway will not cancel is reconciled instead: if it turns out paid the
+// ticket is closed, and if it is still pending no new charge may be made.
+func (s *parkingService) cancelPending(ticketID int) error {
+	pending, err := s.parkRepo.PendingPayments(ticketID)
+	if err != nil {
+		return err
+	}
+
+	for i := range pending {
+		payment := &pending[i]
+
+		res, err := s.gateway.Cancel(payment.OrderID)
+		switch {
+		case errors.Is(err, gateway.ErrOrderNotFound):
+			// never reached the gateway, so nothing there can settle it
+			res = &gateway.Result{
+				OrderID: payment.OrderID,
+				Status:  paymentEntity.StatusCancelled,
+				Amount:  payment.Amount,
+				Time:    time.Now(),
+				Raw:     fmt.Sprintf(`{"order_id":%q,"cancelled":"superseded, not found at the payment gateway"}`, payment.OrderID),
+			}
+		case err != nil:
+			rec, recErr := s.reconcile(payment, 0)
+			if recErr != nil {
+				return fmt.Errorf("%w: superseded charge %s could not be cancelled: %v", ErrGateway, payment.OrderID, err)
+			}
+			switch rec.Status {
+			case paymentEntity.StatusPaid, paymentEntity.StatusRefunded:
+				return ErrTicketClosed
+			case paymentEntity.StatusPending:
+				return fmt.Errorf("%w: superseded charge %s could not be cancelled: %v", ErrGateway, payment.OrderID, err)
+			}
+			continue
+		}
+
+		if _, err := s.applyPayment(res); err != nil {
+			return err
+		}
+		log.Info("superseded qris charge cancelled", "order_id", payment.OrderID, "ticket_id", ticketID)
+	}
+	return nil
+}
+
+// Payment lets a tukang on shift follow a charge at their store.
+func (s *parkingService) Payment(tukangID, paymentID int) (*parkEntity.Payment, error) {
+	att, err := s.onShift(tukangID)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.parkRepo.PaymentDetail(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.StoreID != att.StoreID {
+		return nil, parkRepo.ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+// HandleNotification applies a gateway notification for a QRIS charge, the
+// same way the package payments are: signature and amount are checked, and
+// the status only moves along the allowed transitions. A paid charge closes
+// its ticket.
+func (s *parkingService) HandleNotification(body []byte) error {
+	res, err := s.gateway.VerifyNotification(body)
+	if err != nil {
+		return err
+	}
+	_, err = s.applyPayment(res)
+	return err
+}
+
+// applyPayment reports whether the charge's status changed.
+func (s *parkingService) applyPayment(res *gateway.Result) (bool, error) {
+	payment, err := s.parkRepo.PaymentByOrderID(res.OrderID)
+	if err != nil {
+		return false, err
+	}
+
+	if res.Amount != payment.Amount {
+		return false, paySvc.ErrAmountMismatch
+	}
+	if res.Status == payment.Status {
+		log.Info("duplicate qris notification ignored", "order_id", payment.OrderID, "status", payment.Status)
+		return false, nil
+	}
+	if !paySvc.CanTransition(payment.Status, res.Status) {
+		log.Warn("illegal qris transition ignored", "order_id", payment.OrderID, "from", payment.Status, "to", res.Status)
+		return false, nil
+	}
+
+	payment.MidtransID = res.TransactionID
+	applied, closed, err := s.parkRepo.ApplyPayment(payment, res.Status, res.Raw)
+	if err != nil {
+		return false, err
+	}
+	if !applied {
+		log.Info("qris notification already applied", "order_id", payment.OrderID)
+		return false, nil
+	}
+
+	if res.Status == paymentEntity.StatusPaid && !closed {
+		// the ticket was closed some other way while the QR was open
+		refund, err := s.gateway.Refund(payment.OrderID, payment.Amount, "ticket already closed")
+		if err != nil {
+			log.Error("qris paid for a closed ticket and the refund failed; refund the motorist",
+				"order_id", payment.OrderID, "ticket_id", payment.TicketID, "amount", payment.Amount, "error", err.Error())
+			return true, nil
+		}
+		log.Warn("qris paid for a ticket that was already closed; refunded",
+			"order_id", payment.OrderID, "ticket_id", payment.TicketID, "amount", payment.Amount, "status", refund.Status)
+		// record the refund now rather than waiting for its notification
+		if _, err := s.applyPayment(refund); err != nil {
+			log.Error("failed to record qris refund", "order_id", payment.OrderID, "error", err.Error())
+		}
+		return true, nil
+	}
+
+	log.Info("qris payment status updated", "order_id", payment.OrderID, "ticket_id", payment.TicketID, "status", res.Status)
+	return true, nil
+}
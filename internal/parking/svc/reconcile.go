@@ -0,0 +1,102 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	parkEntity "github.com/ghulammuzz/backend-parkerin/internal/parking/entity"
+	parkRepo "github.com/ghulammuzz/backend-parkerin/internal/parking/repo"
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	paySvc "github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+// reconcileBatch caps the charges one reconciliation run asks the gateway
+// about.
+const reconcileBatch = 100
+
+// ReconcilePayment asks the gateway for a QRIS charge's status and applies it
+// the way a notification would.
+func (s *parkingService) ReconcilePayment(orderID string) (*paymentEntity.Reconciliation, error) {
+	payment, err := s.parkRepo.PaymentByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reconcile(payment, 0)
+}
+
+// ReconcileStale returns the reconciliation job for QRIS charges, run like
+// the package payments one: charges still pending after age are checked
+// against the gateway, and those it does not know after abandonAfter are
+// expired.
+func ReconcileStale(parkRepo parkRepo.ParkingRepository, gw gateway.PaymentGateway, age, abandonAfter time.Duration) func(ctx context.Context) (interface{}, error) {
+	s := &parkingService{parkRepo: parkRepo, gateway: gw}
+	return func(ctx context.Context) (interface{}, error) {
+		report := &paymentEntity.ReconcileReport{}
+
+		payments, err := parkRepo.UnsettledPayments(time.Now().Add(-age).Unix(), reconcileBatch)
+		if err != nil {
+			return report, err
+		}
+		for i := range payments {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			rec, err := s.reconcile(&payments[i], abandonAfter)
+			if markErr := parkRepo.MarkPaymentReconciled(payments[i].ID); markErr != nil {
+				log.Error("failed to mark reconciled", "order_id", payments[i].OrderID, "error", markErr.Error())
+			}
+			if err != nil {
+				report.Failed++
+				log.Error("failed to reconcile qris charge", "order_id", payments[i].OrderID, "error", err.Error())
+				if rec == nil {
+					continue
+				}
+			}
+			report.Add(rec)
+			if rec.Mismatch() {
+				log.Warn("qris status mismatch", "order_id", rec.OrderID, "local", rec.Local, "gateway", rec.Gateway, "status", rec.Status)
+			}
+		}
+
+		if report.Updated > 0 || report.Failed > 0 {
+			log.Info("qris charges reconciled", "checked", report.Checked, "updated", report.Updated, "failed", report.Failed)
+		}
+		return report, nil
+	}
+}
+
+func (s *parkingService) reconcile(payment *parkEntity.Payment, abandonAfter time.Duration) (*paymentEntity.Reconciliation, error) {
+	rec := &paymentEntity.Reconciliation{OrderID: payment.OrderID, Local: payment.Status, Status: payment.Status}
+
+	res, err := s.gateway.Status(payment.OrderID)
+	switch {
+	case errors.Is(err, gateway.ErrOrderNotFound):
+		rec.Gateway = paymentEntity.GatewayNotFound
+		if abandonAfter == 0 || payment.Status != paymentEntity.StatusPending ||
+			time.Since(time.Unix(payment.CreatedAt, 0)) < abandonAfter {
+			return rec, nil
+		}
+		res = paySvc.Abandoned(payment.OrderID, payment.Amount)
+	case err != nil:
+		return nil, err
+	default:
+		rec.Gateway = res.Status
+		if res.Status == payment.Status && res.Amount == payment.Amount {
+			// still where we think it is; nothing to apply
+			return rec, nil
+		}
+	}
+
+	applied, err := s.applyPayment(res)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, err
+	}
+	if applied {
+		rec.Status = res.Status
+	}
+	return rec, nil
+}
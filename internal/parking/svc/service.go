@@ -0,0 +1,240 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ghulammuzz/backend-parkerin/config"
+	attEntity "github.com/ghulammuzz/backend-parkerin/internal/attendance/entity"
+	attRepo "github.com/ghulammuzz/backend-parkerin/internal/attendance/repo"
+	parkEntity "github.com/ghulammuzz/backend-parkerin/internal/parking/entity"
+	parkRepo "github.com/ghulammuzz/backend-parkerin/internal/parking/repo"
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/tariff"
+)
+
+var (
+	// ErrNotOnShift is returned to a tukang recording tickets without being
+	// checked in at the store.
+	ErrNotOnShift = errors.New("tukang is not checked in at this store")
+	// ErrKeyReused means an idempotency key was sent again with a different
+	// vehicle.
+	ErrKeyReused     = errors.New("idempotency key already used for another vehicle")
+	ErrInvalidTariff = errors.New("invalid tariff")
+	ErrInvalidQuote  = errors.New("invalid quote")
+)
+
+// jakarta is the zone night surcharges are read in (WIB, no DST).
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+type ParkingService interface {
+	Tariffs(storeID int) ([]tariff.Rule, error)
+	SetTariff(storeID int, rule *tariff.Rule) error
+	Quote(req *parkEntity.QuoteRequest) (*tariff.Breakdown, error)
+	Enter(tukangID int, req *parkEntity.EntryRequest) (*parkEntity.Ticket, bool, error)
+	Exit(tukangID, ticketID int, req *parkEntity.ExitRequest) (*parkEntity.Ticket, error)
+	OpenTickets(tukangID int) ([]parkEntity.Ticket, error)
+	DailyTotals(storeID int, period attEntity.Range) ([]parkEntity.DailyTotal, error)
+	ChargeQRIS(tukangID, ticketID int) (*parkEntity.Payment, error)
+	Payment(tukangID, paymentID int) (*parkEntity.Payment, error)
+	HandleNotification(body []byte) error
+	ReconcilePayment(orderID string) (*paymentEntity.Reconciliation, error)
+}
+
+type parkingService struct {
+	parkRepo parkRepo.ParkingRepository
+	attRepo  attRepo.AttendanceRepository
+	gateway  gateway.PaymentGateway
+	cfg      config.PaymentConfig
+}
+
+// Tariffs returns the store's rule for every vehicle type, the defaults where
+// it has not set one.
+func (s *parkingService) Tariffs(storeID int) ([]tariff.Rule, error) {
+	stored, err := s.parkRepo.Tariffs(storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := map[string]tariff.Rule{}
+	for vehicleType, rule := range parkEntity.DefaultTariffs {
+		byType[vehicleType] = rule
+	}
+	for _, rule := range stored {
+		byType[rule.VehicleType] = rule
+	}
+
+	rules := make([]tariff.Rule, 0, len(parkEntity.VehicleTypes))
+	for _, vehicleType := range parkEntity.VehicleTypes {
+		rules = append(rules, byType[vehicleType])
+	}
+	return rules, nil
+}
+
+func (s *parkingService) SetTariff(storeID int, rule *tariff.Rule) error {
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTariff, err)
+	}
+	return s.parkRepo.UpsertTariff(storeID, rule)
+}
+
+// Quote prices a stay under the store's tariff without recording anything.
+func (s *parkingService) Quote(req *parkEntity.QuoteRequest) (*tariff.Breakdown, error) {
+	exit := time.Now()
+	if req.ExitAt != 0 {
+		exit = time.Unix(req.ExitAt, 0)
+	}
+	return s.quote(req.StoreID, req.VehicleType, time.Unix(req.EntryAt, 0), exit)
+}
+
+// Enter opens a ticket at the store the tukang is checked in at. A retry with
+// the same idempotency key returns the ticket already recorded and false.
+func (s *parkingService) Enter(tukangID int, req *parkEntity.EntryRequest) (*parkEntity.Ticket, bool, error) {
+	att, err := s.onShift(tukangID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ticket := &parkEntity.Ticket{
+		StoreID:           att.StoreID,
+		IdempotencyKey:    req.IdempotencyKey,
+		Plate:             normalizePlate(req.Plate),
+		VehicleType:       req.VehicleType,
+		EntryTukangID:     tukangID,
+		EntryEmploymentID: att.EmploymentID,
+		EntryAt:           time.Now().Unix(),
+		EntryDeviceAt:     req.DeviceTime,
+	}
+	plate, vehicleType := ticket.Plate, ticket.VehicleType
+
+	created, err := s.parkRepo.Enter(ticket)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created && (ticket.Plate != plate || ticket.VehicleType != vehicleType) {
+		return nil, false, ErrKeyReused
+	}
+	return ticket, created, nil
+}
+
+// Exit closes the ticket and charges the store's tariff for the stay in cash. Any tukang on shift
+// at the store may record the exit and is credited with the fee. Exiting a
+// closed ticket again returns it unchanged, so retries are harmless.
+func (s *parkingService) Exit(tukangID, ticketID int, req *parkEntity.ExitRequest) (*parkEntity.Ticket, error) {
+	att, err := s.onShift(tukangID)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.parkRepo.Detail(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.StoreID != att.StoreID {
+		return nil, parkRepo.ErrTicketNotFound
+	}
+	if ticket.Status == parkEntity.StatusClosed {
+		return ticket, nil
+	}
+
+	now := time.Now()
+	breakdown, err := s.quote(ticket.StoreID, ticket.VehicleType, time.Unix(ticket.EntryAt, 0), now)
+	if err != nil {
+		return nil, err
+	}
+
+	fee := breakdown.Total
+	method := parkEntity.PaymentCash
+	exitAt := now.Unix()
+	ticket.Status = parkEntity.StatusClosed
+	ticket.ExitTukangID = &tukangID
+	ticket.ExitEmploymentID = &att.EmploymentID
+	ticket.ExitAt = &exitAt
+	ticket.ExitDeviceAt = &req.DeviceTime
+	ticket.Fee = &fee
+	ticket.PaymentMethod = &method
+	ticket.UpdatedAt = exitAt
+
+	ok, err := s.parkRepo.Exit(ticket)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// a concurrent retry closed it first; return what it recorded
+		return s.parkRepo.Detail(ticketID)
+	}
+
+	log.Info("parking ticket closed", "ticket_id", ticket.ID, "store_id", ticket.StoreID, "tukang_id", tukangID, "fee", fee)
+	return ticket, nil
+}
+
+func (s *parkingService) OpenTickets(tukangID int) ([]parkEntity.Ticket, error) {
+	att, err := s.onShift(tukangID)
+	if err != nil {
+		return nil, err
+	}
+	return s.parkRepo.ListOpen(att.StoreID)
+}
+
+func (s *parkingService) DailyTotals(storeID int, period attEntity.Range) ([]parkEntity.DailyTotal, error) {
+	return s.parkRepo.DailyTotals(storeID, period)
+}
+
+// quote prices a stay under the store's rule for the vehicle type.
+func (s *parkingService) quote(storeID int, vehicleType string, entry, exit time.Time) (*tariff.Breakdown, error) {
+	rules, err := s.Tariffs(storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, ok := parkEntity.DefaultTariffs[vehicleType]
+	for _, r := range rules {
+		if r.VehicleType == vehicleType {
+			rule, ok = r, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown vehicle type %q", ErrInvalidTariff, vehicleType)
+	}
+
+	breakdown, err := rule.Quote(entry, exit, jakarta)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuote, err)
+	}
+	return &breakdown, nil
+}
+
+func (s *parkingService) onShift(tukangID int) (*attEntity.Attendance, error) {
+	att, _, err := s.attRepo.Open(tukangID)
+	if err != nil {
+		if errors.Is(err, attRepo.ErrNotCheckedIn) {
+			return nil, ErrNotOnShift
+		}
+		return nil, err
+	}
+	return att, nil
+}
+
+// normalizePlate upper-cases the plate and drops spaces and dashes, so
+// "b 1234-xyz" and "B1234XYZ" are the same vehicle.
+func normalizePlate(plate string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(plate)))
+}
+
+func NewParkingService(parkRepo parkRepo.ParkingRepository, attRepo attRepo.AttendanceRepository, gw gateway.PaymentGateway, cfg config.PaymentConfig) ParkingService {
+	return &parkingService{
+		parkRepo: parkRepo,
+		attRepo:  attRepo,
+		gateway:  gw,
+		cfg:      cfg,
+	}
+}
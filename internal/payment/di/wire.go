@@ -3,17 +3,21 @@ package di
 import (
 	"database/sql"
 
+	pkgRepo "github.com/ghulammuzz/backend-parkerin/internal/packages/repo"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
 	"github.com/ghulammuzz/backend-parkerin/internal/payment/handler"
+	payRepo "github.com/ghulammuzz/backend-parkerin/internal/payment/repo"
 	paySvc "github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
 	userRepo "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
 	"github.com/google/wire"
-	"github.com/midtrans/midtrans-go/snap"
 )
 
-func InitializedPaymentServiceFake(sb *sql.DB, midtransClient *snap.Client) *handler.PaymentHandler {
+func InitializedPaymentServiceFake(sb *sql.DB, gw gateway.PaymentGateway) *handler.PaymentHandler {
 	wire.Build(
 		handler.NewPaymentHandler,
 		paySvc.NewPaymentService,
+		payRepo.NewPaymentRepository,
+		pkgRepo.NewPackageRepository,
 		userRepo.NewUserRepository,
 	)
 
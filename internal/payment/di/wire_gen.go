@@ -9,17 +9,21 @@ package di
 import (
 	"database/sql"
 
+	repo3 "github.com/ghulammuzz/backend-parkerin/internal/packages/repo"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
 	"github.com/ghulammuzz/backend-parkerin/internal/payment/handler"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
 	repo2 "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
-	"github.com/midtrans/midtrans-go/snap"
 )
 
 // Injectors from wire.go:
 
-func InitializedPaymentService(sb *sql.DB, midtransClient *snap.Client) *handler.PaymentHandler {
+func InitializedPaymentService(sb *sql.DB, gw gateway.PaymentGateway) *handler.PaymentHandler {
 	userRepository := repo2.NewUserRepository(sb)
-	paymentService := svc.NewPaymentService(userRepository, midtransClient)
+	paymentRepository := repo.NewPaymentRepository(sb)
+	packageRepository := repo3.NewPackageRepository(sb)
+	paymentService := svc.NewPaymentService(userRepository, paymentRepository, packageRepository, gw)
 	paymentHandler := handler.NewPaymentHandler(paymentService)
 	return paymentHandler
 }
@@ -2,8 +2,19 @@ package entity
 
 import "time"
 
+const (
+	StatusPending   = "pending"
+	StatusChallenge = "challenge"
+	StatusPaid      = "paid"
+	StatusFailed    = "failed"
+	StatusExpired   = "expired"
+	StatusCancelled = "cancelled"
+	StatusRefunded  = "refunded"
+)
+
 type Transaction struct {
 	ID              int
+	OrderID         string
 	UserID          int
 	PackageID       int
 	Status          string
@@ -30,3 +41,56 @@ type CreateTransactionResponse struct {
 	Token string
 	URL   string
 }
+
+// NotificationRequest is the subset of the Midtrans HTTP notification body we act on.
+type NotificationRequest struct {
+	OrderID           string `json:"order_id"`
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	TransactionTime   string `json:"transaction_time"`
+	FraudStatus       string `json:"fraud_status"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	PaymentType       string `json:"payment_type"`
+	SignatureKey      string `json:"signature_key"`
+}
+
+// GatewayNotFound is the gateway status of an order the gateway does not know.
+const GatewayNotFound = "not_found"
+
+// Reconciliation is one order checked against the gateway's status API.
+// Status is the local status afterwards; Error says why what the gateway
+// reported was not applied.
+type Reconciliation struct {
+	OrderID string `json:"order_id"`
+	Local   string `json:"local_status"`
+	Gateway string `json:"gateway_status"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Mismatch reports whether the gateway disagreed with us, on the status or
+// on anything that kept its status from being applied.
+func (r *Reconciliation) Mismatch() bool {
+	return r.Gateway != r.Local || r.Error != ""
+}
+
+// ReconcileReport is what one reconciliation run did. Mismatches lists every
+// order the gateway disagreed on, applied or not.
+type ReconcileReport struct {
+	Checked    int              `json:"checked"`
+	Updated    int              `json:"updated"`
+	Failed     int              `json:"failed"`
+	Mismatches []Reconciliation `json:"mismatches,omitempty"`
+}
+
+// Add records one checked order.
+func (r *ReconcileReport) Add(rec *Reconciliation) {
+	r.Checked++
+	if rec.Status != rec.Local {
+		r.Updated++
+	}
+	if rec.Mismatch() {
+		r.Mismatches = append(r.Mismatches, *rec)
+	}
+}
@@ -0,0 +1,185 @@
+package fake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+// What the fake gateway does with a new charge on its own.
+const (
+	OutcomeSettlement = "settlement"
+	OutcomeExpire     = "expire"
+	OutcomeDeny       = "deny"
+	// OutcomeManual leaves charges pending until Settle, Expire or Deny.
+	OutcomeManual = "manual"
+)
+
+// Gateway is an in-process gateway.PaymentGateway. Charges live in memory and
+// are completed with the same signed Midtrans-style notifications Midtrans
+// would send, posted to the charge's notification URL, so the webhooks run
+// unchanged.
+type Gateway struct {
+	notifier *Notifier
+	outcome  string
+	delay    time.Duration
+
+	mu      sync.Mutex
+	charges map[string]*charge
+}
+
+// NewGateway completes every new charge with outcome after delay, posting the
+// notification to notificationURL unless the charge names its own.
+func NewGateway(serverKey, notificationURL, outcome string, delay time.Duration) (*Gateway, error) {
+	switch outcome {
+	case OutcomeSettlement, OutcomeExpire, OutcomeDeny, OutcomeManual:
+	default:
+		return nil, fmt.Errorf("unknown fake payment outcome %q", outcome)
+	}
+	return &Gateway{
+		notifier: NewNotifier(notificationURL, serverKey),
+		outcome:  outcome,
+		delay:    delay,
+		charges:  map[string]*charge{},
+	}, nil
+}
+
+func (g *Gateway) CreateCharge(req *gateway.ChargeRequest) (*gateway.Charge, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.charges[req.OrderID]; ok {
+		return nil, fmt.Errorf("%w: order %s already exists", gateway.ErrGateway, req.OrderID)
+	}
+	c := &charge{
+		orderID:   req.OrderID,
+		amount:    int(req.Amount),
+		status:    "pending",
+		notifyURL: req.NotificationURL,
+		createdAt: time.Now(),
+	}
+	g.charges[req.OrderID] = c
+
+	result := &gateway.Charge{
+		OrderID:       req.OrderID,
+		TransactionID: fmt.Sprintf("fake-%s", req.OrderID),
+	}
+	switch req.Method {
+	case gateway.MethodSnap:
+		result.Token = fmt.Sprintf("fake-token-%s", req.OrderID)
+		result.RedirectURL = fmt.Sprintf("https://fake-payment.local/snap/%s", req.OrderID)
+	case gateway.MethodQRIS:
+		result.QRString = fmt.Sprintf("00020101021226620014COM.FAKE.QRIS%s5204599953033605405%d6304", req.OrderID, req.Amount)
+	default:
+		delete(g.charges, req.OrderID)
+		return nil, fmt.Errorf("%w: unsupported method %q", gateway.ErrGateway, req.Method)
+	}
+	if req.Expiry > 0 {
+		expiresAt := c.createdAt.Add(req.Expiry)
+		result.ExpiresAt = &expiresAt
+	}
+
+	if g.outcome != OutcomeManual {
+		orderID, outcome := req.OrderID, g.outcome
+		time.AfterFunc(g.delay, func() {
+			if _, err := g.Complete(orderID, outcome); err != nil {
+				log.Error("fake payment notification failed", "order_id", orderID, "error", err.Error())
+			}
+		})
+	}
+	return result, nil
+}
+
+func (g *Gateway) Status(orderID string) (*gateway.Result, error) {
+	g.mu.Lock()
+	c, ok := g.charges[orderID]
+	g.mu.Unlock()
+	if !ok {
+		return nil, gateway.ErrOrderNotFound
+	}
+	return g.result(c)
+}
+
+// Cancel cancels a pending charge and notifies it like Midtrans does.
+func (g *Gateway) Cancel(orderID string) (*gateway.Result, error) {
+	return g.move(orderID, "pending", "cancel")
+}
+
+// Refund refunds a settled charge in full and notifies it; amount and reason
+// are not checked.
+func (g *Gateway) Refund(orderID string, amount int64, reason string) (*gateway.Result, error) {
+	return g.move(orderID, "settlement", "refund")
+}
+
+func (g *Gateway) VerifyNotification(body []byte) (*gateway.Result, error) {
+	return gateway.VerifyMidtransNotification(body, g.notifier.ServerKey)
+}
+
+// Settle simulates the customer paying.
+func (g *Gateway) Settle(orderID string) (int, error) {
+	return g.Complete(orderID, OutcomeSettlement)
+}
+
+// Expire simulates the charge running out unpaid.
+func (g *Gateway) Expire(orderID string) (int, error) {
+	return g.Complete(orderID, OutcomeExpire)
+}
+
+// Deny simulates the provider rejecting the payment.
+func (g *Gateway) Deny(orderID string) (int, error) {
+	return g.Complete(orderID, OutcomeDeny)
+}
+
+// Complete moves a charge to transactionStatus and posts its notification,
+// returning the webhook's HTTP status.
+func (g *Gateway) Complete(orderID, transactionStatus string) (int, error) {
+	g.mu.Lock()
+	c, ok := g.charges[orderID]
+	if ok {
+		c.status = transactionStatus
+	}
+	g.mu.Unlock()
+	if !ok {
+		return 0, gateway.ErrOrderNotFound
+	}
+	return notify(g.notifier, c, transactionStatus)
+}
+
+func (g *Gateway) move(orderID, from, to string) (*gateway.Result, error) {
+	g.mu.Lock()
+	c, ok := g.charges[orderID]
+	if ok && c.status != from {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("%w: order %s is %s", gateway.ErrGateway, orderID, c.status)
+	}
+	if ok {
+		c.status = to
+	}
+	g.mu.Unlock()
+	if !ok {
+		return nil, gateway.ErrOrderNotFound
+	}
+
+	if _, err := notify(g.notifier, c, to); err != nil {
+		log.Error("fake payment notification failed", "order_id", orderID, "error", err.Error())
+	}
+	return g.result(c)
+}
+
+func (g *Gateway) result(c *charge) (*gateway.Result, error) {
+	g.mu.Lock()
+	status := c.status
+	g.mu.Unlock()
+
+	return &gateway.Result{
+		OrderID:       c.orderID,
+		TransactionID: fmt.Sprintf("fake-%s", c.orderID),
+		Status:        gateway.MapMidtransStatus(status, "accept"),
+		Amount:        int64(c.amount),
+		PaymentType:   "qris",
+		Time:          c.createdAt,
+	}, nil
+}
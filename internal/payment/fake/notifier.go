@@ -0,0 +1,77 @@
+// Package fake holds local stand-ins for Midtrans so the payment flow can be
+// exercised without the sandbox.
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+)
+
+var statusCodes = map[string]string{
+	"capture":    "200",
+	"settlement": "200",
+	"pending":    "201",
+	"deny":       "202",
+	"cancel":     "202",
+	"expire":     "407",
+	"refund":     "200",
+}
+
+// Notifier posts signed Midtrans-style notifications to a webhook URL.
+type Notifier struct {
+	URL       string
+	ServerKey string
+	Client    *http.Client
+}
+
+func NewNotifier(url, serverKey string) *Notifier {
+	return &Notifier{URL: url, ServerKey: serverKey, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Build returns a correctly signed notification body.
+func (n *Notifier) Build(orderID, transactionStatus, fraudStatus string, amount int) paymentEntity.NotificationRequest {
+	statusCode, ok := statusCodes[transactionStatus]
+	if !ok {
+		statusCode = "200"
+	}
+	grossAmount := fmt.Sprintf("%d.00", amount)
+
+	return paymentEntity.NotificationRequest{
+		OrderID:           orderID,
+		TransactionID:     fmt.Sprintf("fake-%s", orderID),
+		TransactionStatus: transactionStatus,
+		TransactionTime:   time.Now().In(time.FixedZone("WIB", 7*60*60)).Format("2006-01-02 15:04:05"),
+		FraudStatus:       fraudStatus,
+		StatusCode:        statusCode,
+		GrossAmount:       grossAmount,
+		PaymentType:       "qris",
+		SignatureKey:      gateway.SignatureKey(orderID, statusCode, grossAmount, n.ServerKey),
+	}
+}
+
+// Send posts notif to the webhook and returns the HTTP status code.
+func (n *Notifier) Send(notif paymentEntity.NotificationRequest) (int, error) {
+	body, err := json.Marshal(notif)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// Notify builds and sends a notification in one call.
+func (n *Notifier) Notify(orderID, transactionStatus, fraudStatus string, amount int) (int, error) {
+	return n.Send(n.Build(orderID, transactionStatus, fraudStatus, amount))
+}
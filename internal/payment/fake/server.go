@@ -0,0 +1,246 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server stands in for the Midtrans API. It accepts Snap transactions and
+// QRIS charges, answers status lookups, cancels and refunds and, when a charge
+// is completed, posts the signed notification Midtrans would send. Point the
+// app at it with MIDTRANS_BASE_URL.
+type Server struct {
+	ServerKey string
+	// Notifier posts the notifications; a charge created with an
+	// X-Override-Notification header is notified there instead.
+	Notifier *Notifier
+
+	mu      sync.Mutex
+	charges map[string]*charge
+}
+
+type charge struct {
+	orderID   string
+	amount    int
+	status    string
+	notifyURL string
+	createdAt time.Time
+}
+
+func NewServer(serverKey, webhookURL string) *Server {
+	return &Server{
+		ServerKey: serverKey,
+		Notifier:  NewNotifier(webhookURL, serverKey),
+		charges:   map[string]*charge{},
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if key, _, ok := r.BasicAuth(); !ok || key != s.ServerKey {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"status_code": "401", "status_message": "Access denied"})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/snap/v1/transactions":
+		s.snap(w, r)
+	case r.Method == http.MethodPost && path == "charge":
+		s.charge(w, r)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/status"):
+		s.status(w, strings.TrimSuffix(path, "/status"))
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/cancel"):
+		s.move(w, strings.TrimSuffix(path, "/cancel"), "pending", "cancel")
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/refund"):
+		s.move(w, strings.TrimSuffix(path, "/refund"), "settlement", "refund")
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"status_code": "404", "status_message": "Not found"})
+	}
+}
+
+func (s *Server) snap(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TransactionDetails struct {
+			OrderID     string `json:"order_id"`
+			GrossAmount int    `json:"gross_amount"`
+		} `json:"transaction_details"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TransactionDetails.OrderID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error_messages": []string{"invalid request"}})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orderID := req.TransactionDetails.OrderID
+	if _, ok := s.charges[orderID]; ok {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error_messages": []string{"transaction_details.order_id has already been taken"}})
+		return
+	}
+	s.charges[orderID] = &charge{
+		orderID:   orderID,
+		amount:    req.TransactionDetails.GrossAmount,
+		status:    "pending",
+		notifyURL: r.Header.Get("X-Override-Notification"),
+		createdAt: time.Now(),
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"token":        fmt.Sprintf("fake-token-%s", orderID),
+		"redirect_url": fmt.Sprintf("http://%s/snap/v2/vtweb/%s", r.Host, orderID),
+	})
+}
+
+func (s *Server) charge(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PaymentType        string `json:"payment_type"`
+		TransactionDetails struct {
+			OrderID     string `json:"order_id"`
+			GrossAmount int    `json:"gross_amount"`
+		} `json:"transaction_details"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TransactionDetails.OrderID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"status_code": "400", "status_message": "Invalid request"})
+		return
+	}
+	if req.PaymentType != "qris" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"status_code": "400", "status_message": "Only qris is simulated"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orderID := req.TransactionDetails.OrderID
+	if _, ok := s.charges[orderID]; ok {
+		writeJSON(w, http.StatusConflict, map[string]string{"status_code": "406", "status_message": "The request could not be completed due to a conflict with the current state of the target resource"})
+		return
+	}
+	c := &charge{
+		orderID:   orderID,
+		amount:    req.TransactionDetails.GrossAmount,
+		status:    "pending",
+		notifyURL: r.Header.Get("X-Override-Notification"),
+		createdAt: time.Now(),
+	}
+	s.charges[orderID] = c
+
+	body := s.body(c)
+	body["status_message"] = "QRIS transaction is created"
+	body["qr_string"] = fmt.Sprintf("00020101021226620014COM.FAKE.QRIS%s5204599953033605405%d6304", orderID, c.amount)
+	body["expiry_time"] = c.createdAt.Add(15 * time.Minute).In(wib).Format(timeLayout)
+	body["actions"] = []map[string]string{{
+		"name":   "generate-qr-code",
+		"method": "GET",
+		"url":    fmt.Sprintf("http://%s/v2/qris/%s/qr-code", r.Host, orderID),
+	}}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) status(w http.ResponseWriter, orderID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.charges[orderID]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"status_code": "404", "status_message": "Transaction doesn't exist."})
+		return
+	}
+	body := s.body(c)
+	body["status_message"] = "Success, transaction is found"
+	body["signature_key"] = s.Notifier.Build(c.orderID, c.status, "accept", c.amount).SignatureKey
+	writeJSON(w, http.StatusOK, body)
+}
+
+// move changes a charge in status from to status to and notifies it.
+func (s *Server) move(w http.ResponseWriter, orderID, from, to string) {
+	s.mu.Lock()
+	c, ok := s.charges[orderID]
+	if !ok {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]string{"status_code": "404", "status_message": "Transaction doesn't exist."})
+		return
+	}
+	if c.status != from {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{"status_code": "412", "status_message": "Merchant cannot modify the status of the transaction"})
+		return
+	}
+	c.status = to
+	body := s.body(c)
+	s.mu.Unlock()
+
+	go notify(s.Notifier, c, to)
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) body(c *charge) map[string]interface{} {
+	statusCode, ok := statusCodes[c.status]
+	if !ok {
+		statusCode = "200"
+	}
+	return map[string]interface{}{
+		"status_code":        statusCode,
+		"transaction_id":     fmt.Sprintf("fake-%s", c.orderID),
+		"order_id":           c.orderID,
+		"gross_amount":       fmt.Sprintf("%d.00", c.amount),
+		"currency":           "IDR",
+		"payment_type":       "qris",
+		"transaction_time":   c.createdAt.In(wib).Format(timeLayout),
+		"transaction_status": c.status,
+		"fraud_status":       "accept",
+		"acquirer":           "gopay",
+	}
+}
+
+// Complete moves a charge to transactionStatus ("settlement", "expire",
+// "deny", "cancel" ...) and notifies the webhook, returning its HTTP status.
+func (s *Server) Complete(orderID, transactionStatus string) (int, error) {
+	s.mu.Lock()
+	c, ok := s.charges[orderID]
+	if ok {
+		c.status = transactionStatus
+	}
+	s.mu.Unlock()
+	if !ok {
+		return 0, fmt.Errorf("unknown order %q", orderID)
+	}
+
+	return notify(s.Notifier, c, transactionStatus)
+}
+
+// notify posts the notification for c moving to transactionStatus, to the
+// charge's own notification URL when it has one.
+func notify(n *Notifier, c *charge, transactionStatus string) (int, error) {
+	notif := n.Build(c.orderID, transactionStatus, "accept", c.amount)
+	if c.notifyURL == "" {
+		return n.Send(notif)
+	}
+	override := *n
+	override.URL = c.notifyURL
+	return override.Send(notif)
+}
+
+// Settle simulates the motorist paying the QR.
+func (s *Server) Settle(orderID string) (int, error) {
+	return s.Complete(orderID, "settlement")
+}
+
+// Expire simulates the QR running out unpaid.
+func (s *Server) Expire(orderID string) (int, error) {
+	return s.Complete(orderID, "expire")
+}
+
+const timeLayout = "2006-01-02 15:04:05"
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,84 @@
+// Package gateway hides the payment provider behind PaymentGateway so the
+// payment flows do not depend on Midtrans directly.
+package gateway
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature key")
+	ErrUnknownStatus    = errors.New("unknown transaction status")
+	// ErrOrderNotFound is returned when the provider has no transaction for
+	// the order.
+	ErrOrderNotFound = errors.New("order not found at the payment gateway")
+	ErrGateway       = errors.New("payment gateway error")
+)
+
+const (
+	// MethodSnap lets the customer pick a method on the provider's page.
+	MethodSnap = "snap"
+	// MethodQRIS returns a dynamic QR to show the customer.
+	MethodQRIS = "qris"
+)
+
+type Item struct {
+	ID    string
+	Name  string
+	Price int64
+	Qty   int32
+}
+
+type Customer struct {
+	Name  string
+	Phone string
+}
+
+// ChargeRequest asks the provider to collect Amount (Rupiah) for OrderID.
+// NotificationURL, when set, replaces the account's notification URL for this
+// charge; Expiry, when set, limits how long it can be paid.
+type ChargeRequest struct {
+	OrderID         string
+	Amount          int64
+	Method          string
+	Items           []Item
+	Customer        *Customer
+	NotificationURL string
+	Expiry          time.Duration
+}
+
+// Charge is what the customer needs to pay: a redirect for snap, a QR for
+// qris.
+type Charge struct {
+	OrderID       string
+	TransactionID string
+	Token         string
+	RedirectURL   string
+	QRString      string
+	QRURL         string
+	ExpiresAt     *time.Time
+}
+
+// Result is the state of a transaction at the provider, from a status lookup,
+// cancel, refund or notification. Status is one of the payment entity
+// statuses; Raw is the provider's payload.
+type Result struct {
+	OrderID       string
+	TransactionID string
+	Status        string
+	Amount        int64
+	PaymentType   string
+	Time          time.Time
+	Raw           string
+}
+
+type PaymentGateway interface {
+	CreateCharge(req *ChargeRequest) (*Charge, error)
+	Status(orderID string) (*Result, error)
+	Cancel(orderID string) (*Result, error)
+	Refund(orderID string, amount int64, reason string) (*Result, error)
+	// VerifyNotification checks a notification body came from the provider
+	// and returns what it reports.
+	VerifyNotification(body []byte) (*Result, error)
+}
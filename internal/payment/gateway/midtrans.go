@@ -0,0 +1,306 @@
+package gateway
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+// jakarta is the zone Midtrans reports times in (WIB, no DST).
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+const midtransTimeLayout = "2006-01-02 15:04:05"
+
+type midtransGateway struct {
+	serverKey string
+	snap      snap.Client
+	core      coreapi.Client
+}
+
+// NewMidtrans talks to the Midtrans sandbox, or to production when production
+// is set. A non-empty baseURL sends every request to that server instead.
+// Snap serves MethodSnap, the Core API everything else.
+func NewMidtrans(serverKey string, production bool, baseURL string) PaymentGateway {
+	env := midtrans.Sandbox
+	if production {
+		env = midtrans.Production
+	}
+
+	g := &midtransGateway{serverKey: serverKey}
+	g.snap.New(serverKey, env)
+	g.core.New(serverKey, env)
+
+	if target, err := url.Parse(baseURL); err == nil && target.Host != "" {
+		httpClient := &midtrans.HttpClientImplementation{
+			HttpClient: &http.Client{
+				Timeout:   midtrans.DefaultHttpTimeout,
+				Transport: rewriteHost{target: target, next: http.DefaultTransport},
+			},
+			Logger: midtrans.GetDefaultLogger(env),
+		}
+		g.snap.HttpClient = httpClient
+		g.core.HttpClient = httpClient
+	}
+	return g
+}
+
+func (g *midtransGateway) CreateCharge(req *ChargeRequest) (*Charge, error) {
+	options := &midtrans.ConfigOptions{}
+	if req.NotificationURL != "" {
+		options.SetPaymentOverrideNotification(req.NotificationURL)
+	}
+
+	items := make([]midtrans.ItemDetails, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, midtrans.ItemDetails{ID: item.ID, Name: item.Name, Price: item.Price, Qty: item.Qty})
+	}
+	details := midtrans.TransactionDetails{OrderID: req.OrderID, GrossAmt: req.Amount}
+
+	switch req.Method {
+	case MethodSnap:
+		chargeReq := &snap.Request{
+			TransactionDetails: details,
+			Items:              &items,
+			CreditCard:         &snap.CreditCardDetails{Secure: true},
+			EnabledPayments:    snap.AllSnapPaymentType,
+		}
+		if req.Customer != nil {
+			address := &midtrans.CustomerAddress{FName: req.Customer.Name, Phone: req.Customer.Phone, CountryCode: "IDN"}
+			chargeReq.CustomerDetail = &midtrans.CustomerDetails{
+				FName:    req.Customer.Name,
+				Phone:    req.Customer.Phone,
+				BillAddr: address,
+				ShipAddr: address,
+			}
+		}
+		if req.Expiry > 0 {
+			chargeReq.Expiry = &snap.ExpiryDetails{Unit: "minute", Duration: int64(req.Expiry / time.Minute)}
+		}
+
+		client := g.snap
+		client.Options = options
+		res, errResp := client.CreateTransaction(chargeReq)
+		if errResp != nil {
+			return nil, gatewayError(errResp)
+		}
+		return &Charge{OrderID: req.OrderID, Token: res.Token, RedirectURL: res.RedirectURL}, nil
+
+	case MethodQRIS:
+		chargeReq := &coreapi.ChargeReq{
+			PaymentType:        coreapi.PaymentTypeQris,
+			TransactionDetails: details,
+			Items:              &items,
+		}
+		if req.Expiry > 0 {
+			chargeReq.CustomExpiry = &coreapi.CustomExpiry{ExpiryDuration: int(req.Expiry / time.Minute), Unit: "minute"}
+		}
+
+		client := g.core
+		client.Options = options
+		res, errResp := client.ChargeTransaction(chargeReq)
+		if errResp != nil {
+			return nil, gatewayError(errResp)
+		}
+		if failed(res.StatusCode) {
+			return nil, fmt.Errorf("%w: %s %s", ErrGateway, res.StatusCode, res.StatusMessage)
+		}
+
+		charge := &Charge{OrderID: req.OrderID, TransactionID: res.TransactionID, QRString: res.QRString}
+		for _, action := range res.Actions {
+			if action.Name == "generate-qr-code" {
+				charge.QRURL = action.URL
+			}
+		}
+		if t, err := time.ParseInLocation(midtransTimeLayout, res.ExpiryTime, jakarta); err == nil {
+			charge.ExpiresAt = &t
+		}
+		return charge, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported method %q", ErrGateway, req.Method)
+}
+
+func (g *midtransGateway) Status(orderID string) (*Result, error) {
+	res, errResp := g.core.CheckTransaction(orderID)
+	if errResp != nil {
+		if errResp.GetStatusCode() == http.StatusNotFound {
+			return nil, ErrOrderNotFound
+		}
+		return nil, gatewayError(errResp)
+	}
+	if res.StatusCode == "404" {
+		return nil, ErrOrderNotFound
+	}
+	if failed(res.StatusCode) {
+		return nil, fmt.Errorf("%w: %s %s", ErrGateway, res.StatusCode, res.StatusMessage)
+	}
+
+	raw, _ := json.Marshal(res)
+	return result(res.OrderID, res.TransactionID, res.TransactionStatus, res.FraudStatus, res.GrossAmount,
+		res.PaymentType, res.TransactionTime, raw)
+}
+
+func (g *midtransGateway) Cancel(orderID string) (*Result, error) {
+	res, errResp := g.core.CancelTransaction(orderID)
+	if errResp != nil {
+		return nil, gatewayError(errResp)
+	}
+	if failed(res.StatusCode) {
+		return nil, fmt.Errorf("%w: %s %s", ErrGateway, res.StatusCode, res.StatusMessage)
+	}
+
+	raw, _ := json.Marshal(res)
+	return result(res.OrderID, res.TransactionID, res.TransactionStatus, res.FraudStatus, res.GrossAmount,
+		res.PaymentType, res.TransactionTime, raw)
+}
+
+func (g *midtransGateway) Refund(orderID string, amount int64, reason string) (*Result, error) {
+	res, errResp := g.core.RefundTransaction(orderID, &coreapi.RefundReq{
+		RefundKey: fmt.Sprintf("%s-refund", orderID),
+		Amount:    amount,
+		Reason:    reason,
+	})
+	if errResp != nil {
+		return nil, gatewayError(errResp)
+	}
+	if failed(res.StatusCode) {
+		return nil, fmt.Errorf("%w: %s %s", ErrGateway, res.StatusCode, res.StatusMessage)
+	}
+
+	raw, _ := json.Marshal(res)
+	return result(res.OrderID, res.TransactionID, res.TransactionStatus, res.FraudStatus, res.GrossAmount,
+		res.PaymentType, res.TransactionTime, raw)
+}
+
+func (g *midtransGateway) VerifyNotification(body []byte) (*Result, error) {
+	return VerifyMidtransNotification(body, g.serverKey)
+}
+
+// VerifyMidtransNotification checks the signature of a Midtrans HTTP
+// notification against serverKey and returns what it reports. Without a key
+// the signature is computable by anyone, so nothing verifies.
+func VerifyMidtransNotification(body []byte, serverKey string) (*Result, error) {
+	if serverKey == "" {
+		return nil, ErrInvalidSignature
+	}
+
+	var notif paymentEntity.NotificationRequest
+	if err := json.Unmarshal(body, &notif); err != nil {
+		return nil, fmt.Errorf("invalid notification payload: %w", err)
+	}
+
+	expected := SignatureKey(notif.OrderID, notif.StatusCode, notif.GrossAmount, serverKey)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(notif.SignatureKey)) != 1 {
+		return nil, ErrInvalidSignature
+	}
+
+	return result(notif.OrderID, notif.TransactionID, notif.TransactionStatus, notif.FraudStatus, notif.GrossAmount,
+		notif.PaymentType, notif.TransactionTime, body)
+}
+
+// SignatureKey computes the Midtrans notification signature:
+// SHA512(order_id + status_code + gross_amount + server_key).
+func SignatureKey(orderID, statusCode, grossAmount, serverKey string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// MapMidtransStatus converts a Midtrans transaction_status/fraud_status pair
+// into our transaction status. Unknown values return "". A partial refund
+// leaves the order paid: the rest of the payment still stands, so it must not
+// revoke what was bought.
+func MapMidtransStatus(transactionStatus, fraudStatus string) string {
+	switch transactionStatus {
+	case "capture":
+		switch fraudStatus {
+		case "challenge":
+			return paymentEntity.StatusChallenge
+		case "deny":
+			return paymentEntity.StatusFailed
+		default:
+			return paymentEntity.StatusPaid
+		}
+	case "settlement":
+		return paymentEntity.StatusPaid
+	case "pending", "authorize":
+		return paymentEntity.StatusPending
+	case "deny", "failure":
+		return paymentEntity.StatusFailed
+	case "cancel":
+		return paymentEntity.StatusCancelled
+	case "expire":
+		return paymentEntity.StatusExpired
+	case "partial_refund":
+		return paymentEntity.StatusPaid
+	case "refund":
+		return paymentEntity.StatusRefunded
+	}
+	return ""
+}
+
+func result(orderID, transactionID, transactionStatus, fraudStatus, grossAmount, paymentType, transactionTime string, raw []byte) (*Result, error) {
+	status := MapMidtransStatus(transactionStatus, fraudStatus)
+	if status == "" {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStatus, transactionStatus)
+	}
+	if transactionStatus == "partial_refund" {
+		log.Warn("partial refund kept as paid", "order_id", orderID, "transaction_id", transactionID)
+	}
+
+	amount, err := strconv.ParseFloat(grossAmount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid gross amount %q", ErrGateway, grossAmount)
+	}
+
+	at := time.Now()
+	if t, err := time.ParseInLocation(midtransTimeLayout, transactionTime, jakarta); err == nil {
+		at = t
+	}
+
+	return &Result{
+		OrderID:       orderID,
+		TransactionID: transactionID,
+		Status:        status,
+		Amount:        int64(amount),
+		PaymentType:   paymentType,
+		Time:          at,
+		Raw:           string(raw),
+	}, nil
+}
+
+// failed reports a Midtrans status_code outside 2xx; the API answers some
+// errors with HTTP 200 and the code in the body.
+func failed(statusCode string) bool {
+	return statusCode != "" && !strings.HasPrefix(statusCode, "2")
+}
+
+func gatewayError(errResp *midtrans.Error) error {
+	return fmt.Errorf("%w: %s", ErrGateway, errResp.GetMessage())
+}
+
+// rewriteHost points requests built for the Midtrans API at another server.
+type rewriteHost struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t rewriteHost) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.Host = t.target.Host
+	return t.next.RoundTrip(req)
+}
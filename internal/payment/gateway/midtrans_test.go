@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"testing"
+
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+)
+
+func TestMapMidtransStatus(t *testing.T) {
+	tests := []struct {
+		transaction, fraud string
+		want               string
+	}{
+		{"capture", "accept", paymentEntity.StatusPaid},
+		{"capture", "challenge", paymentEntity.StatusChallenge},
+		{"capture", "deny", paymentEntity.StatusFailed},
+		{"settlement", "", paymentEntity.StatusPaid},
+		{"pending", "", paymentEntity.StatusPending},
+		{"authorize", "", paymentEntity.StatusPending},
+		{"deny", "", paymentEntity.StatusFailed},
+		{"failure", "", paymentEntity.StatusFailed},
+		{"cancel", "", paymentEntity.StatusCancelled},
+		{"expire", "", paymentEntity.StatusExpired},
+		{"refund", "", paymentEntity.StatusRefunded},
+		{"partial_refund", "", paymentEntity.StatusPaid},
+		{"chargeback", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := MapMidtransStatus(tt.transaction, tt.fraud); got != tt.want {
+			t.Errorf("MapMidtransStatus(%q, %q) = %q, want %q", tt.transaction, tt.fraud, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"database/sql"
+	"net"
+	"testing"
+
+	pkgRepo "github.com/ghulammuzz/backend-parkerin/internal/packages/repo"
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/fake"
+	payRepo "github.com/ghulammuzz/backend-parkerin/internal/payment/repo"
+	payService "github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
+	"github.com/ghulammuzz/backend-parkerin/internal/testdb"
+	userRepo "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
+	"github.com/gofiber/fiber/v2"
+)
+
+// packageDaily is "Paket Harian", seeded for stores by migration 0003.
+const packageDaily = 1
+
+// TestFakeGatewayFlow buys a package through the fake gateway and completes
+// the charge with its signed notification, posted to the real webhook.
+func TestFakeGatewayFlow(t *testing.T) {
+	cases := []struct {
+		name       string
+		complete   func(*fake.Gateway, string) (int, error)
+		status     string
+		subscribed bool
+	}{
+		{"settlement", (*fake.Gateway).Settle, paymentEntity.StatusPaid, true},
+		{"expire", (*fake.Gateway).Expire, paymentEntity.StatusExpired, false},
+		{"deny", (*fake.Gateway).Deny, paymentEntity.StatusFailed, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := testdb.Open(t)
+			storeID, userID := testdb.Store(t, db)
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			gw, err := fake.NewGateway(serverKey, "http://"+ln.Addr().String()+"/payment/notification", fake.OutcomeManual, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			svc := payService.NewPaymentService(userRepo.NewUserRepository(db), payRepo.NewPaymentRepository(db), pkgRepo.NewPackageRepository(db), gw)
+			app := fiber.New(fiber.Config{DisableStartupMessage: true})
+			NewPaymentHandler(svc).Router(app)
+			go app.Listener(ln)
+			t.Cleanup(func() { app.Shutdown() })
+
+			res, err := svc.CreateTransaction(userID, packageDaily)
+			if err != nil {
+				t.Fatalf("create transaction: %v", err)
+			}
+			if res.URL == "" || res.Token == "" {
+				t.Fatalf("charge has no payment page: %+v", res)
+			}
+
+			var trxID int
+			var orderID string
+			if err := db.QueryRow(`SELECT id, order_id FROM transactions WHERE user_id = $1`, userID).Scan(&trxID, &orderID); err != nil {
+				t.Fatal(err)
+			}
+
+			code, err := tc.complete(gw, orderID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != fiber.StatusOK {
+				t.Fatalf("webhook answered %d, want 200", code)
+			}
+
+			var status string
+			if err := db.QueryRow(`SELECT status FROM transactions WHERE id = $1`, trxID).Scan(&status); err != nil {
+				t.Fatal(err)
+			}
+			if status != tc.status {
+				t.Fatalf("transaction is %s, want %s", status, tc.status)
+			}
+			if got := subscribed(t, db, storeID, trxID); got != tc.subscribed {
+				t.Fatalf("subscribed = %v, want %v", got, tc.subscribed)
+			}
+		})
+	}
+}
+
+func subscribed(t *testing.T, db *sql.DB, storeID, trxID int) bool {
+	t.Helper()
+
+	var ok bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM store_subscriptions
+			WHERE store_id = $1 AND transaction_id = $2 AND package_id = $3 AND ends_at > starts_at
+		)
+	`, storeID, trxID, packageDaily).Scan(&ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"log/slog"
 	"strconv"
 
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	payRepo "github.com/ghulammuzz/backend-parkerin/internal/payment/repo"
 	payService "github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
 	"github.com/ghulammuzz/backend-parkerin/pkg/log"
 	"github.com/ghulammuzz/backend-parkerin/pkg/response"
@@ -14,28 +20,25 @@ type PaymentHandler struct {
 }
 
 func (h PaymentHandler) Router(r fiber.Router) {
-	// r.Post("/pay/:packageID", middleware.JWTProtected(), h.CreateTransaction)
-	r.Post("/pay/:packageID", h.CreateTransaction)
+	r.Post("/pay/:packageID", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore, middleware.RoleTukang), h.CreateTransaction)
+	r.Post("/payment/notification", h.Notification)
+	r.Post("/admin/payments/:orderID/reconcile", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleAdmin), h.Reconcile)
 }
 
 func (h PaymentHandler) CreateTransaction(c *fiber.Ctx) error {
-	// userToken := c.Locals("user").(*jwt.Token)
-
-	// claims, ok := userToken.Claims.(jwt.MapClaims)
-	// if !ok || !userToken.Valid {
-	// 	return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
-	// }
-
-	// userID := int(claims["user_id"].(float64))
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
 
-	userID := 33
+	userID := principal.UserID
 
 	packageID, err := strconv.Atoi(c.Params("packageID"))
 	if err != nil {
 		return response.JSON(c, 400, "invalid package ID", nil)
 	}
 
-	if packageID != 1 && packageID != 2 {
+	if packageID < 1 {
 		return response.JSON(c, 400, "product not valid", nil)
 	}
 
@@ -43,6 +46,12 @@ func (h PaymentHandler) CreateTransaction(c *fiber.Ctx) error {
 
 	transaction, err := h.payService.CreateTransaction(userID, packageID)
 	if err != nil {
+		if errors.Is(err, payService.ErrPackageUnavailable) {
+			return response.JSON(c, 400, "product not valid", err.Error())
+		}
+		if errors.Is(err, gateway.ErrGateway) {
+			return response.JSON(c, 502, "payment gateway unavailable", err.Error())
+		}
 		return response.JSON(c, 500, "error creating transaction", err.Error())
 	}
 	return response.JSON(c, 200, "success creating transaction", transaction)
@@ -50,6 +59,52 @@ func (h PaymentHandler) CreateTransaction(c *fiber.Ctx) error {
 	// return response.JSON(c, 200, "success", userID)
 }
 
+func (h PaymentHandler) Notification(c *fiber.Ctx) error {
+	notif := new(paymentEntity.NotificationRequest)
+	if err := c.BodyParser(notif); err != nil {
+		log.Error("Invalid notification payload", slog.String("error", err.Error()))
+		return response.JSON(c, 400, "invalid payload", err.Error())
+	}
+
+	err := h.payService.HandleNotification(c.Body())
+	if err != nil {
+		log.Error("Error handling payment notification", slog.String("order_id", notif.OrderID), slog.String("error", err.Error()))
+		switch {
+		case errors.Is(err, payService.ErrInvalidSignature):
+			return response.JSON(c, 401, "invalid signature", nil)
+		case errors.Is(err, payRepo.ErrTransactionNotFound):
+			return response.JSON(c, 404, "transaction not found", nil)
+		case errors.Is(err, payService.ErrAmountMismatch), errors.Is(err, payService.ErrUnknownStatus):
+			return response.JSON(c, 400, err.Error(), nil)
+		}
+		return response.JSON(c, 500, "error handling notification", err.Error())
+	}
+
+	return response.JSON(c, 200, "notification processed", nil)
+}
+
+// Reconcile lets an admin settle one order from the gateway's status API
+// instead of waiting for the reconciliation job.
+func (h PaymentHandler) Reconcile(c *fiber.Ctx) error {
+	orderID := c.Params("orderID")
+
+	rec, err := h.payService.Reconcile(orderID)
+	if err != nil {
+		log.Error("Error reconciling payment", slog.String("order_id", orderID), slog.String("error", err.Error()))
+		switch {
+		case errors.Is(err, payRepo.ErrTransactionNotFound):
+			return response.JSON(c, 404, "transaction not found", nil)
+		case errors.Is(err, payService.ErrAmountMismatch):
+			return response.JSON(c, 409, err.Error(), rec)
+		case errors.Is(err, gateway.ErrGateway), errors.Is(err, payService.ErrUnknownStatus):
+			return response.JSON(c, 502, "payment gateway unavailable", err.Error())
+		}
+		return response.JSON(c, 500, "error reconciling transaction", err.Error())
+	}
+
+	return response.JSON(c, 200, "transaction reconciled", rec)
+}
+
 func NewPaymentHandler(payService payService.PaymentService) *PaymentHandler {
 	return &PaymentHandler{payService: payService}
 }
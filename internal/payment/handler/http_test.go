@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/fake"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	payRepo "github.com/ghulammuzz/backend-parkerin/internal/payment/repo"
+	payService "github.com/ghulammuzz/backend-parkerin/internal/payment/svc"
+	"github.com/gofiber/fiber/v2"
+)
+
+const serverKey = "test-server-key"
+
+// memRepo keeps transactions in memory with the same compare-and-set
+// semantics as the Postgres ApplyStatus.
+type memRepo struct {
+	mu       sync.Mutex
+	byOrder  map[string]paymentEntity.Transaction
+	payments map[int]paymentEntity.Payment
+	applied  int
+}
+
+func newMemRepo(transactions ...paymentEntity.Transaction) *memRepo {
+	r := &memRepo{byOrder: map[string]paymentEntity.Transaction{}, payments: map[int]paymentEntity.Payment{}}
+	for _, trx := range transactions {
+		r.byOrder[trx.OrderID] = trx
+	}
+	return r
+}
+
+func (r *memRepo) CreateTransaction(trx *paymentEntity.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	trx.ID = len(r.byOrder) + 1
+	trx.CreatedAt = time.Now()
+	r.byOrder[trx.OrderID] = *trx
+	return nil
+}
+
+func (r *memRepo) UpdatePaymentURL(id int, paymentURL string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for orderID, trx := range r.byOrder {
+		if trx.ID == id {
+			trx.PaymentURL = paymentURL
+			r.byOrder[orderID] = trx
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) GetTransactionByOrderID(orderID string) (*paymentEntity.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	trx, ok := r.byOrder[orderID]
+	if !ok {
+		return nil, payRepo.ErrTransactionNotFound
+	}
+	return &trx, nil
+}
+
+func (r *memRepo) ApplyStatus(trx *paymentEntity.Transaction, toStatus string, payment *paymentEntity.Payment) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.byOrder[trx.OrderID]
+	if stored.Status != trx.Status {
+		return false, nil
+	}
+	stored.Status = toStatus
+	stored.MidtransID = trx.MidtransID
+	r.byOrder[trx.OrderID] = stored
+	r.payments[stored.ID] = *payment
+	r.applied++
+	trx.Status = toStatus
+	return true, nil
+}
+
+func (r *memRepo) Unsettled(before time.Time, limit int) ([]paymentEntity.Transaction, error) {
+	return nil, nil
+}
+
+func (r *memRepo) MarkReconciled(id int) error {
+	return nil
+}
+
+func (r *memRepo) status(orderID string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byOrder[orderID].Status
+}
+
+// serve runs the payment routes on a local port and returns their
+// notification URL.
+func serve(t *testing.T, repo payRepo.PaymentRepository) string {
+	t.Helper()
+
+	svc := payService.NewPaymentService(nil, repo, nil, gateway.NewMidtrans(serverKey, false, ""))
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	NewPaymentHandler(svc).Router(app)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+
+	return "http://" + ln.Addr().String() + "/payment/notification"
+}
+
+func TestNotification(t *testing.T) {
+	trx := paymentEntity.Transaction{ID: 1, OrderID: "ORD-1-1", UserID: 1, PackageID: 1, Status: paymentEntity.StatusPending, Amount: 50000}
+
+	t.Run("bad signature", func(t *testing.T) {
+		repo := newMemRepo(trx)
+		notifier := fake.NewNotifier(serve(t, repo), "some-other-key")
+
+		code, err := notifier.Notify(trx.OrderID, "settlement", "accept", trx.Amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != fiber.StatusUnauthorized {
+			t.Fatalf("status code = %d, want 401", code)
+		}
+		if got := repo.status(trx.OrderID); got != paymentEntity.StatusPending {
+			t.Fatalf("transaction is %s, want pending", got)
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		repo := newMemRepo(trx)
+		notifier := fake.NewNotifier(serve(t, repo), serverKey)
+
+		// gross_amount is part of the signature, so a rewritten amount
+		// must not verify
+		notif := notifier.Build(trx.OrderID, "settlement", "accept", trx.Amount)
+		notif.GrossAmount = "1.00"
+		code, err := notifier.Send(notif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != fiber.StatusUnauthorized {
+			t.Fatalf("status code = %d, want 401", code)
+		}
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		repo := newMemRepo(trx)
+		notifier := fake.NewNotifier(serve(t, repo), serverKey)
+
+		code, err := notifier.Notify("ORD-404", "settlement", "accept", trx.Amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != fiber.StatusNotFound {
+			t.Fatalf("status code = %d, want 404", code)
+		}
+	})
+
+	t.Run("amount mismatch", func(t *testing.T) {
+		repo := newMemRepo(trx)
+		notifier := fake.NewNotifier(serve(t, repo), serverKey)
+
+		code, err := notifier.Notify(trx.OrderID, "settlement", "accept", trx.Amount-1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != fiber.StatusBadRequest {
+			t.Fatalf("status code = %d, want 400", code)
+		}
+		if got := repo.status(trx.OrderID); got != paymentEntity.StatusPending {
+			t.Fatalf("transaction is %s, want pending", got)
+		}
+	})
+
+	t.Run("duplicate settlement", func(t *testing.T) {
+		repo := newMemRepo(trx)
+		notifier := fake.NewNotifier(serve(t, repo), serverKey)
+
+		for i := 0; i < 2; i++ {
+			code, err := notifier.Notify(trx.OrderID, "settlement", "accept", trx.Amount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != fiber.StatusOK {
+				t.Fatalf("notification %d: status code = %d, want 200", i+1, code)
+			}
+		}
+		if got := repo.status(trx.OrderID); got != paymentEntity.StatusPaid {
+			t.Fatalf("transaction is %s, want paid", got)
+		}
+		if repo.applied != 1 {
+			t.Fatalf("status applied %d times, want once", repo.applied)
+		}
+	})
+
+	t.Run("illegal transition", func(t *testing.T) {
+		repo := newMemRepo(trx)
+		notifier := fake.NewNotifier(serve(t, repo), serverKey)
+
+		for _, status := range []string{"settlement", "pending", "expire"} {
+			code, err := notifier.Notify(trx.OrderID, status, "accept", trx.Amount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != fiber.StatusOK {
+				t.Fatalf("%s: status code = %d, want 200", status, code)
+			}
+		}
+		if got := repo.status(trx.OrderID); got != paymentEntity.StatusPaid {
+			t.Fatalf("transaction is %s, want paid", got)
+		}
+		if repo.applied != 1 {
+			t.Fatalf("status applied %d times, want once", repo.applied)
+		}
+	})
+
+	t.Run("fraud challenge then settlement", func(t *testing.T) {
+		repo := newMemRepo(trx)
+		notifier := fake.NewNotifier(serve(t, repo), serverKey)
+
+		if _, err := notifier.Notify(trx.OrderID, "capture", "challenge", trx.Amount); err != nil {
+			t.Fatal(err)
+		}
+		if got := repo.status(trx.OrderID); got != paymentEntity.StatusChallenge {
+			t.Fatalf("transaction is %s, want challenge", got)
+		}
+		if _, err := notifier.Notify(trx.OrderID, "settlement", "accept", trx.Amount); err != nil {
+			t.Fatal(err)
+		}
+		if got := repo.status(trx.OrderID); got != paymentEntity.StatusPaid {
+			t.Fatalf("transaction is %s, want paid", got)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+type PaymentRepository interface {
+	CreateTransaction(trx *paymentEntity.Transaction) error
+	UpdatePaymentURL(id int, paymentURL string) error
+	GetTransactionByOrderID(orderID string) (*paymentEntity.Transaction, error)
+	ApplyStatus(trx *paymentEntity.Transaction, toStatus string, payment *paymentEntity.Payment) (bool, error)
+	Unsettled(before time.Time, limit int) ([]paymentEntity.Transaction, error)
+	MarkReconciled(id int) error
+}
+
+type paymentRepository struct {
+	db *sql.DB
+}
+
+func (r *paymentRepository) CreateTransaction(trx *paymentEntity.Transaction) error {
+	query := `
+		INSERT INTO transactions (order_id, user_id, package_id, status, amount, payment_url)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, transaction_time, created_at, updated_at
+	`
+	err := r.db.QueryRow(query, trx.OrderID, trx.UserID, trx.PackageID, trx.Status, trx.Amount, trx.PaymentURL).
+		Scan(&trx.ID, &trx.TransactionTime, &trx.CreatedAt, &trx.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentRepository) UpdatePaymentURL(id int, paymentURL string) error {
+	query := `UPDATE transactions SET payment_url = $1, updated_at = NOW() WHERE id = $2`
+	_, err := r.db.Exec(query, paymentURL, id)
+	if err != nil {
+		return fmt.Errorf("failed to update payment url: %w", err)
+	}
+	return nil
+}
+
+const selectTransaction = `
+	SELECT id, order_id, user_id, package_id, status, amount, transaction_time,
+	       payment_url, midtrans_id, created_at, updated_at
+	FROM transactions
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransaction(row rowScanner) (*paymentEntity.Transaction, error) {
+	trx := &paymentEntity.Transaction{}
+	err := row.Scan(
+		&trx.ID,
+		&trx.OrderID,
+		&trx.UserID,
+		&trx.PackageID,
+		&trx.Status,
+		&trx.Amount,
+		&trx.TransactionTime,
+		&trx.PaymentURL,
+		&trx.MidtransID,
+		&trx.CreatedAt,
+		&trx.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return trx, nil
+}
+
+func (r *paymentRepository) GetTransactionByOrderID(orderID string) (*paymentEntity.Transaction, error) {
+	trx, err := scanTransaction(r.db.QueryRow(selectTransaction+` WHERE order_id = $1`, orderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	return trx, nil
+}
+
+// Unsettled returns up to limit transactions created before the given time
+// that are still waiting on the gateway, pending or challenged. Those never
+// reconciled come first, then the ones checked longest ago, so orders that
+// stay unsettled cannot keep the rest from being polled.
+func (r *paymentRepository) Unsettled(before time.Time, limit int) ([]paymentEntity.Transaction, error) {
+	query := selectTransaction + `
+		WHERE status IN ('pending', 'challenge') AND created_at < $1
+		ORDER BY last_reconciled_at NULLS FIRST, created_at
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unsettled transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := []paymentEntity.Transaction{}
+	for rows.Next() {
+		trx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, *trx)
+	}
+	return transactions, rows.Err()
+}
+
+func (r *paymentRepository) MarkReconciled(id int) error {
+	_, err := r.db.Exec(`UPDATE transactions SET last_reconciled_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark transaction reconciled: %w", err)
+	}
+	return nil
+}
+
+// ApplyStatus moves trx from its current status to toStatus and upserts the
+// payment record in one transaction. The update only matches while the row is
+// still in trx.Status, so a duplicate or racing notification reports false
+// instead of applying the same transition twice.
+func (r *paymentRepository) ApplyStatus(trx *paymentEntity.Transaction, toStatus string, payment *paymentEntity.Payment) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	updateQuery := `
+		UPDATE transactions
+		SET status = $1, midtrans_id = COALESCE(NULLIF($2, ''), midtrans_id), updated_at = NOW()
+		WHERE id = $3 AND status = $4
+	`
+	result, err := tx.Exec(updateQuery, toStatus, trx.MidtransID, trx.ID, trx.Status)
+	if err != nil {
+		return false, fmt.Errorf("failed to update transaction status: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	paymentQuery := `
+		INSERT INTO payments (transaction_id, payment_method, payment_status, payment_time, raw_response)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (transaction_id) DO UPDATE
+		SET payment_method = EXCLUDED.payment_method,
+		    payment_status = EXCLUDED.payment_status,
+		    payment_time = EXCLUDED.payment_time,
+		    raw_response = EXCLUDED.raw_response,
+		    updated_at = NOW()
+	`
+	_, err = tx.Exec(paymentQuery, trx.ID, payment.PaymentMethod, payment.PaymentStatus, payment.PaymentTime, payment.RawResponse)
+	if err != nil {
+		return false, fmt.Errorf("failed to save payment: %w", err)
+	}
+
+	switch toStatus {
+	case paymentEntity.StatusPaid:
+		if err := grantSubscription(tx, trx); err != nil {
+			return false, err
+		}
+	case paymentEntity.StatusRefunded:
+		_, err = tx.Exec(`DELETE FROM store_subscriptions WHERE transaction_id = $1`, trx.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to revoke store subscription: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	trx.Status = toStatus
+	return true, nil
+}
+
+// grantSubscription gives the paying store an entitlement window for the
+// package's duration. A renewal starts where the latest window ends, so paying
+// early extends the expiry instead of overlapping it. Transactions from users
+// without a store grant nothing.
+func grantSubscription(tx *sql.Tx, trx *paymentEntity.Transaction) error {
+	var storeID int
+	err := tx.QueryRow(`SELECT id FROM stores WHERE user_id = $1 FOR UPDATE`, trx.UserID).Scan(&storeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to lock store: %w", err)
+	}
+
+	query := `
+		INSERT INTO store_subscriptions (store_id, transaction_id, package_id, starts_at, ends_at, created_at)
+		SELECT $1, $2, p.id, w.starts_at, w.starts_at + p.duration_days * 86400, $4
+		FROM packages p,
+		     (SELECT GREATEST($4, COALESCE(MAX(ends_at), 0)) AS starts_at
+		      FROM store_subscriptions WHERE store_id = $1) w
+		WHERE p.id = $3
+		ON CONFLICT (transaction_id) DO NOTHING
+	`
+	_, err = tx.Exec(query, storeID, trx.ID, trx.PackageID, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to grant store subscription: %w", err)
+	}
+	return nil
+}
+
+func NewPaymentRepository(db *sql.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
@@ -0,0 +1,110 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	payRepo "github.com/ghulammuzz/backend-parkerin/internal/payment/repo"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+// reconcileBatch caps the orders one reconciliation run asks the gateway about.
+const reconcileBatch = 100
+
+// Reconcile asks the gateway for the order's status and applies it the way a
+// notification would.
+func (s *paymentService) Reconcile(orderID string) (*paymentEntity.Reconciliation, error) {
+	trx, err := s.payRepo.GetTransactionByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reconcile(trx, 0)
+}
+
+// ReconcileStale returns the reconciliation job for package payments: every
+// transaction still pending or challenged after age is checked against the
+// gateway, in case its notification was lost. A pending one the gateway does
+// not know after abandonAfter never reached it and is expired.
+func ReconcileStale(payRepo payRepo.PaymentRepository, gw gateway.PaymentGateway, age, abandonAfter time.Duration) func(ctx context.Context) (interface{}, error) {
+	s := &paymentService{payRepo: payRepo, gateway: gw}
+	return func(ctx context.Context) (interface{}, error) {
+		report := &paymentEntity.ReconcileReport{}
+
+		transactions, err := payRepo.Unsettled(time.Now().Add(-age), reconcileBatch)
+		if err != nil {
+			return report, err
+		}
+		for i := range transactions {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			rec, err := s.reconcile(&transactions[i], abandonAfter)
+			if markErr := payRepo.MarkReconciled(transactions[i].ID); markErr != nil {
+				log.Error("failed to mark reconciled", "order_id", transactions[i].OrderID, "error", markErr.Error())
+			}
+			if err != nil {
+				report.Failed++
+				log.Error("failed to reconcile payment", "order_id", transactions[i].OrderID, "error", err.Error())
+				if rec == nil {
+					continue
+				}
+			}
+			report.Add(rec)
+			if rec.Mismatch() {
+				log.Warn("payment status mismatch", "order_id", rec.OrderID, "local", rec.Local, "gateway", rec.Gateway, "status", rec.Status)
+			}
+		}
+
+		if report.Updated > 0 || report.Failed > 0 {
+			log.Info("payments reconciled", "checked", report.Checked, "updated", report.Updated, "failed", report.Failed)
+		}
+		return report, nil
+	}
+}
+
+func (s *paymentService) reconcile(trx *paymentEntity.Transaction, abandonAfter time.Duration) (*paymentEntity.Reconciliation, error) {
+	rec := &paymentEntity.Reconciliation{OrderID: trx.OrderID, Local: trx.Status, Status: trx.Status}
+
+	res, err := s.gateway.Status(trx.OrderID)
+	switch {
+	case errors.Is(err, gateway.ErrOrderNotFound):
+		rec.Gateway = paymentEntity.GatewayNotFound
+		if abandonAfter == 0 || trx.Status != paymentEntity.StatusPending || time.Since(trx.CreatedAt) < abandonAfter {
+			return rec, nil
+		}
+		res = Abandoned(trx.OrderID, int64(trx.Amount))
+	case err != nil:
+		return nil, err
+	default:
+		rec.Gateway = res.Status
+		if res.Status == trx.Status && res.Amount == int64(trx.Amount) {
+			// still where we think it is; nothing to apply
+			return rec, nil
+		}
+	}
+
+	applied, err := s.apply(res)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, err
+	}
+	if applied {
+		rec.Status = res.Status
+	}
+	return rec, nil
+}
+
+// Abandoned is the result applied to a pending order the gateway never saw.
+func Abandoned(orderID string, amount int64) *gateway.Result {
+	return &gateway.Result{
+		OrderID: orderID,
+		Status:  paymentEntity.StatusExpired,
+		Amount:  amount,
+		Time:    time.Now(),
+		Raw:     fmt.Sprintf(`{"order_id":%q,"reconciliation":"order not found at the payment gateway"}`, orderID),
+	}
+}
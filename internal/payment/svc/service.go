@@ -1,38 +1,105 @@
 package svc
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	pkgRepo "github.com/ghulammuzz/backend-parkerin/internal/packages/repo"
 	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/payment/gateway"
+	payRepo "github.com/ghulammuzz/backend-parkerin/internal/payment/repo"
 	userRepo "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
 	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
 
-	"github.com/midtrans/midtrans-go"
-	"github.com/midtrans/midtrans-go/snap"
+var (
+	ErrInvalidSignature = gateway.ErrInvalidSignature
+	ErrAmountMismatch   = errors.New("gross amount does not match transaction")
+	ErrUnknownStatus    = gateway.ErrUnknownStatus
+	// ErrPackageUnavailable covers unknown, inactive, and other-role packages.
+	ErrPackageUnavailable = errors.New("package not available")
 )
 
 type PaymentService interface {
 	CreateTransaction(userID, packageID int) (*paymentEntity.CreateTransactionResponse, error)
+	HandleNotification(body []byte) error
+	Reconcile(orderID string) (*paymentEntity.Reconciliation, error)
 }
 
 type paymentService struct {
-	userRepo       userRepo.UserRepository
-	midtransClient *snap.Client
+	userRepo userRepo.UserRepository
+	payRepo  payRepo.PaymentRepository
+	pkgRepo  pkgRepo.PackageRepository
+	gateway  gateway.PaymentGateway
+}
+
+func (s *paymentService) HandleNotification(body []byte) error {
+	res, err := s.gateway.VerifyNotification(body)
+	if err != nil {
+		return err
+	}
+	_, err = s.apply(res)
+	return err
+}
+
+// apply moves the transaction to the status the gateway reports: the amount
+// must match, and the status only moves along the allowed transitions. It
+// reports whether the status changed.
+func (s *paymentService) apply(res *gateway.Result) (bool, error) {
+	trx, err := s.payRepo.GetTransactionByOrderID(res.OrderID)
+	if err != nil {
+		return false, err
+	}
+
+	if res.Amount != int64(trx.Amount) {
+		return false, ErrAmountMismatch
+	}
+
+	if res.Status == trx.Status {
+		log.Info("duplicate payment notification ignored", "order_id", trx.OrderID, "status", trx.Status)
+		return false, nil
+	}
+	if !CanTransition(trx.Status, res.Status) {
+		log.Warn("illegal payment transition ignored", "order_id", trx.OrderID, "from", trx.Status, "to", res.Status)
+		return false, nil
+	}
+
+	trx.MidtransID = res.TransactionID
+	applied, err := s.payRepo.ApplyStatus(trx, res.Status, &paymentEntity.Payment{
+		TransactionID: trx.ID,
+		PaymentMethod: res.PaymentType,
+		PaymentStatus: res.Status,
+		PaymentTime:   res.Time,
+		RawResponse:   res.Raw,
+	})
+	if err != nil {
+		return false, err
+	}
+	if !applied {
+		log.Info("payment notification already applied", "order_id", trx.OrderID)
+		return false, nil
+	}
+
+	log.Info("payment status updated", "order_id", trx.OrderID, "status", res.Status)
+	return true, nil
 }
 
 func (s *paymentService) CreateTransaction(userID, packageID int) (*paymentEntity.CreateTransactionResponse, error) {
 
 	log.Debug("init svc")
-	var currentAmount int
 
-	if packageID == 1 {
-		currentAmount = 3000
-	} else if packageID == 2 {
-		currentAmount = 50000
-	} else {
-		currentAmount = 50000
+	pkg, err := s.pkgRepo.Detail(packageID)
+	if err != nil {
+		if errors.Is(err, pkgRepo.ErrPackageNotFound) {
+			return nil, ErrPackageUnavailable
+		}
+		return nil, err
+	}
+	if !pkg.IsActive {
+		return nil, ErrPackageUnavailable
 	}
+	currentAmount := pkg.Price
 
 	log.Debug("current Ammount : ", currentAmount)
 
@@ -41,63 +108,64 @@ func (s *paymentService) CreateTransaction(userID, packageID int) (*paymentEntit
 		log.Debug("error user repo detail")
 		return nil, err
 	}
-	custAddress := &midtrans.CustomerAddress{
-		FName:       users.Name,
-		LName:       "",
-		Phone:       users.PhoneNumber,
-		Address:     "",
-		City:        "",
-		Postcode:    "",
-		CountryCode: "IDN",
-	}
 
 	log.Debug("user name : ", users.Name)
 
-	chargeReq := &snap.Request{
-		TransactionDetails: midtrans.TransactionDetails{
-			OrderID:  fmt.Sprintf("ORD-%s-%d", users.Name, time.Now().UnixMilli()),
-			GrossAmt: int64(currentAmount),
-		},
-		CreditCard: &snap.CreditCardDetails{
-			Secure: true,
-		},
-		CustomerDetail: &midtrans.CustomerDetails{
-			FName:    users.Name,
-			BillAddr: custAddress,
-			ShipAddr: custAddress,
-		},
-		EnabledPayments: snap.AllSnapPaymentType,
-		Items: &[]midtrans.ItemDetails{
+	if pkg.TargetRole != users.Role {
+		return nil, ErrPackageUnavailable
+	}
+
+	orderID := fmt.Sprintf("ORD-%d-%d", userID, time.Now().UnixMilli())
+
+	trx := &paymentEntity.Transaction{
+		OrderID:   orderID,
+		UserID:    userID,
+		PackageID: pkg.ID,
+		Status:    paymentEntity.StatusPending,
+		Amount:    currentAmount,
+	}
+	if err := s.payRepo.CreateTransaction(trx); err != nil {
+		return nil, err
+	}
+
+	charge, err := s.gateway.CreateCharge(&gateway.ChargeRequest{
+		OrderID: orderID,
+		Amount:  int64(currentAmount),
+		Method:  gateway.MethodSnap,
+		Items: []gateway.Item{
 			{
-				ID:    "T-Harian",
+				ID:    fmt.Sprintf("PKG-%d", pkg.ID),
 				Qty:   1,
 				Price: int64(currentAmount),
-				Name:  "Paket Harian",
+				Name:  pkg.Name,
 			},
 		},
+		Customer: &gateway.Customer{
+			Name:  users.Name,
+			Phone: users.PhoneNumber,
+		},
+	})
+	if err != nil {
+		log.Debug("error charge transaction")
+		return nil, err
 	}
 
-	log.Debug("charge req : ", chargeReq)
-
-	chargeRes, errResp := s.midtransClient.CreateTransaction(chargeReq)
-	if errResp != nil {
-		log.Debug("error charge transaction")
+	if err := s.payRepo.UpdatePaymentURL(trx.ID, charge.RedirectURL); err != nil {
 		return nil, err
 	}
 
 	transaction := paymentEntity.CreateTransactionResponse{
-		Token: chargeRes.Token,
-		URL:   chargeRes.RedirectURL,
+		Token: charge.Token,
+		URL:   charge.RedirectURL,
 	}
 
 	return &transaction, nil
 }
 
-func NewPaymentService(userRepo userRepo.UserRepository, midtransClient *snap.Client) PaymentService {
-	return &paymentService{userRepo: userRepo, midtransClient: midtransClient}
+func NewPaymentService(userRepo userRepo.UserRepository, payRepo payRepo.PaymentRepository, pkgRepo pkgRepo.PackageRepository, gw gateway.PaymentGateway) PaymentService {
+	return &paymentService{userRepo: userRepo, payRepo: payRepo, pkgRepo: pkgRepo, gateway: gw}
 }
 
-
 /*
 
 get user id by token
@@ -107,4 +175,4 @@ attach price, product name, id to midtrans-item
 attach name, phone to midtrans-customer
 
 
-*/
\ No newline at end of file
+*/
@@ -0,0 +1,33 @@
+package svc
+
+import (
+	paymentEntity "github.com/ghulammuzz/backend-parkerin/internal/payment/entity"
+)
+
+// transitions lists the statuses each transaction status may move to.
+var transitions = map[string][]string{
+	paymentEntity.StatusPending: {
+		paymentEntity.StatusChallenge,
+		paymentEntity.StatusPaid,
+		paymentEntity.StatusFailed,
+		paymentEntity.StatusExpired,
+		paymentEntity.StatusCancelled,
+	},
+	paymentEntity.StatusChallenge: {
+		paymentEntity.StatusPaid,
+		paymentEntity.StatusFailed,
+		paymentEntity.StatusCancelled,
+	},
+	paymentEntity.StatusPaid: {
+		paymentEntity.StatusRefunded,
+	},
+}
+
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
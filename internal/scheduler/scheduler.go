@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+// Job is work run every Interval by exactly one replica: each run first takes
+// the pg_try_advisory_lock on LockKey, and replicas that miss it skip the run.
+type Job struct {
+	Name     string
+	LockKey  int64
+	Interval time.Duration
+	Run      func(ctx context.Context) (interface{}, error)
+}
+
+// Run is the outcome of one tick of a job on this replica.
+type Run struct {
+	StartedAt  int64       `json:"started_at"`
+	FinishedAt int64       `json:"finished_at"`
+	DurationMs int64       `json:"duration_ms"`
+	Leader     bool        `json:"leader"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+type Status struct {
+	Name     string `json:"name"`
+	Interval string `json:"interval"`
+	// LastRun is the latest tick, LastLeaderRun the latest one this replica
+	// actually executed.
+	LastRun       *Run `json:"last_run"`
+	LastLeaderRun *Run `json:"last_leader_run"`
+}
+
+type Scheduler struct {
+	db   *sql.DB
+	jobs []Job
+
+	mu     sync.RWMutex
+	status map[string]*Status
+}
+
+func New(db *sql.DB) *Scheduler {
+	return &Scheduler{db: db, status: map[string]*Status{}}
+}
+
+// Register adds a job; it must be called before Start.
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+	s.status[job.Name] = &Status{Name: job.Name, Interval: job.Interval.String()}
+}
+
+// Start runs every job once straight away and then on its interval until ctx
+// is cancelled. It does not block.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+// Status returns the last runs of every job, ordered by name.
+func (s *Scheduler) Status() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.status))
+	for _, st := range s.status {
+		statuses = append(statuses, *st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx, job)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, job Job) {
+	started := time.Now()
+	run := &Run{StartedAt: started.Unix()}
+
+	leader, err := s.runLocked(ctx, job, run)
+	run.Leader = leader
+	if err != nil {
+		run.Error = err.Error()
+		log.Error("scheduled job failed", "job", job.Name, "error", err.Error())
+	}
+	finished := time.Now()
+	run.FinishedAt = finished.Unix()
+	run.DurationMs = finished.Sub(started).Milliseconds()
+
+	s.mu.Lock()
+	s.status[job.Name].LastRun = run
+	if leader {
+		s.status[job.Name].LastLeaderRun = run
+	}
+	s.mu.Unlock()
+}
+
+// runLocked runs the job while holding its advisory lock on a dedicated
+// connection, reporting whether this replica got the lock.
+func (s *Scheduler) runLocked(ctx context.Context, job Job, run *Run) (leader bool, err error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, job.LockKey).Scan(&leader); err != nil {
+		return false, fmt.Errorf("failed to take job lock: %w", err)
+	}
+	if !leader {
+		return false, nil
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, job.LockKey)
+
+	defer func() {
+		// a panicking job must not take the scheduler goroutine down with it
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	run.Result, err = job.Run(ctx)
+	return true, err
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 
 	repoApp "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
+	repoDep "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/handler"
 	repoStore "github.com/ghulammuzz/backend-parkerin/internal/store/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/svc"
@@ -18,6 +19,7 @@ func InitializedStoreServiceFake(sb *sql.DB) *handler.StoreHandler {
 		repoStore.NewStoreRepository,
 		repoUser.NewUserRepository,
 		repoApp.NewApplicationRepository,
+		repoDep.NewDepositRepository,
 	)
 
 	return &handler.StoreHandler{}
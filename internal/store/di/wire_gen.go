@@ -9,6 +9,7 @@ package di
 import (
 	"database/sql"
 	repo3 "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
+	repo4 "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/handler"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/svc"
@@ -21,7 +22,8 @@ func InitializedStoreService(sb *sql.DB) *handler.StoreHandler {
 	storeRepository := repo.NewStoreRepository(sb)
 	userRepository := repo2.NewUserRepository(sb)
 	applicationRepository := repo3.NewApplicationRepository(sb)
-	storeService := svc.NewStoreService(storeRepository, userRepository, applicationRepository)
+	depositRepository := repo4.NewDepositRepository(sb)
+	storeService := svc.NewStoreService(storeRepository, userRepository, applicationRepository, depositRepository)
 	storeHandler := handler.NewStoreHandler(storeService)
 	return storeHandler
 }
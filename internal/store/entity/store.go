@@ -1,16 +1,26 @@
 package entity
 
-import userEntity "github.com/ghulammuzz/backend-parkerin/internal/users/entity"
+import (
+	depEntity "github.com/ghulammuzz/backend-parkerin/internal/deposits/entity"
+	userEntity "github.com/ghulammuzz/backend-parkerin/internal/users/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/schedule"
+)
 
 type ListStoreSubResponse struct {
-	ID           int    `json:"id"`
-	UserID       int    `json:"user_id"`
-	StoreName    string `json:"store_name"`
-	Address      string `json:"address"`
-	UrlImage     string `json:"url_image"`
-	WorkingHours string `json:"working_hours"`
-	IsHiring     bool   `json:"is_hiring"`
-	IsPaid       bool   `json:"is_paid"`
+	ID              int                `json:"id"`
+	UserID          int                `json:"user_id"`
+	StoreName       string             `json:"store_name"`
+	Address         string             `json:"address"`
+	UrlImage        string             `json:"url_image"`
+	WorkingHours    string             `json:"working_hours"`
+	WorkingSchedule *schedule.Schedule `json:"working_schedule"`
+	IsOpenNow       *bool              `json:"is_open_now,omitempty"`
+	IsHiring        bool               `json:"is_hiring"`
+	IsPaid          bool               `json:"is_paid"`
+	PaidUntil       *int64             `json:"paid_until"`
+	Latitude        float64            `json:"latitude,omitempty"`
+	Longitude       float64            `json:"longitude,omitempty"`
+	DistanceKm      *float64           `json:"distance_km,omitempty"`
 }
 
 type ListStoreResponse struct {
@@ -19,37 +29,103 @@ type ListStoreResponse struct {
 	Stores []ListStoreSubResponse `json:"stores"`
 }
 
+type NearbyStoreQuery struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+	OpenNow   bool
+	// StoreIDs restricts the result when non-nil; set by the service, not the handler.
+	StoreIDs []int
+}
+
 type DashboardStoreResponse struct {
-	ID           int                           `json:"id"`
-	User         userEntity.UserDetailResponse `json:"user"`
-	StoreName    string                        `json:"store_name"`
-	Address      string                        `json:"address"`
-	UrlImage     string                        `json:"url_image"`
-	Latitude     float64                       `json:"latitude"`
-	Longitude    float64                       `json:"longitude"`
-	WorkingHours string                        `json:"working_hours"`
-	IsHiring     bool                          `json:"is_hiring"`
-	IsPaid       bool                          `json:"is_paid"`
-	CreatedAt    int64                         `json:"created_at"`
-	IsVerified   bool                          `json:"is_verified"`
+	ID                 int                           `json:"id"`
+	User               userEntity.UserDetailResponse `json:"user"`
+	StoreName          string                        `json:"store_name"`
+	Address            string                        `json:"address"`
+	UrlImage           string                        `json:"url_image"`
+	Latitude           float64                       `json:"latitude"`
+	Longitude          float64                       `json:"longitude"`
+	WorkingHours       string                        `json:"working_hours"`
+	WorkingSchedule    *schedule.Schedule            `json:"working_schedule"`
+	IsOpenNow          *bool                         `json:"is_open_now,omitempty"`
+	IsHiring           bool                          `json:"is_hiring"`
+	HiringCapacity     int                           `json:"hiring_capacity"`
+	ActiveEmployments  int                           `json:"active_employments"`
+	IsPaid             bool                          `json:"is_paid"`
+	PaidUntil          *int64                        `json:"paid_until"`
+	CreatedAt          int64                         `json:"created_at"`
+	IsVerified         bool                          `json:"is_verified"`
+	VerificationStatus string                        `json:"verification_status"`
+	Deposits           *depEntity.Summary            `json:"deposits"`
 }
 
 type DetailStoreResponse struct {
-	ID           int     `json:"id"`
-	UserID       int     `json:"user_id"`
-	StoreName    string  `json:"store_name"`
-	Address      string  `json:"address"`
-	UrlImage     string  `json:"url_image"`
-	Latitude     float64 `json:"latitude"`
-	Longitude    float64 `json:"longitude"`
-	PhoneNumber  string  `json:"phone_number"`
-	WorkingHours string  `json:"working_hours"`
-	IsPaid       bool    `json:"is_paid"`
-	IsVerified   bool    `json:"is_verified"`
-	IsHiring     bool    `json:"is_hiring"`
-	CreatedAt    int64   `json:"created_at"`
+	ID                 int                `json:"id"`
+	UserID             int                `json:"user_id"`
+	StoreName          string             `json:"store_name"`
+	Address            string             `json:"address"`
+	UrlImage           string             `json:"url_image"`
+	Latitude           float64            `json:"latitude"`
+	Longitude          float64            `json:"longitude"`
+	PhoneNumber        string             `json:"phone_number"`
+	WorkingHours       string             `json:"working_hours"`
+	WorkingSchedule    *schedule.Schedule `json:"working_schedule"`
+	IsOpenNow          *bool              `json:"is_open_now,omitempty"`
+	IsPaid             bool               `json:"is_paid"`
+	PaidUntil          *int64             `json:"paid_until"`
+	IsVerified         bool               `json:"is_verified"`
+	IsHiring           bool               `json:"is_hiring"`
+	CreatedAt          int64              `json:"created_at"`
+	VerificationStatus string             `json:"verification_status"`
 }
 
+// UpdateIsHiringRequest changes whichever of the fields is present; an
+// omitted field is left as it is.
 type UpdateIsHiringRequest struct {
-	IsHiring bool `json:"is_hiring"`
+	IsHiring *bool `json:"is_hiring"`
+	// Capacity optionally changes how many tukang the store wants at once.
+	Capacity *int `json:"capacity"`
+}
+
+const (
+	VerificationPending   = "pending"
+	VerificationApproved  = "approved"
+	VerificationRejected  = "rejected"
+	VerificationSuspended = "suspended"
+)
+
+type UpdateVerificationRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected suspended"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+type AdminStoreSubResponse struct {
+	ID                 int     `json:"id"`
+	UserID             int     `json:"user_id"`
+	StoreName          string  `json:"store_name"`
+	Address            string  `json:"address"`
+	UrlImage           string  `json:"url_image"`
+	Latitude           float64 `json:"latitude"`
+	Longitude          float64 `json:"longitude"`
+	OwnerName          string  `json:"owner_name"`
+	OwnerPhone         string  `json:"owner_phone"`
+	VerificationStatus string  `json:"verification_status"`
+	CreatedAt          int64   `json:"created_at"`
+}
+
+type AdminStoreListResponse struct {
+	Page   int                     `json:"page"`
+	Limit  int                     `json:"limit"`
+	Stores []AdminStoreSubResponse `json:"stores"`
+}
+
+type VerificationEvent struct {
+	ID         int    `json:"id"`
+	StoreID    int    `json:"store_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason"`
+	AdminID    *int   `json:"admin_id"`
+	CreatedAt  int64  `json:"created_at"`
 }
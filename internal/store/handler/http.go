@@ -1,19 +1,27 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/store/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/geo"
 	"github.com/ghulammuzz/backend-parkerin/pkg/log"
 	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/ghulammuzz/backend-parkerin/pkg/schedule"
 	"github.com/gofiber/fiber/v2"
 )
 
+const (
+	defaultRadiusKm = 5
+	maxRadiusKm     = 50
+)
+
 type StoreHandler struct {
 	storeService svc.StoreService
 }
@@ -25,9 +33,14 @@ func NewStoreHandler(storeService svc.StoreService) *StoreHandler {
 func (h *StoreHandler) Router(r fiber.Router) {
 	r.Get("/stores", h.ListStores)
 	r.Get("/store/:id", h.GetStoreDetail)
-	r.Get("/store-dashboard", middleware.JWTProtected(), h.DashboardStore)
-	r.Put("/store-hiring", middleware.JWTProtected(), h.UpdateIsHiringHandler)
-	r.Post("/store-img", middleware.JWTProtected(), h.UplaodStoreIMGHandler)
+	r.Get("/store-dashboard", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.DashboardStore)
+	r.Put("/store-hiring", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.UpdateIsHiringHandler)
+	r.Put("/store-schedule", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.UpdateScheduleHandler)
+	r.Post("/store-img", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.UplaodStoreIMGHandler)
+
+	r.Get("/admin/stores", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleAdmin), h.ListStoresForVerification)
+	r.Put("/admin/stores/:id/verification", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleAdmin), h.UpdateVerification)
+	r.Get("/admin/stores/:id/verification-history", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleAdmin), h.VerificationHistory)
 }
 
 func (h *StoreHandler) ListStores(c *fiber.Ctx) error {
@@ -40,13 +53,23 @@ func (h *StoreHandler) ListStores(c *fiber.Ctx) error {
 		limit = 10 // default limit
 	}
 
+	if c.Query("lat") != "" || c.Query("lng") != "" {
+		return h.listNearbyStores(c, page, limit)
+	}
+
 	isHiring, err := strconv.ParseBool(c.Query("isHiring", "false"))
 	if err != nil {
 		log.Error("Invalid isHiring parameter", slog.String("error", err.Error()))
 		return response.JSON(c, fiber.StatusBadRequest, "Invalid isHiring parameter", nil)
 	}
 
-	stores, err := h.storeService.ListStores(page, limit, isHiring)
+	openNow, err := strconv.ParseBool(c.Query("open_now", "false"))
+	if err != nil {
+		log.Error("Invalid open_now parameter", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid open_now parameter", nil)
+	}
+
+	stores, err := h.storeService.ListStores(page, limit, isHiring, openNow)
 	if err != nil {
 		log.Error("Failed to retrieve store list", slog.String("error", err.Error()))
 		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve store list", err.Error())
@@ -55,6 +78,42 @@ func (h *StoreHandler) ListStores(c *fiber.Ctx) error {
 	return response.JSON(c, fiber.StatusOK, "Store list retrieved successfully", stores)
 }
 
+// listNearbyStores serves GET /stores?lat=&lng=&radius_km= with hiring stores
+// sorted by distance from the given point.
+func (h *StoreHandler) listNearbyStores(c *fiber.Ctx, page, limit int) error {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil || !geo.ValidCoordinates(lat, lng) {
+		log.Error("Invalid coordinates", slog.String("lat", c.Query("lat")), slog.String("lng", c.Query("lng")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid lat/lng parameter", nil)
+	}
+
+	radiusKm, err := strconv.ParseFloat(c.Query("radius_km", strconv.Itoa(defaultRadiusKm)), 64)
+	if err != nil || radiusKm <= 0 || radiusKm > maxRadiusKm {
+		log.Error("Invalid radius_km parameter", slog.String("radius_km", c.Query("radius_km")))
+		return response.JSON(c, fiber.StatusBadRequest, "radius_km must be greater than 0 and at most 50", nil)
+	}
+
+	openNow, err := strconv.ParseBool(c.Query("open_now", "false"))
+	if err != nil {
+		log.Error("Invalid open_now parameter", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid open_now parameter", nil)
+	}
+
+	stores, err := h.storeService.ListNearbyStores(page, limit, entity.NearbyStoreQuery{
+		Latitude:  lat,
+		Longitude: lng,
+		RadiusKm:  radiusKm,
+		OpenNow:   openNow,
+	})
+	if err != nil {
+		log.Error("Failed to retrieve nearby stores", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve store list", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Store list retrieved successfully", stores)
+}
+
 func (h *StoreHandler) GetStoreDetail(c *fiber.Ctx) error {
 	storeIDStr := c.Params("id")
 	storeID, err := strconv.Atoi(storeIDStr)
@@ -73,15 +132,13 @@ func (h *StoreHandler) GetStoreDetail(c *fiber.Ctx) error {
 }
 
 func (h *StoreHandler) DashboardStore(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
-
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token")
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
+	userID := principal.UserID
 
 	store, err := h.storeService.DashboardStore(userID)
 	if err != nil {
@@ -98,49 +155,80 @@ func (h *StoreHandler) UpdateIsHiringHandler(c *fiber.Ctx) error {
 		log.Error("Payload error", slog.String("error", err.Error()))
 		return response.JSON(c, 400, "Payload error", err.Error())
 	}
+	if req.IsHiring == nil && req.Capacity == nil {
+		return response.JSON(c, 400, "Payload error", "is_hiring or capacity is required")
+	}
 
-	userToken := c.Locals("user").(*jwt.Token)
-
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token")
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
+	storeID := principal.StoreID
+	log.Debug(fmt.Sprint(storeID))
 
-	// using get storeID by userID
-	storeID, err := h.storeService.GetStoreIDByUserID(userID)
-	if err != nil {
-		log.Error("Invalid user ID", slog.String("userID", fmt.Sprint(userID)))
-		return response.JSON(c, 400, "invalid user ID", nil)
+	if req.Capacity != nil {
+		if err := h.storeService.UpdateHiringCapacity(storeID, *req.Capacity); err != nil {
+			log.Error("Error updating hiring capacity", slog.String("error", err.Error()))
+			if errors.Is(err, svc.ErrInvalidCapacity) {
+				return response.JSON(c, fiber.StatusBadRequest, err.Error(), nil)
+			}
+			return response.JSON(c, 500, "error svc", err.Error())
+		}
 	}
-	log.Debug(fmt.Sprint(storeID))
 
-	if err := h.storeService.UpdateIsHiring(req.IsHiring, storeID); err != nil {
-		log.Error("Error updating hiring status", slog.String("error", err.Error()))
-		return response.JSON(c, 500, "error svc", err.Error())
+	if req.IsHiring != nil {
+		if err := h.storeService.UpdateIsHiring(*req.IsHiring, storeID); err != nil {
+			log.Error("Error updating hiring status", slog.String("error", err.Error()))
+			if errors.Is(err, svc.ErrCapacityReached) {
+				return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+			}
+			if errors.Is(err, svc.ErrSubscriptionRequired) {
+				return response.JSON(c, fiber.StatusPaymentRequired, err.Error(), nil)
+			}
+			if errors.Is(err, svc.ErrStoreNotApproved) {
+				return response.JSON(c, fiber.StatusForbidden, err.Error(), nil)
+			}
+			return response.JSON(c, 500, "error svc", err.Error())
+		}
 	}
 
 	return response.JSON(c, 200, "Success Updated", nil)
 }
 
-func (h *StoreHandler) UplaodStoreIMGHandler(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
+func (h *StoreHandler) UpdateScheduleHandler(c *fiber.Ctx) error {
+	var req schedule.Schedule
+	if err := c.BodyParser(&req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, 400, "Payload error", err.Error())
+	}
 
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token")
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
+	if err := h.storeService.UpdateSchedule(principal.StoreID, &req); err != nil {
+		log.Error("Error updating working schedule", slog.String("error", err.Error()))
+		if errors.Is(err, svc.ErrInvalidSchedule) {
+			return response.JSON(c, fiber.StatusBadRequest, "Validation failed", err.Error())
+		}
+		return response.JSON(c, 500, "error svc", err.Error())
+	}
 
-	storeID, err := h.storeService.GetStoreIDByUserID(userID)
-	if err != nil {
-		log.Error("Invalid user ID", slog.String("userID", fmt.Sprint(userID)))
-		return response.JSON(c, 400, "invalid user ID", nil)
+	return response.JSON(c, 200, "Working schedule updated", req)
+}
+
+func (h *StoreHandler) UplaodStoreIMGHandler(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
+
+	storeID := principal.StoreID
 	log.Debug(fmt.Sprint(storeID))
 
 	img, err := c.FormFile("img")
@@ -163,3 +251,83 @@ func (h *StoreHandler) UplaodStoreIMGHandler(c *fiber.Ctx) error {
 
 	return response.JSON(c, 200, "img uploaded", nil)
 }
+
+func (h *StoreHandler) ListStoresForVerification(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	status := c.Query("status", entity.VerificationPending)
+	switch status {
+	case entity.VerificationPending, entity.VerificationApproved, entity.VerificationRejected, entity.VerificationSuspended:
+	default:
+		log.Error("Invalid status parameter", slog.String("status", status))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid status parameter", nil)
+	}
+
+	stores, err := h.storeService.ListStoresForVerification(status, page, limit)
+	if err != nil {
+		log.Error("Failed to retrieve verification queue", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve verification queue", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Verification queue retrieved successfully", stores)
+}
+
+func (h *StoreHandler) UpdateVerification(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	storeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || storeID < 1 {
+		log.Error("Invalid store ID", slog.String("storeID", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid store ID", nil)
+	}
+
+	var req entity.UpdateVerificationRequest
+	if err := c.BodyParser(&req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, 400, "Payload error", err.Error())
+	}
+	if req.Status != entity.VerificationApproved && req.Status != entity.VerificationRejected && req.Status != entity.VerificationSuspended {
+		log.Error("Invalid status", slog.String("status", req.Status))
+		return response.JSON(c, 400, "invalid status; must be 'approved', 'rejected' or 'suspended'", nil)
+	}
+
+	if err := h.storeService.UpdateVerification(storeID, principal.UserID, &req); err != nil {
+		log.Error("Error updating verification status", slog.String("error", err.Error()))
+		switch {
+		case errors.Is(err, svc.ErrReasonRequired):
+			return response.JSON(c, fiber.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, repo.ErrVerificationTransition):
+			return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+		}
+		return response.JSON(c, 500, "error svc verification", err.Error())
+	}
+
+	return response.JSON(c, 200, "Verification status updated", nil)
+}
+
+func (h *StoreHandler) VerificationHistory(c *fiber.Ctx) error {
+	storeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || storeID < 1 {
+		log.Error("Invalid store ID", slog.String("storeID", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid store ID", nil)
+	}
+
+	events, err := h.storeService.VerificationHistory(storeID)
+	if err != nil {
+		log.Error("Failed to retrieve verification history", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve verification history", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Verification history retrieved successfully", events)
+}
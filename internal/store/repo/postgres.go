@@ -3,41 +3,267 @@ package repo
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"time"
 
 	storeEntity "github.com/ghulammuzz/backend-parkerin/internal/store/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/geo"
+	"github.com/ghulammuzz/backend-parkerin/pkg/schedule"
+	"github.com/lib/pq"
 )
 
 type StoreRepository interface {
-	List(page, limit int, isHiring bool) (storeEntity.ListStoreResponse, error)
+	List(page, limit int, isHiring bool, storeIDs []int) (storeEntity.ListStoreResponse, error)
+	Schedules(isHiring bool) (map[int]*schedule.Schedule, error)
+	UpdateSchedule(storeID int, sched *schedule.Schedule) error
+	ListNearby(page, limit int, near storeEntity.NearbyStoreQuery) (storeEntity.ListStoreResponse, error)
 	Detail(id int) (*storeEntity.DetailStoreResponse, error)
 	DetailByUserID(id int) (*storeEntity.DetailStoreResponse, error)
 	GetStoreIDByUserID(userID int) (int, error)
 	UpdateIsHiring(isHiring bool, storeID int) error
 	IsStoreIDValid(storeID int) (bool, error)
 	UploadStoreIMG(storeID int, img string) error
-	VerifiedStore(storeID int) error
+	VerificationStatus(storeID int) (string, error)
+	UpdateVerificationStatus(storeID int, to, reason string, adminID *int, allowedFrom ...string) (string, error)
+	VerificationHistory(storeID int) ([]storeEntity.VerificationEvent, error)
+	ListByVerificationStatus(status string, page, limit int) (storeEntity.AdminStoreListResponse, error)
+	ActiveSubscriptionUntil(storeID int) (int64, bool, error)
+	HiringCapacity(storeID int) (capacity, active int, err error)
+	UpdateHiringCapacity(storeID, capacity int) error
 }
 
+var ErrVerificationTransition = errors.New("store is not in a state that allows this verification change")
+
 type storeRepository struct {
 	db *sql.DB
 }
 
-func (r *storeRepository) VerifiedStore(storeID int) error {
+// paidUntilQuery selects the end of the store's entitlement window, or NULL
+// when it has none running. %s is the stores table alias.
+const paidUntilQuery = `(
+	SELECT MAX(ss.ends_at) FROM store_subscriptions ss
+	WHERE ss.store_id = %s.id AND ss.ends_at > EXTRACT(EPOCH FROM NOW())::BIGINT
+)`
+
+// hiringQuery is true when the store takes general applications or has an
+// open vacancy. %[1]s is the stores table alias.
+const hiringQuery = `(
+	%[1]s.is_hiring OR EXISTS (SELECT 1 FROM vacancies v WHERE v.store_id = %[1]s.id AND v.status = 'open')
+)`
+
+func setPaidUntil(paidUntil sql.NullInt64, isPaid *bool, out **int64) {
+	*isPaid = paidUntil.Valid
+	if paidUntil.Valid {
+		*out = &paidUntil.Int64
+	}
+}
+
+// decodeSchedule turns a nullable working_schedule column into a schedule;
+// stores registered before structured hours existed have none.
+func decodeSchedule(raw []byte) (*schedule.Schedule, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	sched := &schedule.Schedule{}
+	if err := json.Unmarshal(raw, sched); err != nil {
+		return nil, fmt.Errorf("failed to decode working schedule: %w", err)
+	}
+	return sched, nil
+}
+
+func (r *storeRepository) ActiveSubscriptionUntil(storeID int) (int64, bool, error) {
+	var paidUntil sql.NullInt64
+	query := fmt.Sprintf(`SELECT %s FROM stores s WHERE s.id = $1`, fmt.Sprintf(paidUntilQuery, "s"))
+	err := r.db.QueryRow(query, storeID).Scan(&paidUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, fmt.Errorf("store with ID %d not found", storeID)
+		}
+		return 0, false, err
+	}
+	return paidUntil.Int64, paidUntil.Valid, nil
+}
+
+// HiringCapacity returns how many tukang the store wants working at once
+// through general applications and how many active employments fill it.
+// Employments started from a vacancy count against that vacancy instead.
+func (r *storeRepository) HiringCapacity(storeID int) (capacity, active int, err error) {
 	query := `
-		UPDATE users
-		SET is_verified = true
-		FROM stores
-		WHERE stores.id = $1
-		AND stores.user_id = users.id
+		SELECT s.hiring_capacity,
+		       (SELECT COUNT(*) FROM employments e WHERE e.store_id = s.id AND e.status = 'active' AND e.vacancy_id IS NULL)
+		FROM stores s
+		WHERE s.id = $1
 	`
-	_, err := r.db.Exec(query, storeID)
+	err = r.db.QueryRow(query, storeID).Scan(&capacity, &active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, fmt.Errorf("store with ID %d not found", storeID)
+		}
+		return 0, 0, err
+	}
+	return capacity, active, nil
+}
+
+func (r *storeRepository) UpdateHiringCapacity(storeID, capacity int) error {
+	_, err := r.db.Exec(`UPDATE stores SET hiring_capacity = $1 WHERE id = $2`, capacity, storeID)
 	if err != nil {
-		return fmt.Errorf("failed to update user verification status: %w", err)
+		return fmt.Errorf("failed to update hiring capacity: %w", err)
 	}
 	return nil
 }
 
+func (r *storeRepository) VerificationStatus(storeID int) (string, error) {
+	var status string
+	err := r.db.QueryRow(`SELECT verification_status FROM stores WHERE id = $1`, storeID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("store with ID %d not found", storeID)
+		}
+		return "", err
+	}
+	return status, nil
+}
+
+// UpdateVerificationStatus moves the store to status `to` when its current
+// status is one of allowedFrom, recording the decision in
+// store_verification_events. It returns the previous status.
+func (r *storeRepository) UpdateVerificationStatus(storeID int, to, reason string, adminID *int, allowedFrom ...string) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var from string
+	err = tx.QueryRow(`SELECT verification_status FROM stores WHERE id = $1 FOR UPDATE`, storeID).Scan(&from)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("store with ID %d not found", storeID)
+		}
+		return "", err
+	}
+
+	allowed := false
+	for _, status := range allowedFrom {
+		if status == from {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return from, ErrVerificationTransition
+	}
+
+	if _, err := tx.Exec(`UPDATE stores SET verification_status = $1 WHERE id = $2`, to, storeID); err != nil {
+		return "", fmt.Errorf("failed to update verification status: %w", err)
+	}
+
+	eventQuery := `
+		INSERT INTO store_verification_events (store_id, from_status, to_status, reason, admin_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.Exec(eventQuery, storeID, from, to, reason, adminID, time.Now().Unix()); err != nil {
+		return "", fmt.Errorf("failed to record verification event: %w", err)
+	}
+
+	return from, tx.Commit()
+}
+
+func (r *storeRepository) VerificationHistory(storeID int) ([]storeEntity.VerificationEvent, error) {
+	query := `
+		SELECT id, store_id, from_status, to_status, reason, admin_id, created_at
+		FROM store_verification_events
+		WHERE store_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.db.Query(query, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []storeEntity.VerificationEvent{}
+	for rows.Next() {
+		event := storeEntity.VerificationEvent{}
+		var adminID sql.NullInt64
+		if err := rows.Scan(
+			&event.ID,
+			&event.StoreID,
+			&event.FromStatus,
+			&event.ToStatus,
+			&event.Reason,
+			&adminID,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if adminID.Valid {
+			id := int(adminID.Int64)
+			event.AdminID = &id
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *storeRepository) ListByVerificationStatus(status string, page, limit int) (storeEntity.AdminStoreListResponse, error) {
+	offset := (page - 1) * limit
+	query := `
+		SELECT s.id, s.user_id, s.store_name, s.address, s.url_image, s.latitude, s.longitude,
+		       u.name, u.phone_number, s.verification_status, s.created_at
+		FROM stores s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.verification_status = $1
+		ORDER BY s.created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(query, status, limit, offset)
+	if err != nil {
+		return storeEntity.AdminStoreListResponse{}, err
+	}
+	defer rows.Close()
+
+	stores := []storeEntity.AdminStoreSubResponse{}
+	for rows.Next() {
+		store := storeEntity.AdminStoreSubResponse{}
+		if err := rows.Scan(
+			&store.ID,
+			&store.UserID,
+			&store.StoreName,
+			&store.Address,
+			&store.UrlImage,
+			&store.Latitude,
+			&store.Longitude,
+			&store.OwnerName,
+			&store.OwnerPhone,
+			&store.VerificationStatus,
+			&store.CreatedAt,
+		); err != nil {
+			return storeEntity.AdminStoreListResponse{}, err
+		}
+		stores = append(stores, store)
+	}
+
+	if err := rows.Err(); err != nil {
+		return storeEntity.AdminStoreListResponse{}, err
+	}
+
+	return storeEntity.AdminStoreListResponse{
+		Stores: stores,
+		Page:   page,
+		Limit:  limit,
+	}, nil
+}
+
 func (r *storeRepository) UploadStoreIMG(storeID int, img string) error {
 	query := `UPDATE stores SET url_image = $1 WHERE id = $2`
 	_, err := r.db.Exec(query, img, storeID)
@@ -67,10 +293,28 @@ func (r *storeRepository) IsStoreIDValid(storeID int) (bool, error) {
 	return true, nil
 }
 
+// UpdateIsHiring toggles general applications. Turning hiring off also closes
+// the store's open vacancies so it stops showing up as hiring at all.
 func (r *storeRepository) UpdateIsHiring(isHiring bool, storeID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := "UPDATE stores SET is_hiring = $1 WHERE id = $2"
-	_, err := r.db.Exec(query, isHiring, storeID)
-	return err
+	if _, err := tx.Exec(query, isHiring, storeID); err != nil {
+		return err
+	}
+
+	if !isHiring {
+		closeQuery := `UPDATE vacancies SET status = 'closed', updated_at = $1 WHERE store_id = $2 AND status = 'open'`
+		if _, err := tx.Exec(closeQuery, time.Now().Unix(), storeID); err != nil {
+			return fmt.Errorf("failed to close vacancies: %w", err)
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (s *storeRepository) GetStoreIDByUserID(userID int) (int, error) {
@@ -88,14 +332,16 @@ func (s *storeRepository) GetStoreIDByUserID(userID int) (int, error) {
 }
 
 func (s *storeRepository) DetailByUserID(id int) (*storeEntity.DetailStoreResponse, error) {
-	query := `
+	query := fmt.Sprintf(`
 		SELECT s.id, s.user_id, s.store_name, s.url_image, s.address, s.latitude, s.longitude, 
-		       s.working_hours, s.is_hiring, s.is_paid, u.is_verified, s.created_at
+		       s.working_hours, s.working_schedule, s.is_hiring, %s, s.verification_status, s.created_at
 		FROM stores s
 		JOIN users u ON s.user_id = u.id
 		WHERE s.user_id = $1
-	`
+	`, fmt.Sprintf(paidUntilQuery, "s"))
 
+	var paidUntil sql.NullInt64
+	var rawSchedule []byte
 	storeDetail := &storeEntity.DetailStoreResponse{}
 	err := s.db.QueryRow(query, id).Scan(
 		&storeDetail.ID,
@@ -106,9 +352,10 @@ func (s *storeRepository) DetailByUserID(id int) (*storeEntity.DetailStoreRespon
 		&storeDetail.Latitude,
 		&storeDetail.Longitude,
 		&storeDetail.WorkingHours,
+		&rawSchedule,
 		&storeDetail.IsHiring,
-		&storeDetail.IsPaid,
-		&storeDetail.IsVerified,
+		&paidUntil,
+		&storeDetail.VerificationStatus,
 		&storeDetail.CreatedAt,
 	)
 	if err != nil {
@@ -117,12 +364,17 @@ func (s *storeRepository) DetailByUserID(id int) (*storeEntity.DetailStoreRespon
 		}
 		return nil, err
 	}
+	if storeDetail.WorkingSchedule, err = decodeSchedule(rawSchedule); err != nil {
+		return nil, err
+	}
+	setPaidUntil(paidUntil, &storeDetail.IsPaid, &storeDetail.PaidUntil)
+	storeDetail.IsVerified = storeDetail.VerificationStatus == storeEntity.VerificationApproved
 
 	return storeDetail, nil
 }
 
 func (s *storeRepository) Detail(id int) (*storeEntity.DetailStoreResponse, error) {
-	query := `
+	query := fmt.Sprintf(`
 		SELECT 
 			st.id, 
 			st.user_id, 
@@ -131,16 +383,20 @@ func (s *storeRepository) Detail(id int) (*storeEntity.DetailStoreResponse, erro
 			st.latitude, 
 			st.longitude, 
 			st.working_hours, 
+			st.working_schedule,
 			st.url_image, 
-			st.is_hiring, 
-			st.is_paid, 
+			%s, 
+			%s, 
+			st.verification_status,
 			st.created_at,
 			u.phone_number
 		FROM stores st
 		JOIN users u ON st.user_id = u.id
 		WHERE st.id = $1
-	`
+	`, fmt.Sprintf(hiringQuery, "st"), fmt.Sprintf(paidUntilQuery, "st"))
 
+	var paidUntil sql.NullInt64
+	var rawSchedule []byte
 	storeDetail := &storeEntity.DetailStoreResponse{}
 	err := s.db.QueryRow(query, id).Scan(
 		&storeDetail.ID,
@@ -150,9 +406,11 @@ func (s *storeRepository) Detail(id int) (*storeEntity.DetailStoreResponse, erro
 		&storeDetail.Latitude,
 		&storeDetail.Longitude,
 		&storeDetail.WorkingHours,
+		&rawSchedule,
 		&storeDetail.UrlImage,
 		&storeDetail.IsHiring,
-		&storeDetail.IsPaid,
+		&paidUntil,
+		&storeDetail.VerificationStatus,
 		&storeDetail.CreatedAt,
 		&storeDetail.PhoneNumber,
 	)
@@ -162,21 +420,29 @@ func (s *storeRepository) Detail(id int) (*storeEntity.DetailStoreResponse, erro
 		}
 		return nil, err
 	}
+	if storeDetail.WorkingSchedule, err = decodeSchedule(rawSchedule); err != nil {
+		return nil, err
+	}
+	setPaidUntil(paidUntil, &storeDetail.IsPaid, &storeDetail.PaidUntil)
+	storeDetail.IsVerified = storeDetail.VerificationStatus == storeEntity.VerificationApproved
 
 	return storeDetail, nil
 }
 
 
-func (s *storeRepository) List(page, limit int, isHiring bool) (storeEntity.ListStoreResponse, error) {
+// List pages through approved stores by whether they are hiring (see
+// hiringQuery). A non-nil storeIDs further limits the result to those stores.
+func (s *storeRepository) List(page, limit int, isHiring bool, storeIDs []int) (storeEntity.ListStoreResponse, error) {
 	offset := (page - 1) * limit
-	query := `
-		SELECT id, user_id, store_name, address, working_hours, url_image, is_hiring, is_paid
-		FROM stores WHERE is_hiring = $1
-		ORDER BY created_at DESC
+	query := fmt.Sprintf(`
+		SELECT s.id, s.user_id, s.store_name, s.address, s.working_hours, s.working_schedule, s.url_image, %[1]s, %[2]s
+		FROM stores s WHERE %[1]s = $1 AND s.verification_status = 'approved'
+		  AND ($4::INT[] IS NULL OR s.id = ANY($4))
+		ORDER BY s.created_at DESC
 		LIMIT $2 OFFSET $3
-	`
+	`, fmt.Sprintf(hiringQuery, "s"), fmt.Sprintf(paidUntilQuery, "s"))
 
-	rows, err := s.db.Query(query, isHiring, limit, offset)
+	rows, err := s.db.Query(query, isHiring, limit, offset, storeIDArray(storeIDs))
 	if err != nil {
 		return storeEntity.ListStoreResponse{}, err
 	}
@@ -185,18 +451,27 @@ func (s *storeRepository) List(page, limit int, isHiring bool) (storeEntity.List
 	stores := []storeEntity.ListStoreSubResponse{}
 	for rows.Next() {
 		store := storeEntity.ListStoreSubResponse{}
+		var paidUntil sql.NullInt64
+		var rawSchedule []byte
 		if err := rows.Scan(
 			&store.ID,
 			&store.UserID,
 			&store.StoreName,
 			&store.Address,
 			&store.WorkingHours,
+			&rawSchedule,
 			&store.UrlImage,
 			&store.IsHiring,
-			&store.IsPaid,
+			&paidUntil,
 		); err != nil {
 			return storeEntity.ListStoreResponse{}, err
 		}
+		sched, err := decodeSchedule(rawSchedule)
+		if err != nil {
+			return storeEntity.ListStoreResponse{}, err
+		}
+		store.WorkingSchedule = sched
+		setPaidUntil(paidUntil, &store.IsPaid, &store.PaidUntil)
 		stores = append(stores, store)
 	}
 
@@ -211,6 +486,145 @@ func (s *storeRepository) List(page, limit int, isHiring bool) (storeEntity.List
 	}, nil
 }
 
+// ListNearby returns approved hiring stores within near.RadiusKm, closest
+// first. The bounding box narrows the scan through idx_stores_lat_lng before
+// the exact haversine distance is computed.
+func (s *storeRepository) ListNearby(page, limit int, near storeEntity.NearbyStoreQuery) (storeEntity.ListStoreResponse, error) {
+	offset := (page - 1) * limit
+	box := geo.BoundingBox(near.Latitude, near.Longitude, near.RadiusKm)
+	query := fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT s.id, s.user_id, s.store_name, s.address, s.working_hours, s.working_schedule, s.url_image, %[1]s AS is_hiring, %[2]s AS paid_until,
+			       s.latitude, s.longitude,
+			       6371 * 2 * ASIN(SQRT(
+			           POWER(SIN(RADIANS(s.latitude - $1) / 2), 2) +
+			           COS(RADIANS($1)) * COS(RADIANS(s.latitude)) * POWER(SIN(RADIANS(s.longitude - $2) / 2), 2)
+			       )) AS distance_km
+			FROM stores s
+			WHERE s.verification_status = 'approved'
+			  AND s.latitude BETWEEN $3 AND $4
+			  AND s.longitude BETWEEN $5 AND $6
+			  AND ($10::INT[] IS NULL OR s.id = ANY($10))
+		) nearby
+		WHERE distance_km <= $7 AND is_hiring
+		ORDER BY distance_km ASC
+		LIMIT $8 OFFSET $9
+	`, fmt.Sprintf(hiringQuery, "s"), fmt.Sprintf(paidUntilQuery, "s"))
+
+	rows, err := s.db.Query(query, near.Latitude, near.Longitude, box.MinLat, box.MaxLat, box.MinLng, box.MaxLng, near.RadiusKm, limit, offset, storeIDArray(near.StoreIDs))
+	if err != nil {
+		return storeEntity.ListStoreResponse{}, err
+	}
+	defer rows.Close()
+
+	stores := []storeEntity.ListStoreSubResponse{}
+	for rows.Next() {
+		store := storeEntity.ListStoreSubResponse{}
+		var paidUntil sql.NullInt64
+		var rawSchedule []byte
+		var distance float64
+		if err := rows.Scan(
+			&store.ID,
+			&store.UserID,
+			&store.StoreName,
+			&store.Address,
+			&store.WorkingHours,
+			&rawSchedule,
+			&store.UrlImage,
+			&store.IsHiring,
+			&paidUntil,
+			&store.Latitude,
+			&store.Longitude,
+			&distance,
+		); err != nil {
+			return storeEntity.ListStoreResponse{}, err
+		}
+		sched, err := decodeSchedule(rawSchedule)
+		if err != nil {
+			return storeEntity.ListStoreResponse{}, err
+		}
+		store.WorkingSchedule = sched
+		setPaidUntil(paidUntil, &store.IsPaid, &store.PaidUntil)
+		distance = math.Round(distance*100) / 100
+		store.DistanceKm = &distance
+		stores = append(stores, store)
+	}
+
+	if err := rows.Err(); err != nil {
+		return storeEntity.ListStoreResponse{}, err
+	}
+
+	return storeEntity.ListStoreResponse{
+		Stores: stores,
+		Page:   page,
+		Limit:  limit,
+	}, nil
+}
+
+// storeIDArray maps a nil filter to SQL NULL so the query skips it, while an
+// empty one still matches nothing.
+func storeIDArray(storeIDs []int) interface{} {
+	if storeIDs == nil {
+		return nil
+	}
+	ids := make([]int64, len(storeIDs))
+	for i, id := range storeIDs {
+		ids[i] = int64(id)
+	}
+	return pq.Array(ids)
+}
+
+// Schedules returns the structured hours of every approved store that is (or
+// is not) hiring, keyed by store id. Stores without a schedule are left out.
+func (s *storeRepository) Schedules(isHiring bool) (map[int]*schedule.Schedule, error) {
+	query := fmt.Sprintf(`
+		SELECT s.id, s.working_schedule FROM stores s
+		WHERE %s = $1 AND s.verification_status = 'approved' AND s.working_schedule IS NOT NULL
+	`, fmt.Sprintf(hiringQuery, "s"))
+	rows, err := s.db.Query(query, isHiring)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make(map[int]*schedule.Schedule)
+	for rows.Next() {
+		var id int
+		var raw []byte
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, err
+		}
+		sched, err := decodeSchedule(raw)
+		if err != nil {
+			return nil, err
+		}
+		schedules[id] = sched
+	}
+	return schedules, rows.Err()
+}
+
+// UpdateSchedule stores the structured hours and refreshes working_hours with
+// its legacy rendering so older clients keep reading a plain string.
+func (s *storeRepository) UpdateSchedule(storeID int, sched *schedule.Schedule) error {
+	raw, err := json.Marshal(sched)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE stores SET working_schedule = $1, working_hours = $2 WHERE id = $3`
+	result, err := s.db.Exec(query, raw, sched.String(), storeID)
+	if err != nil {
+		return fmt.Errorf("failed to update working schedule: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("store with ID %d not found", storeID)
+	}
+	return nil
+}
+
 func NewStoreRepository(db *sql.DB) StoreRepository {
 	return &storeRepository{db: db}
 }
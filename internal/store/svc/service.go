@@ -5,30 +5,53 @@ import (
 	"fmt"
 	"mime/multipart"
 	"os"
+	"time"
 
 	"github.com/ghulammuzz/backend-parkerin/config"
 	appRepo "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
+	depRepo "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/store/entity"
 	storeRepo "github.com/ghulammuzz/backend-parkerin/internal/store/repo"
 	userRepo "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
 	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/schedule"
 	"github.com/ghulammuzz/backend-parkerin/pkg/storage"
 )
 
+// ErrSubscriptionRequired is returned when a paid-only capability is used
+// without an active subscription window.
+var ErrSubscriptionRequired = errors.New("an active subscription is required")
+
+var (
+	ErrStoreNotApproved = errors.New("store has not been approved by an admin")
+	ErrReasonRequired   = errors.New("a reason is required when rejecting or suspending a store")
+	ErrInvalidSchedule  = errors.New("invalid working schedule")
+	ErrCapacityReached  = errors.New("active employments already fill the hiring capacity")
+	ErrInvalidCapacity  = errors.New("capacity must be between 1 and 50")
+)
+
 type StoreService interface {
-	ListStores(page, limit int, isHiring bool) (entity.ListStoreResponse, error)
+	ListStores(page, limit int, isHiring, openNow bool) (entity.ListStoreResponse, error)
+	ListNearbyStores(page, limit int, near entity.NearbyStoreQuery) (entity.ListStoreResponse, error)
 	GetStoreDetail(id int) (*entity.DetailStoreResponse, error)
 	DashboardStore(userId int) (*entity.DashboardStoreResponse, error)
 	GetStoreIDByUserID(userID int) (int, error)
 	UpdateIsHiring(isHiring bool, storeID int) error
+	UpdateHiringCapacity(storeID, capacity int) error
 	CheckStoreID(storeID int) (bool, error)
 	UploadStoreIMG(storeID int, img *multipart.FileHeader) error
+	RequireSubscription(storeID int) error
+	ListStoresForVerification(status string, page, limit int) (entity.AdminStoreListResponse, error)
+	UpdateVerification(storeID, adminID int, req *entity.UpdateVerificationRequest) error
+	VerificationHistory(storeID int) ([]entity.VerificationEvent, error)
+	UpdateSchedule(storeID int, sched *schedule.Schedule) error
 }
 
 type storeService struct {
 	storeRepo storeRepo.StoreRepository
 	userRepo  userRepo.UserRepository
 	appRepo   appRepo.ApplicationRepository
+	depRepo   depRepo.DepositRepository
 }
 
 func (s *storeService) UploadStoreIMG(storeID int, img *multipart.FileHeader) error {
@@ -38,10 +61,6 @@ func (s *storeService) UploadStoreIMG(storeID int, img *multipart.FileHeader) er
 	if err != nil {
 		return fmt.Errorf("failed to upload image to GCS: %w", err)
 	}
-	err = s.storeRepo.VerifiedStore(storeID)
-	if err != nil {
-		return err
-	}
 	log.Info("success to upload store img to GCS ", storeID)
 
 	err = s.storeRepo.UploadStoreIMG(storeID, storeIMG)
@@ -53,14 +72,88 @@ func (s *storeService) UploadStoreIMG(storeID int, img *multipart.FileHeader) er
 		return fmt.Errorf("failed to update repository: %w", err)
 	}
 
+	// a new photo puts a rejected store back in the moderation queue
+	_, err = s.storeRepo.UpdateVerificationStatus(storeID, entity.VerificationPending, "photo re-uploaded", nil, entity.VerificationRejected)
+	if err != nil && !errors.Is(err, storeRepo.ErrVerificationTransition) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *storeService) ListStoresForVerification(status string, page, limit int) (entity.AdminStoreListResponse, error) {
+	return s.storeRepo.ListByVerificationStatus(status, page, limit)
+}
+
+func (s *storeService) UpdateVerification(storeID, adminID int, req *entity.UpdateVerificationRequest) error {
+	if req.Status != entity.VerificationApproved && req.Reason == "" {
+		return ErrReasonRequired
+	}
+
+	allowedFrom := []string{}
+	for _, status := range []string{entity.VerificationPending, entity.VerificationApproved, entity.VerificationRejected, entity.VerificationSuspended} {
+		if status != req.Status {
+			allowedFrom = append(allowedFrom, status)
+		}
+	}
+
+	from, err := s.storeRepo.UpdateVerificationStatus(storeID, req.Status, req.Reason, &adminID, allowedFrom...)
+	if err != nil {
+		return err
+	}
+
+	if req.Status != entity.VerificationApproved {
+		if err := s.appRepo.RejectedAllApplicantsByStoreID(storeID); err != nil {
+			return err
+		}
+		if err := s.storeRepo.UpdateIsHiring(false, storeID); err != nil {
+			return err
+		}
+	}
+
+	log.Audit("store_verification", "store_id", storeID, "admin_id", adminID, "from", from, "to", req.Status, "reason", req.Reason)
 	return nil
 }
 
+func (s *storeService) VerificationHistory(storeID int) ([]entity.VerificationEvent, error) {
+	return s.storeRepo.VerificationHistory(storeID)
+}
+
 func (s *storeService) CheckStoreID(storeID int) (bool, error) {
 	return s.storeRepo.IsStoreIDValid(storeID)
 }
 
+func (s *storeService) RequireSubscription(storeID int) error {
+	_, active, err := s.storeRepo.ActiveSubscriptionUntil(storeID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrSubscriptionRequired
+	}
+	return nil
+}
+
 func (s *storeService) UpdateIsHiring(isHiring bool, storeID int) error {
+	if isHiring {
+		status, err := s.storeRepo.VerificationStatus(storeID)
+		if err != nil {
+			return err
+		}
+		if status != entity.VerificationApproved {
+			return ErrStoreNotApproved
+		}
+		if err := s.RequireSubscription(storeID); err != nil {
+			return err
+		}
+		capacity, active, err := s.storeRepo.HiringCapacity(storeID)
+		if err != nil {
+			return err
+		}
+		if active >= capacity {
+			return ErrCapacityReached
+		}
+	}
 
 	if !isHiring {
 		err := s.appRepo.RejectedAllApplicantsByStoreID(storeID)
@@ -72,20 +165,98 @@ func (s *storeService) UpdateIsHiring(isHiring bool, storeID int) error {
 	return s.storeRepo.UpdateIsHiring(isHiring, storeID)
 }
 
+func (s *storeService) UpdateHiringCapacity(storeID, capacity int) error {
+	if capacity < 1 || capacity > 50 {
+		return ErrInvalidCapacity
+	}
+	return s.storeRepo.UpdateHiringCapacity(storeID, capacity)
+}
+
 func (s *storeService) GetStoreIDByUserID(userID int) (int, error) {
 	return s.storeRepo.GetStoreIDByUserID(userID)
 }
 
-func (s *storeService) ListStores(page, limit int, isHiring bool) (entity.ListStoreResponse, error) {
-	stores, err := s.storeRepo.List(page, limit, isHiring)
+func (s *storeService) ListStores(page, limit int, isHiring, openNow bool) (entity.ListStoreResponse, error) {
+	now := time.Now()
+	var storeIDs []int
+	if openNow {
+		ids, err := s.openStoreIDs(isHiring, now)
+		if err != nil {
+			return entity.ListStoreResponse{}, err
+		}
+		storeIDs = ids
+	}
+
+	stores, err := s.storeRepo.List(page, limit, isHiring, storeIDs)
 	if err != nil {
 		return entity.ListStoreResponse{}, err
 	}
+	markOpenNow(stores.Stores, now)
 	return stores, nil
 }
 
+func (s *storeService) ListNearbyStores(page, limit int, near entity.NearbyStoreQuery) (entity.ListStoreResponse, error) {
+	now := time.Now()
+	if near.OpenNow {
+		ids, err := s.openStoreIDs(true, now)
+		if err != nil {
+			return entity.ListStoreResponse{}, err
+		}
+		near.StoreIDs = ids
+	}
+
+	stores, err := s.storeRepo.ListNearby(page, limit, near)
+	if err != nil {
+		return entity.ListStoreResponse{}, err
+	}
+	markOpenNow(stores.Stores, now)
+	return stores, nil
+}
+
+// openStoreIDs evaluates every structured schedule at now. Stores that only
+// have a free-text working_hours cannot be evaluated and never count as open.
+func (s *storeService) openStoreIDs(isHiring bool, now time.Time) ([]int, error) {
+	schedules, err := s.storeRepo.Schedules(isHiring)
+	if err != nil {
+		return nil, err
+	}
+	ids := []int{}
+	for id, sched := range schedules {
+		if sched.IsOpenAt(now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func markOpenNow(stores []entity.ListStoreSubResponse, now time.Time) {
+	for i := range stores {
+		stores[i].IsOpenNow = isOpenAt(stores[i].WorkingSchedule, now)
+	}
+}
+
+func isOpenAt(sched *schedule.Schedule, now time.Time) *bool {
+	if sched == nil {
+		return nil
+	}
+	open := sched.IsOpenAt(now)
+	return &open
+}
+
 func (s *storeService) GetStoreDetail(id int) (*entity.DetailStoreResponse, error) {
-	return s.storeRepo.Detail(id)
+	store, err := s.storeRepo.Detail(id)
+	if err != nil {
+		return nil, err
+	}
+	store.IsOpenNow = isOpenAt(store.WorkingSchedule, time.Now())
+	return store, nil
+}
+
+func (s *storeService) UpdateSchedule(storeID int, sched *schedule.Schedule) error {
+	if err := sched.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	return s.storeRepo.UpdateSchedule(storeID, sched)
 }
 
 func (s *storeService) DashboardStore(id int) (*entity.DashboardStoreResponse, error) {
@@ -98,24 +269,41 @@ func (s *storeService) DashboardStore(id int) (*entity.DashboardStoreResponse, e
 		return nil, errors.New("error repo detail by user id")
 	}
 
+	capacity, active, err := s.storeRepo.HiringCapacity(store.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	deposits, err := s.depRepo.Summary(store.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	response := &entity.DashboardStoreResponse{
-		ID:           store.ID,
-		User:         *user,
-		StoreName:    store.StoreName,
-		Address:      store.Address,
-		UrlImage:     store.UrlImage,
-		Latitude:     store.Latitude,
-		Longitude:    store.Longitude,
-		WorkingHours: store.WorkingHours,
-		IsHiring:     store.IsHiring,
-		IsPaid:       store.IsPaid,
-		CreatedAt:    store.CreatedAt,
-		IsVerified:   store.IsVerified,
+		ID:                 store.ID,
+		User:               *user,
+		StoreName:          store.StoreName,
+		Address:            store.Address,
+		UrlImage:           store.UrlImage,
+		Latitude:           store.Latitude,
+		Longitude:          store.Longitude,
+		WorkingHours:       store.WorkingHours,
+		WorkingSchedule:    store.WorkingSchedule,
+		IsOpenNow:          isOpenAt(store.WorkingSchedule, time.Now()),
+		IsHiring:           store.IsHiring,
+		HiringCapacity:     capacity,
+		ActiveEmployments:  active,
+		IsPaid:             store.IsPaid,
+		PaidUntil:          store.PaidUntil,
+		CreatedAt:          store.CreatedAt,
+		IsVerified:         store.IsVerified,
+		VerificationStatus: store.VerificationStatus,
+		Deposits:           deposits,
 	}
 
 	return response, nil
 }
 
-func NewStoreService(storeRepo storeRepo.StoreRepository, userRepo userRepo.UserRepository, appRepo appRepo.ApplicationRepository) StoreService {
-	return &storeService{storeRepo: storeRepo, userRepo: userRepo, appRepo: appRepo}
+func NewStoreService(storeRepo storeRepo.StoreRepository, userRepo userRepo.UserRepository, appRepo appRepo.ApplicationRepository, depRepo depRepo.DepositRepository) StoreService {
+	return &storeService{storeRepo: storeRepo, userRepo: userRepo, appRepo: appRepo, depRepo: depRepo}
 }
@@ -0,0 +1,25 @@
+package di
+
+import (
+	"database/sql"
+
+	attRepo "github.com/ghulammuzz/backend-parkerin/internal/attendance/repo"
+	empRepo "github.com/ghulammuzz/backend-parkerin/internal/employments/repo"
+	parkRepo "github.com/ghulammuzz/backend-parkerin/internal/parking/repo"
+	"github.com/ghulammuzz/backend-parkerin/internal/timesheets/handler"
+	tsSvc "github.com/ghulammuzz/backend-parkerin/internal/timesheets/svc"
+	"github.com/google/wire"
+)
+
+func InitializedTimesheetServiceFake(sb *sql.DB) *handler.TimesheetHandler {
+	wire.Build(
+		handler.NewTimesheetHandler,
+		tsSvc.NewTimesheetService,
+		empRepo.NewEmploymentRepository,
+		attRepo.NewAttendanceRepository,
+		parkRepo.NewParkingRepository,
+		wire.Bind(new(tsSvc.RevenueSource), new(parkRepo.ParkingRepository)),
+	)
+
+	return &handler.TimesheetHandler{}
+}
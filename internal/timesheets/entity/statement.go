@@ -0,0 +1,59 @@
+package entity
+
+const (
+	ShiftWorked      = "worked"
+	ShiftLate        = "late"
+	ShiftMissed      = "missed"
+	ShiftUpcoming    = "upcoming"
+	ShiftUnscheduled = "unscheduled"
+)
+
+// Shift is one line of a timesheet: a scheduled shift with the attendance
+// matched to it, or an attendance that matched no scheduled shift.
+type Shift struct {
+	Date           string `json:"date"`
+	Status         string `json:"status"`
+	ScheduledStart *int64 `json:"scheduled_start"`
+	ScheduledEnd   *int64 `json:"scheduled_end"`
+	AttendanceID   *int   `json:"attendance_id"`
+	CheckInAt      *int64 `json:"check_in_at"`
+	CheckOutAt     *int64 `json:"check_out_at"`
+	WorkedMinutes  int    `json:"worked_minutes"`
+	LateMinutes    int    `json:"late_minutes"`
+}
+
+// Timesheet sums a period's shifts. WorkedShifts counts only scheduled shifts
+// that were worked; UnscheduledShifts counts attendances matching none.
+type Timesheet struct {
+	ScheduledShifts   int     `json:"scheduled_shifts"`
+	WorkedShifts      int     `json:"worked_shifts"`
+	WorkedMinutes     int     `json:"worked_minutes"`
+	LateArrivals      int     `json:"late_arrivals"`
+	LateMinutes       int     `json:"late_minutes"`
+	MissedShifts      int     `json:"missed_shifts"`
+	UnscheduledShifts int     `json:"unscheduled_shifts"`
+	Shifts            []Shift `json:"shifts"`
+}
+
+// Statement is what one employment earned in a month. Amounts are Rupiah.
+type Statement struct {
+	EmploymentID    int       `json:"employment_id"`
+	StoreID         int       `json:"store_id"`
+	StoreName       string    `json:"store_name"`
+	TukangID        int       `json:"tukang_id"`
+	TukangName      string    `json:"tukang_name"`
+	Month           string    `json:"month"`
+	PayBasis        string    `json:"pay_basis"`
+	WageAmount      int64     `json:"wage_amount"`
+	FeeSplitPercent int       `json:"fee_split_percent"`
+	Revenue         int64     `json:"revenue"`
+	Earnings        int64     `json:"earnings"`
+	Timesheet       Timesheet `json:"timesheet"`
+}
+
+// MonthlySummary totals a set of statements, e.g. a store's payroll.
+type MonthlySummary struct {
+	Month      string      `json:"month"`
+	Earnings   int64       `json:"earnings"`
+	Statements []Statement `json:"statements"`
+}
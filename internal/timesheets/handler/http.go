@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	tsService "github.com/ghulammuzz/backend-parkerin/internal/timesheets/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TimesheetHandler struct {
+	tsService tsService.TimesheetService
+}
+
+func NewTimesheetHandler(tsService tsService.TimesheetService) *TimesheetHandler {
+	return &TimesheetHandler{tsService}
+}
+
+func (h *TimesheetHandler) Router(r fiber.Router) {
+	r.Get("/statements/store", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.StoreStatement)
+	r.Get("/statements/user", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang), h.UserStatement)
+}
+
+// ?month=YYYY-MM&tukang_id=; the current month and every tukang when omitted
+func (h *TimesheetHandler) StoreStatement(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	tukangID := 0
+	if raw := c.Query("tukang_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id < 1 {
+			log.Error("Invalid tukang ID", slog.String("tukang_id", raw))
+			return response.JSON(c, fiber.StatusBadRequest, "Invalid tukang ID", nil)
+		}
+		tukangID = id
+	}
+
+	summary, err := h.tsService.StoreStatement(principal.StoreID, tukangID, c.Query("month"))
+	if err != nil {
+		return statementError(c, err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Statement retrieved successfully", summary)
+}
+
+// ?month=YYYY-MM, the current month when omitted
+func (h *TimesheetHandler) UserStatement(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	summary, err := h.tsService.TukangStatement(principal.UserID, c.Query("month"))
+	if err != nil {
+		return statementError(c, err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Statement retrieved successfully", summary)
+}
+
+func statementError(c *fiber.Ctx, err error) error {
+	log.Error("Failed to build statement", slog.String("error", err.Error()))
+	if errors.Is(err, tsService.ErrInvalidMonth) {
+		return response.JSON(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+	return response.JSON(c, fiber.StatusInternalServerError, "Failed to build statement", err.Error())
+}
@@ -0,0 +1,267 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	attEntity "github.com/ghulammuzz/backend-parkerin/internal/attendance/entity"
+	attRepo "github.com/ghulammuzz/backend-parkerin/internal/attendance/repo"
+	empEntity "github.com/ghulammuzz/backend-parkerin/internal/employments/entity"
+	empRepo "github.com/ghulammuzz/backend-parkerin/internal/employments/repo"
+	tsEntity "github.com/ghulammuzz/backend-parkerin/internal/timesheets/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/schedule"
+)
+
+var ErrInvalidMonth = errors.New("invalid month, use YYYY-MM")
+
+const (
+	// lateGrace is how long after the shift starts a check-in still counts
+	// as on time.
+	lateGrace = 10 * time.Minute
+	// earlyCheckIn is how long before the shift starts a check-in is still
+	// matched to it.
+	earlyCheckIn = 2 * time.Hour
+)
+
+// jakarta is the zone statement months are expressed in (WIB, no DST).
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+// RevenueSource reports the parking revenue collected under an employment
+// within a period, used for revenue-share pay. The parking ticket ledger is
+// the implementation.
+type RevenueSource interface {
+	Revenue(employmentID int, period attEntity.Range) (int64, error)
+}
+
+type TimesheetService interface {
+	StoreStatement(storeID, tukangID int, month string) (*tsEntity.MonthlySummary, error)
+	TukangStatement(tukangID int, month string) (*tsEntity.MonthlySummary, error)
+}
+
+type timesheetService struct {
+	empRepo empRepo.EmploymentRepository
+	attRepo attRepo.AttendanceRepository
+	revenue RevenueSource
+}
+
+// StoreStatement returns the month's statements of the store's tukang, or of
+// one tukang unless tukangID is 0.
+func (s *timesheetService) StoreStatement(storeID, tukangID int, month string) (*tsEntity.MonthlySummary, error) {
+	return s.statement(storeID, tukangID, month)
+}
+
+func (s *timesheetService) TukangStatement(tukangID int, month string) (*tsEntity.MonthlySummary, error) {
+	return s.statement(0, tukangID, month)
+}
+
+func (s *timesheetService) statement(storeID, tukangID int, month string) (*tsEntity.MonthlySummary, error) {
+	start, err := parseMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	end := start.AddDate(0, 1, 0)
+
+	employments, err := s.empRepo.ListForPeriod(storeID, tukangID, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &tsEntity.MonthlySummary{
+		Month:      start.Format("2006-01"),
+		Statements: []tsEntity.Statement{},
+	}
+	now := time.Now()
+	for _, emp := range employments {
+		st, err := s.employmentStatement(emp, start, end, now)
+		if err != nil {
+			return nil, err
+		}
+		summary.Earnings += st.Earnings
+		summary.Statements = append(summary.Statements, *st)
+	}
+	return summary, nil
+}
+
+func (s *timesheetService) employmentStatement(emp empEntity.Employment, start, end, now time.Time) (*tsEntity.Statement, error) {
+	// the part of the month the employment was running
+	from, to := start, end
+	if begun, err := time.ParseInLocation(time.DateOnly, emp.StartDate, jakarta); err == nil && begun.After(from) {
+		from = begun
+	}
+	if emp.EndedAt != nil && time.Unix(*emp.EndedAt, 0).Before(to) {
+		to = time.Unix(*emp.EndedAt, 0)
+	}
+	period := attEntity.Range{From: from.Unix(), To: to.Unix()}
+
+	attendances, err := s.attRepo.ListByEmployment(emp.ID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &tsEntity.Statement{
+		EmploymentID:    emp.ID,
+		StoreID:         emp.StoreID,
+		StoreName:       emp.StoreName,
+		TukangID:        emp.TukangID,
+		TukangName:      emp.TukangName,
+		Month:           start.Format("2006-01"),
+		PayBasis:        emp.PayBasis,
+		WageAmount:      emp.WageAmount,
+		FeeSplitPercent: emp.FeeSplitPercent,
+		Timesheet:       buildTimesheet(emp.ShiftPattern, attendances, from, to, now),
+	}
+
+	switch emp.PayBasis {
+	case empEntity.PayHourly:
+		st.Earnings = emp.WageAmount * int64(st.Timesheet.WorkedMinutes) / 60
+	case empEntity.PayRevenueShare:
+		st.Revenue, err = s.revenue.Revenue(emp.ID, period)
+		if err != nil {
+			return nil, err
+		}
+		st.Earnings = st.Revenue * int64(emp.FeeSplitPercent) / 100
+	default:
+		st.Earnings = emp.WageAmount * int64(st.Timesheet.WorkedShifts)
+	}
+	return st, nil
+}
+
+// buildTimesheet matches attendances to the shifts the pattern schedules in
+// [from, to). A shift is matched by the first unused check-in between
+// earlyCheckIn before its start and its end; later check-ins in that window
+// (a tukang stepping out and back in) are folded into the same shift. Shifts
+// that ended before now without a check-in are missed.
+//
+// WorkedShifts counts matched shifts with a checked-out attendance and is
+// what per-shift pay is paid on. WorkedMinutes covers every checked-out
+// attendance, so hourly pay includes unscheduled work; an unscheduled
+// attendance earns no per-shift pay and is counted in UnscheduledShifts for
+// the store to settle separately.
+func buildTimesheet(pattern *schedule.Schedule, attendances []attEntity.Attendance, from, to, now time.Time) tsEntity.Timesheet {
+	ts := tsEntity.Timesheet{Shifts: []tsEntity.Shift{}}
+	used := make([]bool, len(attendances))
+
+	for _, att := range attendances {
+		if att.WorkedMinutes != nil {
+			ts.WorkedMinutes += *att.WorkedMinutes
+		}
+	}
+
+	if pattern != nil {
+		loc := pattern.Location()
+		first := from.In(loc)
+		day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+		for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+			for _, span := range pattern.SpansOn(day) {
+				if span.Start.Before(from) || !span.Start.Before(to) {
+					continue
+				}
+				ts.ScheduledShifts++
+				shift := scheduledShift(day, span)
+
+				checkedOut := false
+				for i, att := range attendances {
+					checkIn := time.Unix(att.CheckInAt, 0)
+					if used[i] || checkIn.Before(span.Start.Add(-earlyCheckIn)) || !checkIn.Before(span.End) {
+						continue
+					}
+					used[i] = true
+					checkedOut = checkedOut || att.WorkedMinutes != nil
+					if shift.Status != "" {
+						addAttendance(&shift, att)
+						continue
+					}
+					fillAttendance(&shift, att)
+					shift.Status = tsEntity.ShiftWorked
+					if late := checkIn.Sub(span.Start); late > lateGrace {
+						shift.Status = tsEntity.ShiftLate
+						shift.LateMinutes = int(late / time.Minute)
+						ts.LateArrivals++
+						ts.LateMinutes += shift.LateMinutes
+					}
+				}
+
+				switch {
+				case shift.Status != "":
+					if checkedOut {
+						ts.WorkedShifts++
+					}
+				case span.End.Before(now):
+					shift.Status = tsEntity.ShiftMissed
+					ts.MissedShifts++
+				default:
+					shift.Status = tsEntity.ShiftUpcoming
+				}
+				ts.Shifts = append(ts.Shifts, shift)
+			}
+		}
+	}
+
+	for i, att := range attendances {
+		if used[i] {
+			continue
+		}
+		shift := tsEntity.Shift{
+			Date:   time.Unix(att.CheckInAt, 0).In(jakarta).Format(time.DateOnly),
+			Status: tsEntity.ShiftUnscheduled,
+		}
+		fillAttendance(&shift, att)
+		ts.UnscheduledShifts++
+		ts.Shifts = append(ts.Shifts, shift)
+	}
+
+	sort.SliceStable(ts.Shifts, func(i, j int) bool { return ts.Shifts[i].Date < ts.Shifts[j].Date })
+	return ts
+}
+
+func scheduledShift(day time.Time, span schedule.Span) tsEntity.Shift {
+	start, end := span.Start.Unix(), span.End.Unix()
+	return tsEntity.Shift{
+		Date:           day.Format(time.DateOnly),
+		ScheduledStart: &start,
+		ScheduledEnd:   &end,
+	}
+}
+
+func fillAttendance(shift *tsEntity.Shift, att attEntity.Attendance) {
+	id, checkIn := att.ID, att.CheckInAt
+	shift.AttendanceID = &id
+	shift.CheckInAt = &checkIn
+	shift.CheckOutAt = att.CheckOutAt
+	if att.WorkedMinutes != nil {
+		shift.WorkedMinutes = *att.WorkedMinutes
+	}
+}
+
+// addAttendance folds a repeat check-in into an already matched shift: its
+// minutes are added and the shift closes with its check-out.
+func addAttendance(shift *tsEntity.Shift, att attEntity.Attendance) {
+	shift.CheckOutAt = att.CheckOutAt
+	if att.WorkedMinutes != nil {
+		shift.WorkedMinutes += *att.WorkedMinutes
+	}
+}
+
+// parseMonth reads YYYY-MM as the first instant of that month in WIB; an
+// empty month is the current one.
+func parseMonth(month string) (time.Time, error) {
+	if month == "" {
+		now := time.Now().In(jakarta)
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, jakarta), nil
+	}
+	start, err := time.ParseInLocation("2006-01", month, jakarta)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidMonth, err)
+	}
+	return start, nil
+}
+
+func NewTimesheetService(empRepo empRepo.EmploymentRepository, attRepo attRepo.AttendanceRepository, revenue RevenueSource) TimesheetService {
+	return &timesheetService{
+		empRepo: empRepo,
+		attRepo: attRepo,
+		revenue: revenue,
+	}
+}
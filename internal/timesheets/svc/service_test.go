@@ -0,0 +1,222 @@
+package svc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	attEntity "github.com/ghulammuzz/backend-parkerin/internal/attendance/entity"
+	tsEntity "github.com/ghulammuzz/backend-parkerin/internal/timesheets/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/schedule"
+)
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, jakarta)
+}
+
+func everyDay(open, close string) *schedule.Schedule {
+	days := map[string][]schedule.Range{}
+	for _, d := range []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"} {
+		days[d] = []schedule.Range{{Open: open, Close: close}}
+	}
+	return &schedule.Schedule{Timezone: schedule.DefaultTimezone, Days: days}
+}
+
+// attended is a checked-out attendance; a zero out leaves it open.
+func attended(id int, in, out time.Time) attEntity.Attendance {
+	att := attEntity.Attendance{ID: id, CheckInAt: in.Unix()}
+	if !out.IsZero() {
+		checkOut := out.Unix()
+		minutes := int(out.Sub(in) / time.Minute)
+		att.CheckOutAt = &checkOut
+		att.WorkedMinutes = &minutes
+	}
+	return att
+}
+
+func TestBuildTimesheet(t *testing.T) {
+	day := everyDay("08:00", "16:00")
+	night := everyDay("22:00", "06:00")
+	// Mon 5 and Tue 6 January, looked at from the following month
+	from, to, later := at(time.January, 5, 0, 0), at(time.January, 7, 0, 0), at(time.February, 1, 0, 0)
+
+	tests := []struct {
+		name        string
+		pattern     *schedule.Schedule
+		attendances []attEntity.Attendance
+		from, to    time.Time
+		now         time.Time
+		want        tsEntity.Timesheet
+		statuses    []string
+	}{
+		{
+			name:        "on time check-in matches the shift",
+			pattern:     day,
+			attendances: []attEntity.Attendance{attended(1, at(time.January, 5, 7, 55), at(time.January, 5, 16, 0))},
+			from:        from,
+			to:          to,
+			now:         later,
+			want:        tsEntity.Timesheet{ScheduledShifts: 2, WorkedShifts: 1, WorkedMinutes: 485, MissedShifts: 1},
+			statuses:    []string{tsEntity.ShiftWorked, tsEntity.ShiftMissed},
+		},
+		{
+			name:        "check-in within the grace is on time",
+			pattern:     day,
+			attendances: []attEntity.Attendance{attended(1, at(time.January, 5, 8, 10), at(time.January, 5, 16, 0))},
+			from:        from,
+			to:          to,
+			now:         later,
+			want:        tsEntity.Timesheet{ScheduledShifts: 2, WorkedShifts: 1, WorkedMinutes: 470, MissedShifts: 1},
+			statuses:    []string{tsEntity.ShiftWorked, tsEntity.ShiftMissed},
+		},
+		{
+			name:        "check-in past the grace is late",
+			pattern:     day,
+			attendances: []attEntity.Attendance{attended(1, at(time.January, 5, 8, 30), at(time.January, 5, 16, 0))},
+			from:        from,
+			to:          to,
+			now:         later,
+			want:        tsEntity.Timesheet{ScheduledShifts: 2, WorkedShifts: 1, WorkedMinutes: 450, LateArrivals: 1, LateMinutes: 30, MissedShifts: 1},
+			statuses:    []string{tsEntity.ShiftLate, tsEntity.ShiftMissed},
+		},
+		{
+			name:     "no attendance misses every ended shift",
+			pattern:  day,
+			from:     from,
+			to:       to,
+			now:      later,
+			want:     tsEntity.Timesheet{ScheduledShifts: 2, MissedShifts: 2},
+			statuses: []string{tsEntity.ShiftMissed, tsEntity.ShiftMissed},
+		},
+		{
+			name:        "shift that has not ended is upcoming",
+			pattern:     day,
+			attendances: []attEntity.Attendance{attended(1, at(time.January, 5, 8, 0), at(time.January, 5, 16, 0))},
+			from:        from,
+			to:          to,
+			now:         at(time.January, 6, 12, 0),
+			want:        tsEntity.Timesheet{ScheduledShifts: 2, WorkedShifts: 1, WorkedMinutes: 480},
+			statuses:    []string{tsEntity.ShiftWorked, tsEntity.ShiftUpcoming},
+		},
+		{
+			name:    "repeat check-ins fold into one worked shift",
+			pattern: day,
+			attendances: []attEntity.Attendance{
+				attended(1, at(time.January, 5, 8, 0), at(time.January, 5, 12, 0)),
+				attended(2, at(time.January, 5, 13, 0), at(time.January, 5, 16, 0)),
+				attended(3, at(time.January, 5, 15, 0), at(time.January, 5, 15, 30)),
+			},
+			from:     from,
+			to:       to,
+			now:      later,
+			want:     tsEntity.Timesheet{ScheduledShifts: 2, WorkedShifts: 1, WorkedMinutes: 450, MissedShifts: 1},
+			statuses: []string{tsEntity.ShiftWorked, tsEntity.ShiftMissed},
+		},
+		{
+			name:        "open attendance is not a worked shift yet",
+			pattern:     day,
+			attendances: []attEntity.Attendance{attended(1, at(time.January, 5, 8, 0), time.Time{})},
+			from:        from,
+			to:          to,
+			now:         later,
+			want:        tsEntity.Timesheet{ScheduledShifts: 2, MissedShifts: 1},
+			statuses:    []string{tsEntity.ShiftWorked, tsEntity.ShiftMissed},
+		},
+		{
+			name:    "unscheduled attendance adds minutes, not shifts",
+			pattern: day,
+			attendances: []attEntity.Attendance{
+				attended(1, at(time.January, 5, 8, 0), at(time.January, 5, 16, 0)),
+				attended(2, at(time.January, 5, 18, 0), at(time.January, 5, 20, 0)),
+			},
+			from:     from,
+			to:       to,
+			now:      later,
+			want:     tsEntity.Timesheet{ScheduledShifts: 2, WorkedShifts: 1, WorkedMinutes: 600, MissedShifts: 1, UnscheduledShifts: 1},
+			statuses: []string{tsEntity.ShiftWorked, tsEntity.ShiftUnscheduled, tsEntity.ShiftMissed},
+		},
+		{
+			name:        "check-in too early for the shift is unscheduled",
+			pattern:     day,
+			attendances: []attEntity.Attendance{attended(1, at(time.January, 5, 5, 0), at(time.January, 5, 7, 0))},
+			from:        from,
+			to:          to,
+			now:         later,
+			want:        tsEntity.Timesheet{ScheduledShifts: 2, WorkedMinutes: 120, MissedShifts: 2, UnscheduledShifts: 1},
+			statuses:    []string{tsEntity.ShiftMissed, tsEntity.ShiftUnscheduled, tsEntity.ShiftMissed},
+		},
+		{
+			name:        "without a pattern every attendance is unscheduled",
+			attendances: []attEntity.Attendance{attended(1, at(time.January, 5, 8, 0), at(time.January, 5, 16, 0))},
+			from:        from,
+			to:          to,
+			now:         later,
+			want:        tsEntity.Timesheet{WorkedMinutes: 480, UnscheduledShifts: 1},
+			statuses:    []string{tsEntity.ShiftUnscheduled},
+		},
+		{
+			name:    "night shift belongs to the month it starts in",
+			pattern: night,
+			attendances: []attEntity.Attendance{
+				// the tail of the 30 January shift, started before the period
+				attended(1, at(time.January, 31, 0, 30), at(time.January, 31, 6, 0)),
+				attended(2, at(time.January, 31, 21, 50), at(time.February, 1, 6, 0)),
+			},
+			from:     at(time.January, 31, 0, 0),
+			to:       at(time.February, 1, 0, 0),
+			now:      later.AddDate(0, 0, 1),
+			want:     tsEntity.Timesheet{ScheduledShifts: 1, WorkedShifts: 1, WorkedMinutes: 330 + 490, UnscheduledShifts: 1},
+			statuses: []string{tsEntity.ShiftWorked, tsEntity.ShiftUnscheduled},
+		},
+		{
+			name:     "employment ending mid-month stops scheduling",
+			pattern:  day,
+			from:     from,
+			to:       at(time.January, 6, 0, 0),
+			now:      later,
+			want:     tsEntity.Timesheet{ScheduledShifts: 1, MissedShifts: 1},
+			statuses: []string{tsEntity.ShiftMissed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildTimesheet(tt.pattern, tt.attendances, tt.from, tt.to, tt.now)
+
+			var statuses []string
+			for _, s := range got.Shifts {
+				statuses = append(statuses, s.Status)
+			}
+			if !reflect.DeepEqual(statuses, tt.statuses) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.statuses)
+			}
+
+			got.Shifts = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("timesheet = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTimesheetFoldsRepeatCheckIns(t *testing.T) {
+	attendances := []attEntity.Attendance{
+		attended(1, at(time.January, 5, 8, 0), at(time.January, 5, 12, 0)),
+		attended(2, at(time.January, 5, 13, 0), at(time.January, 5, 16, 0)),
+	}
+	ts := buildTimesheet(everyDay("08:00", "16:00"), attendances, at(time.January, 5, 0, 0), at(time.January, 6, 0, 0), at(time.February, 1, 0, 0))
+
+	if len(ts.Shifts) != 1 {
+		t.Fatalf("got %d shifts, want 1", len(ts.Shifts))
+	}
+	shift := ts.Shifts[0]
+	if shift.AttendanceID == nil || *shift.AttendanceID != 1 {
+		t.Errorf("attendance = %v, want the first check-in", shift.AttendanceID)
+	}
+	if shift.CheckOutAt == nil || *shift.CheckOutAt != at(time.January, 5, 16, 0).Unix() {
+		t.Errorf("check-out = %v, want the last check-out", shift.CheckOutAt)
+	}
+	if shift.WorkedMinutes != 420 {
+		t.Errorf("worked minutes = %d, want 420", shift.WorkedMinutes)
+	}
+}
@@ -3,18 +3,26 @@ package di
 import (
 	"database/sql"
 
+	depRepo "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
+	otpRepo "github.com/ghulammuzz/backend-parkerin/internal/otp/repo"
+	otpSvc "github.com/ghulammuzz/backend-parkerin/internal/otp/svc"
 	"github.com/ghulammuzz/backend-parkerin/internal/users/handler"
 	"github.com/ghulammuzz/backend-parkerin/internal/users/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/users/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/sms"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/wire"
 )
 
-func InitializedUsersServiceFake(sb *sql.DB, val *validator.Validate) *handler.UserHandler {
+func InitializedUsersServiceFake(sb *sql.DB, val *validator.Validate, sender sms.Sender) *handler.UserHandler {
 	wire.Build(
 		handler.NewUserHandler,
 		svc.NewUserService,
 		repo.NewUserRepository,
+		repo.NewTokenRepository,
+		otpSvc.NewOTPService,
+		otpRepo.NewOTPRepository,
+		depRepo.NewDepositRepository,
 	)
 
 	return &handler.UserHandler{}
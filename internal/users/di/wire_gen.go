@@ -8,17 +8,25 @@ package di
 
 import (
 	"database/sql"
+	repo3 "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
+	repo2 "github.com/ghulammuzz/backend-parkerin/internal/otp/repo"
+	svc2 "github.com/ghulammuzz/backend-parkerin/internal/otp/svc"
 	"github.com/ghulammuzz/backend-parkerin/internal/users/handler"
 	"github.com/ghulammuzz/backend-parkerin/internal/users/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/users/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/sms"
 	"github.com/go-playground/validator/v10"
 )
 
 // Injectors from wire.go:
 
-func InitializedUsersService(sb *sql.DB, val *validator.Validate) *handler.UserHandler {
+func InitializedUsersService(sb *sql.DB, val *validator.Validate, sender sms.Sender) *handler.UserHandler {
 	userRepository := repo.NewUserRepository(sb)
-	userService := svc.NewUserService(userRepository)
+	tokenRepository := repo.NewTokenRepository(sb)
+	otpRepository := repo2.NewOTPRepository(sb)
+	otpService := svc2.NewOTPService(otpRepository, sender)
+	depositRepository := repo3.NewDepositRepository(sb)
+	userService := svc.NewUserService(userRepository, tokenRepository, otpService, depositRepository)
 	userHandler := handler.NewUserHandler(userService, val)
 	return userHandler
 }
@@ -0,0 +1,30 @@
+package entity
+
+type RefreshToken struct {
+	ID              int
+	UserID          int
+	Role            string
+	FamilyID        string
+	TokenHash       string
+	AccessJTI       string
+	AccessExpiresAt int64
+	ExpiresAt       int64
+	RevokedAt       *int64
+	CreatedAt       int64
+}
+
+// req
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	All bool `json:"all"`
+}
+
+// res
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
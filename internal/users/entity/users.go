@@ -1,5 +1,7 @@
 package entity
 
+import "github.com/ghulammuzz/backend-parkerin/pkg/schedule"
+
 // req
 type UserRegisterRequest struct {
 	ID           int      `json:"id"`
@@ -13,7 +15,10 @@ type UserRegisterRequest struct {
 	Latitude     *float64 `json:"latitude,omitempty" validate:"omitempty"`
 	Longitude    *float64 `json:"longitude,omitempty" validate:"omitempty"`
 	WorkingHours *string  `json:"working_hours,omitempty" validate:"omitempty,min=5,max=100"`
-	IsVerified   bool     `json:"is_verified"`
+	// WorkingSchedule takes precedence over WorkingHours, which is then
+	// filled with its rendering.
+	WorkingSchedule *schedule.Schedule `json:"working_schedule,omitempty"`
+	IsVerified      bool               `json:"is_verified"`
 }
 
 // -7.968437, 112.596530
@@ -23,6 +28,16 @@ type UserLoginRequest struct {
 	Password    string `json:"password" validate:"required,min=8"`
 }
 
+type PasswordResetRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,e164"`
+}
+
+type PasswordResetConfirmRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,e164"`
+	Code        string `json:"code" validate:"required,len=6,numeric"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
 // res
 type UserJWT struct {
 	ID          int    `json:"id"`
@@ -30,6 +45,7 @@ type UserJWT struct {
 	Name        string `json:"name"`
 	Password    string `json:"password"`
 	Role        string `json:"role"`
+	IsVerified  bool   `json:"is_verified"`
 }
 
 type StoreJWT struct {
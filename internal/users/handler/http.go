@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	otpEntity "github.com/ghulammuzz/backend-parkerin/internal/otp/entity"
+	otpSvc "github.com/ghulammuzz/backend-parkerin/internal/otp/svc"
 	"github.com/ghulammuzz/backend-parkerin/internal/users/entity"
+	"github.com/ghulammuzz/backend-parkerin/internal/users/repo"
 	"github.com/ghulammuzz/backend-parkerin/internal/users/svc"
 	"github.com/ghulammuzz/backend-parkerin/pkg/form"
 	"github.com/ghulammuzz/backend-parkerin/pkg/log"
@@ -27,9 +30,15 @@ func (h *UserHandler) Router(r fiber.Router) {
 	r.Post("/user/register", h.RegisterUser)
 	r.Post("/user/login", h.LoginUser)
 	r.Post("/store/login", h.LoginStore)
+	r.Post("/user/otp", h.SendVerificationCode)
+	r.Post("/user/verify", h.VerifyPhone)
+	r.Post("/user/password-reset/request", h.RequestPasswordReset)
+	r.Post("/user/password-reset/confirm", h.ConfirmPasswordReset)
+	r.Post("/user/refresh", h.RefreshToken)
+	r.Post("/user/logout", middleware.JWTProtected(), h.Logout)
 	r.Get("/users", h.ListUser)
 	r.Get("/users/:id", h.DetailUser)
-	r.Get("/user-dashboard", middleware.JWTProtected(), h.DashboardUser)
+	r.Get("/user-dashboard", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleTukang, middleware.RoleStore), h.DashboardUser)
 }
 
 func (h *UserHandler) RegisterUser(c *fiber.Ctx) error {
@@ -54,9 +63,17 @@ func (h *UserHandler) RegisterUser(c *fiber.Ctx) error {
 		return response.JSON(c, 400, "Phone number already registered", nil)
 	}
 	if user.Role == "store" {
-		if user.StoreName == nil || user.Address == nil || user.Latitude == nil || user.Longitude == nil || user.WorkingHours == nil {
+		if user.StoreName == nil || user.Address == nil || user.Latitude == nil || user.Longitude == nil || (user.WorkingHours == nil && user.WorkingSchedule == nil) {
 			log.Error("Validation failed for store role: store_name, address, working_hours, latitude, and longitude are required") // Log error
-			return response.JSON(c, 400, "Validation failed", "store_name, address, working_hours, latitude, and longitude are required for store role")
+			return response.JSON(c, 400, "Validation failed", "store_name, address, working_hours or working_schedule, latitude, and longitude are required for store role")
+		}
+		if user.WorkingSchedule != nil {
+			if err := user.WorkingSchedule.Validate(); err != nil {
+				log.Error("Invalid working schedule: %v", err)
+				return response.JSON(c, 400, "Validation failed", err.Error())
+			}
+			workingHours := user.WorkingSchedule.String()
+			user.WorkingHours = &workingHours
 		}
 	}
 
@@ -65,7 +82,12 @@ func (h *UserHandler) RegisterUser(c *fiber.Ctx) error {
 		return response.JSON(c, 500, "register svc error", err.Error())
 	}
 
-	return response.JSON(c, 201, "User registered successfully", nil)
+	// the account exists either way; a failed send can be retried via /user/otp
+	if err := h.userService.SendVerificationCode(user.PhoneNumber, c.IP()); err != nil {
+		log.Error("Error sending verification code: %v", err)
+	}
+
+	return response.JSON(c, 201, "User registered successfully, verification code sent", nil)
 }
 
 func (h *UserHandler) LoginUser(c *fiber.Ctx) error {
@@ -78,6 +100,9 @@ func (h *UserHandler) LoginUser(c *fiber.Ctx) error {
 	token, err := h.userService.LoginUser(loginRequest)
 	if err != nil {
 		log.Error("Login failed: %v", err)
+		if errors.Is(err, svc.ErrPhoneNotVerified) {
+			return response.JSON(c, 403, "Login failed", err.Error())
+		}
 		return response.JSON(c, 401, "Login failed", err.Error())
 	}
 
@@ -94,22 +119,169 @@ func (h *UserHandler) LoginStore(c *fiber.Ctx) error {
 	token, err := h.userService.LoginStore(loginRequest)
 	if err != nil {
 		log.Error("Store login failed: %v", err)
+		if errors.Is(err, svc.ErrPhoneNotVerified) {
+			return response.JSON(c, 403, "Login failed", err.Error())
+		}
 		return response.JSON(c, 401, "Login failed", err.Error())
 	}
 
 	return response.JSON(c, 200, "Login successful", token)
 }
 
-func (h *UserHandler) DashboardUser(c *fiber.Ctx) error {
-	userToken := c.Locals("user").(*jwt.Token)
+func (h *UserHandler) SendVerificationCode(c *fiber.Ctx) error {
+	req := new(otpEntity.SendOTPRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Error parsing otp request body: %v", err)
+		return response.JSON(c, 400, "Invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed: %v", validationErrors)
+		return response.JSON(c, 400, "Validation failed", validationErrors)
+	}
+
+	if err := h.userService.SendVerificationCode(req.PhoneNumber, c.IP()); err != nil {
+		log.Error("Error sending verification code: %v", err)
+		switch {
+		case errors.Is(err, otpSvc.ErrOTPRateLimited):
+			return response.JSON(c, 429, err.Error(), nil)
+		case errors.Is(err, svc.ErrPhoneAlreadyVerified):
+			return response.JSON(c, 400, err.Error(), nil)
+		case errors.Is(err, repo.ErrUserNotFound):
+			return response.JSON(c, 404, "Phone number is not registered", nil)
+		}
+		return response.JSON(c, 500, "Error sending verification code", err.Error())
+	}
+
+	return response.JSON(c, 200, "Verification code sent", nil)
+}
+
+func (h *UserHandler) VerifyPhone(c *fiber.Ctx) error {
+	req := new(otpEntity.VerifyOTPRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Error parsing verify request body: %v", err)
+		return response.JSON(c, 400, "Invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed: %v", validationErrors)
+		return response.JSON(c, 400, "Validation failed", validationErrors)
+	}
+
+	token, err := h.userService.VerifyPhone(req.PhoneNumber, req.Code)
+	if err != nil {
+		log.Error("Phone verification failed: %v", err)
+		if errors.Is(err, otpSvc.ErrOTPInvalid) || errors.Is(err, otpSvc.ErrOTPTooManyAttempts) {
+			return response.JSON(c, 400, "Verification failed", err.Error())
+		}
+		return response.JSON(c, 500, "Verification failed", err.Error())
+	}
+
+	return response.JSON(c, 200, "Phone number verified", token)
+}
+
+func (h *UserHandler) RequestPasswordReset(c *fiber.Ctx) error {
+	req := new(entity.PasswordResetRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Error parsing password reset request body: %v", err)
+		return response.JSON(c, 400, "Invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed: %v", validationErrors)
+		return response.JSON(c, 400, "Validation failed", validationErrors)
+	}
+
+	if err := h.userService.RequestPasswordReset(req.PhoneNumber, c.IP()); err != nil {
+		log.Error("Error requesting password reset: %v", err)
+		if errors.Is(err, otpSvc.ErrOTPRateLimited) {
+			return response.JSON(c, 429, err.Error(), nil)
+		}
+		return response.JSON(c, 500, "Error requesting password reset", err.Error())
+	}
+
+	return response.JSON(c, 200, "If the number is registered, a reset code has been sent", nil)
+}
 
-	claims, ok := userToken.Claims.(jwt.MapClaims)
-	if !ok || !userToken.Valid {
+func (h *UserHandler) ConfirmPasswordReset(c *fiber.Ctx) error {
+	req := new(entity.PasswordResetConfirmRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Error parsing password reset confirm body: %v", err)
+		return response.JSON(c, 400, "Invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed: %v", validationErrors)
+		return response.JSON(c, 400, "Validation failed", validationErrors)
+	}
+
+	if err := h.userService.ConfirmPasswordReset(req, c.IP()); err != nil {
+		log.Error("Password reset failed: %v", err)
+		if errors.Is(err, otpSvc.ErrOTPInvalid) || errors.Is(err, otpSvc.ErrOTPTooManyAttempts) {
+			return response.JSON(c, 400, "Password reset failed", err.Error())
+		}
+		return response.JSON(c, 500, "Password reset failed", err.Error())
+	}
+
+	return response.JSON(c, 200, "Password updated, please log in again", nil)
+}
+
+func (h *UserHandler) RefreshToken(c *fiber.Ctx) error {
+	req := new(entity.RefreshTokenRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Error parsing refresh request body: %v", err)
+		return response.JSON(c, 400, "Invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed: %v", validationErrors)
+		return response.JSON(c, 400, "Validation failed", validationErrors)
+	}
+
+	token, err := h.userService.RefreshToken(req.RefreshToken)
+	if err != nil {
+		log.Error("Refresh failed: %v", err)
+		if errors.Is(err, repo.ErrRefreshTokenInvalid) || errors.Is(err, repo.ErrRefreshTokenReused) {
+			return response.JSON(c, 401, "Refresh failed", err.Error())
+		}
+		return response.JSON(c, 500, "Refresh failed", err.Error())
+	}
+
+	return response.JSON(c, 200, "Token refreshed", token)
+}
+
+func (h *UserHandler) Logout(c *fiber.Ctx) error {
+	req := new(entity.LogoutRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			log.Error("Error parsing logout request body: %v", err)
+			return response.JSON(c, 400, "Invalid payload", err.Error())
+		}
+	}
+
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
 		log.Error("Invalid token")
 		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
 	}
 
-	userID := int(claims["user_id"].(float64))
+	userID := principal.UserID
+	if err := h.userService.Logout(userID, principal.JTI, principal.ExpiresAt, req.All); err != nil {
+		log.Error("Logout failed: %v", err)
+		return response.JSON(c, 500, "Logout failed", err.Error())
+	}
+
+	return response.JSON(c, 200, "Logout successful", nil)
+}
+
+func (h *UserHandler) DashboardUser(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	userID := principal.UserID
 
 	user, err := h.userService.GetUserDetails(userID)
 	if err != nil {
@@ -117,12 +289,23 @@ func (h *UserHandler) DashboardUser(c *fiber.Ctx) error {
 		return response.JSON(c, fiber.StatusInternalServerError, "Failed to fetch user details", err.Error())
 	}
 
-	return response.JSON(c, fiber.StatusOK, "User details", fiber.Map{
+	dashboard := fiber.Map{
 		"id":           user.ID,
 		"name":         user.Name,
 		"phone_number": user.PhoneNumber,
 		"role":         user.Role,
-	})
+	}
+
+	if principal.Role == middleware.RoleTukang {
+		deposits, err := h.userService.DepositSummary(userID)
+		if err != nil {
+			log.Error("Failed to fetch deposit summary", "error", err.Error())
+			return response.JSON(c, fiber.StatusInternalServerError, "Failed to fetch deposit summary", err.Error())
+		}
+		dashboard["deposits"] = deposits
+	}
+
+	return response.JSON(c, fiber.StatusOK, "User details", dashboard)
 }
 
 func (h *UserHandler) ListUser(c *fiber.Ctx) error {
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -9,6 +10,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
 type UserRepository interface {
 	List(page, limit int) (*userEntity.UserListResponse, error)
 	Create(user *userEntity.UserRegisterRequest) error
@@ -17,6 +20,10 @@ type UserRepository interface {
 	LoginStore(user *userEntity.UserLoginRequest) (*userEntity.StoreJWT, error)
 	IsPhoneNumberExists(phoneNumber string) (bool, error)
 	IsUserIDValid(userID int) (bool, error)
+	IsPhoneNumberVerified(phoneNumber string) (bool, error)
+	MarkVerified(phoneNumber string) (*userEntity.UserJWT, error)
+	UpdatePassword(phoneNumber, hashedPassword string) (int, error)
+	StoreIDByUserID(userID int) (int, error)
 }
 
 type userRepository struct {
@@ -90,7 +97,7 @@ func (r *userRepository) LoginStore(user *userEntity.UserLoginRequest) (*userEnt
 	err := r.db.QueryRow(query, user.PhoneNumber).Scan(&storeUser.ID, &storeUser.PhoneNumber, &storeUser.Name, &storeUser.Password, &storeUser.Role)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -116,13 +123,13 @@ func (r *userRepository) LoginStore(user *userEntity.UserLoginRequest) (*userEnt
 }
 
 func (r *userRepository) LoginUser(user *userEntity.UserLoginRequest) (*userEntity.UserJWT, error) {
-	query := `SELECT id, phone_number, name, password, role FROM users WHERE phone_number = $1`
+	query := `SELECT id, phone_number, name, password, role, is_verified FROM users WHERE phone_number = $1`
 	dbUser := &userEntity.UserJWT{}
 
-	err := r.db.QueryRow(query, user.PhoneNumber).Scan(&dbUser.ID, &dbUser.PhoneNumber, &dbUser.Name, &dbUser.Password, &dbUser.Role)
+	err := r.db.QueryRow(query, user.PhoneNumber).Scan(&dbUser.ID, &dbUser.PhoneNumber, &dbUser.Name, &dbUser.Password, &dbUser.Role, &dbUser.IsVerified)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -160,8 +167,18 @@ func (r *userRepository) Create(user *userEntity.UserRegisterRequest) error {
 	}
 
 	if user.Role == "store" {
-		storeQuery := `INSERT INTO stores (user_id, store_name, address, latitude, longitude, created_at, working_hours) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-		_, err := tx.Exec(storeQuery, user.ID, user.StoreName, user.Address, user.Latitude, user.Longitude, user.CreatedAt, user.WorkingHours)
+		// nil keeps working_schedule NULL for free-text-only registrations
+		var workingSchedule interface{}
+		if user.WorkingSchedule != nil {
+			raw, err := json.Marshal(user.WorkingSchedule)
+			if err != nil {
+				return err
+			}
+			workingSchedule = raw
+		}
+
+		storeQuery := `INSERT INTO stores (user_id, store_name, address, latitude, longitude, created_at, working_hours, working_schedule) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+		_, err := tx.Exec(storeQuery, user.ID, user.StoreName, user.Address, user.Latitude, user.Longitude, user.CreatedAt, user.WorkingHours, workingSchedule)
 		if err != nil {
 			return err
 		}
@@ -176,7 +193,7 @@ func (r *userRepository) Detail(userID int) (*userEntity.UserDetailResponse, err
 	err := r.db.QueryRow(query, userID).Scan(&user.ID, &user.Name, &user.PhoneNumber, &user.Role)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -193,6 +210,58 @@ func (r *userRepository) IsPhoneNumberExists(phoneNumber string) (bool, error) {
 	return exists, nil
 }
 
+func (r *userRepository) IsPhoneNumberVerified(phoneNumber string) (bool, error) {
+	var verified bool
+	query := `SELECT is_verified FROM users WHERE phone_number = $1`
+	err := r.db.QueryRow(query, phoneNumber).Scan(&verified)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrUserNotFound
+		}
+		return false, err
+	}
+	return verified, nil
+}
+
+func (r *userRepository) MarkVerified(phoneNumber string) (*userEntity.UserJWT, error) {
+	query := `UPDATE users SET is_verified = true WHERE phone_number = $1 RETURNING id, phone_number, name, role, is_verified`
+	dbUser := &userEntity.UserJWT{}
+	err := r.db.QueryRow(query, phoneNumber).Scan(&dbUser.ID, &dbUser.PhoneNumber, &dbUser.Name, &dbUser.Role, &dbUser.IsVerified)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return dbUser, nil
+}
+
+func (r *userRepository) UpdatePassword(phoneNumber, hashedPassword string) (int, error) {
+	var userID int
+	query := `UPDATE users SET password = $1 WHERE phone_number = $2 RETURNING id`
+	err := r.db.QueryRow(query, hashedPassword, phoneNumber).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrUserNotFound
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (r *userRepository) StoreIDByUserID(userID int) (int, error) {
+	var storeID int
+	query := `SELECT id FROM stores WHERE user_id = $1`
+	err := r.db.QueryRow(query, userID).Scan(&storeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("store data not found")
+		}
+		return 0, err
+	}
+	return storeID, nil
+}
+
 func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{db: db}
 }
@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	userEntity "github.com/ghulammuzz/backend-parkerin/internal/users/entity"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+type TokenRepository interface {
+	Create(token *userEntity.RefreshToken) error
+	Rotate(oldHash string, next *userEntity.RefreshToken) error
+	RevokeSession(userID int, accessJTI string, accessExpiresAt int64) error
+	RevokeAllForUser(userID int) error
+	IsRevoked(jti string) (bool, error)
+}
+
+type tokenRepository struct {
+	db *sql.DB
+}
+
+func (r *tokenRepository) Create(token *userEntity.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, access_jti, access_expires_at, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	token.CreatedAt = time.Now().Unix()
+	err := r.db.QueryRow(query, token.UserID, token.FamilyID, token.TokenHash, token.AccessJTI,
+		token.AccessExpiresAt, token.ExpiresAt, token.CreatedAt).Scan(&token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// Rotate swaps the refresh token identified by oldHash for next, which joins
+// the same family and inherits its user. Presenting a token that was already
+// rotated or revoked means it leaked, so the whole family is killed and
+// ErrRefreshTokenReused is returned.
+func (r *tokenRepository) Rotate(oldHash string, next *userEntity.RefreshToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var old userEntity.RefreshToken
+	var revokedAt sql.NullInt64
+	query := `
+		SELECT rt.id, rt.user_id, u.role, rt.family_id, rt.expires_at, rt.revoked_at
+		FROM refresh_tokens rt
+		JOIN users u ON rt.user_id = u.id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt
+	`
+	err = tx.QueryRow(query, oldHash).Scan(&old.ID, &old.UserID, &old.Role, &old.FamilyID, &old.ExpiresAt, &revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrRefreshTokenInvalid
+		}
+		return err
+	}
+
+	now := time.Now().Unix()
+	if revokedAt.Valid {
+		if err := revokeFamilies(tx, `family_id = $1`, old.FamilyID, now); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+	if old.ExpiresAt <= now {
+		return ErrRefreshTokenInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = $1 WHERE id = $2`, now, old.ID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	next.UserID = old.UserID
+	next.Role = old.Role
+	next.FamilyID = old.FamilyID
+	next.CreatedAt = now
+	insertQuery := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, access_jti, access_expires_at, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	err = tx.QueryRow(insertQuery, next.UserID, next.FamilyID, next.TokenHash, next.AccessJTI,
+		next.AccessExpiresAt, next.ExpiresAt, next.CreatedAt).Scan(&next.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RevokeSession deny-lists the given access token and kills the refresh token
+// family it was issued with.
+func (r *tokenRepository) RevokeSession(userID int, accessJTI string, accessExpiresAt int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	if err := denyJTI(tx, accessJTI, userID, accessExpiresAt, now); err != nil {
+		return err
+	}
+	where := `family_id IN (SELECT family_id FROM refresh_tokens WHERE access_jti = $1)`
+	if err := revokeFamilies(tx, where, accessJTI, now); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeAllForUser logs the user out everywhere.
+func (r *tokenRepository) RevokeAllForUser(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := revokeFamilies(tx, `user_id = $1`, userID, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *tokenRepository) IsRevoked(jti string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+	err := r.db.QueryRow(query, jti).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// revokeFamilies revokes every refresh token matching where (a condition on
+// refresh_tokens taking arg as $1) and deny-lists the access tokens issued
+// alongside them that have not expired yet.
+func revokeFamilies(tx *sql.Tx, where string, arg interface{}, now int64) error {
+	denyQuery := fmt.Sprintf(`
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		SELECT access_jti, user_id, access_expires_at, $2
+		FROM refresh_tokens
+		WHERE %s AND access_expires_at > $2
+		ON CONFLICT (jti) DO NOTHING
+	`, where)
+	if _, err := tx.Exec(denyQuery, arg, now); err != nil {
+		return fmt.Errorf("failed to deny-list access tokens: %w", err)
+	}
+
+	revokeQuery := fmt.Sprintf(`UPDATE refresh_tokens SET revoked_at = $2 WHERE %s AND revoked_at IS NULL`, where)
+	if _, err := tx.Exec(revokeQuery, arg, now); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	_, err := tx.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= $1`, now)
+	return err
+}
+
+func denyJTI(tx *sql.Tx, jti string, userID int, expiresAt, now int64) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	_, err := tx.Exec(query, jti, userID, expiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to deny-list access token: %w", err)
+	}
+	return nil
+}
+
+func NewTokenRepository(db *sql.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
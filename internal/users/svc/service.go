@@ -2,25 +2,44 @@ package svc
 
 import (
 	"errors"
-	"os"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	depEntity "github.com/ghulammuzz/backend-parkerin/internal/deposits/entity"
+	depRepo "github.com/ghulammuzz/backend-parkerin/internal/deposits/repo"
+	otpEntity "github.com/ghulammuzz/backend-parkerin/internal/otp/entity"
+	otpSvc "github.com/ghulammuzz/backend-parkerin/internal/otp/svc"
 	userEntity "github.com/ghulammuzz/backend-parkerin/internal/users/entity"
 	userRepo "github.com/ghulammuzz/backend-parkerin/internal/users/repo"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/utils"
 )
 
 type UserService interface {
 	ListUser(page, limit int) (*userEntity.UserListResponse, error)
 	RegisterUser(user *userEntity.UserRegisterRequest) error
-	LoginUser(user *userEntity.UserLoginRequest) (string, error)
-	LoginStore(user *userEntity.UserLoginRequest) (string, error)
+	LoginUser(user *userEntity.UserLoginRequest) (*userEntity.TokenResponse, error)
+	LoginStore(user *userEntity.UserLoginRequest) (*userEntity.TokenResponse, error)
+	RefreshToken(refreshToken string) (*userEntity.TokenResponse, error)
+	Logout(userID int, jti string, accessExpiresAt int64, all bool) error
+	SendVerificationCode(phoneNumber, ip string) error
+	VerifyPhone(phoneNumber, code string) (*userEntity.TokenResponse, error)
+	RequestPasswordReset(phoneNumber, ip string) error
+	ConfirmPasswordReset(req *userEntity.PasswordResetConfirmRequest, ip string) error
 	GetUserDetails(userID int) (*userEntity.UserDetailResponse, error)
+	DepositSummary(tukangID int) (*depEntity.Summary, error)
 	IsPhoneNumberExists(phone string) (bool, error)
 }
 
+var (
+	ErrPhoneNotVerified     = errors.New("phone number not verified")
+	ErrPhoneAlreadyVerified = errors.New("phone number already verified")
+)
+
 type userService struct {
-	userRepo userRepo.UserRepository
+	userRepo   userRepo.UserRepository
+	tokenRepo  userRepo.TokenRepository
+	otpService otpSvc.OTPService
+	depRepo    depRepo.DepositRepository
 }
 
 func (s *userService) ListUser(page int, limit int) (*userEntity.UserListResponse, error) {
@@ -31,30 +50,16 @@ func (s *userService) ListUser(page int, limit int) (*userEntity.UserListRespons
 	return users, nil
 }
 
-func (s *userService) LoginStore(user *userEntity.UserLoginRequest) (string, error) {
+func (s *userService) LoginStore(user *userEntity.UserLoginRequest) (*userEntity.TokenResponse, error) {
 	dbUser, err := s.userRepo.LoginUser(user)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"apps":    "parkirin-backend",
-		"user_id": dbUser.ID,
-		"role":    dbUser.Role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
-
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", errors.New("JWT_SECRET is not set in environment variables")
+	if !dbUser.IsVerified {
+		return nil, ErrPhoneNotVerified
 	}
 
-	tokenString, err := token.SignedString([]byte(secret))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
+	return s.issueTokens(dbUser.ID, dbUser.Role)
 }
 
 func (s *userService) IsPhoneNumberExists(phone string) (bool, error) {
@@ -65,44 +70,174 @@ func (s *userService) GetUserDetails(userID int) (*userEntity.UserDetailResponse
 	return s.userRepo.Detail(userID)
 }
 
+func (s *userService) DepositSummary(tukangID int) (*depEntity.Summary, error) {
+	return s.depRepo.Summary(0, tukangID)
+}
+
 func (s *userService) RegisterUser(user *userEntity.UserRegisterRequest) error {
 	if user.Role != "tukang" && user.Role != "store" {
 		return errors.New("invalid role")
 	}
-	if user.Role == "tukang" {
-		user.IsVerified = true
-	}
+	// phone ownership is proven later through VerifyPhone
+	user.IsVerified = false
 
 	return s.userRepo.Create(user)
 }
 
-func (s *userService) LoginUser(user *userEntity.UserLoginRequest) (string, error) {
+func (s *userService) LoginUser(user *userEntity.UserLoginRequest) (*userEntity.TokenResponse, error) {
 
 	dbUser, err := s.userRepo.LoginUser(user)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if !dbUser.IsVerified {
+		return nil, ErrPhoneNotVerified
+	}
+
+	return s.issueTokens(dbUser.ID, dbUser.Role)
+}
+
+func (s *userService) SendVerificationCode(phoneNumber, ip string) error {
+	verified, err := s.userRepo.IsPhoneNumberVerified(phoneNumber)
+	if err != nil {
+		return err
+	}
+	if verified {
+		return ErrPhoneAlreadyVerified
+	}
+
+	return s.otpService.Send(phoneNumber, otpEntity.PurposeVerifyPhone, ip)
+}
+
+func (s *userService) VerifyPhone(phoneNumber, code string) (*userEntity.TokenResponse, error) {
+	if err := s.otpService.Verify(phoneNumber, otpEntity.PurposeVerifyPhone, code); err != nil {
+		return nil, err
+	}
+
+	dbUser, err := s.userRepo.MarkVerified(phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(dbUser.ID, dbUser.Role)
+}
+
+// RequestPasswordReset sends a reset code when the number is registered. Unknown
+// numbers succeed silently so the endpoint cannot be used to probe accounts.
+func (s *userService) RequestPasswordReset(phoneNumber, ip string) error {
+	exists, err := s.userRepo.IsPhoneNumberExists(phoneNumber)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Warn("password reset requested for unknown number", "ip", ip)
+		return nil
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"apps":    "parkirin-backend",
-		"user_id": dbUser.ID,
-		"role":    dbUser.Role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
+	return s.otpService.Send(phoneNumber, otpEntity.PurposeResetPassword, ip)
+}
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", errors.New("JWT_SECRET is not set in environment variables")
+func (s *userService) ConfirmPasswordReset(req *userEntity.PasswordResetConfirmRequest, ip string) error {
+	if err := s.otpService.Verify(req.PhoneNumber, otpEntity.PurposeResetPassword, req.Code); err != nil {
+		return err
 	}
 
-	tokenString, err := token.SignedString([]byte(secret))
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	userID, err := s.userRepo.UpdatePassword(req.PhoneNumber, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+
+	log.Audit("password_reset", "user_id", userID, "ip", ip)
+	return nil
+}
+
+func (s *userService) RefreshToken(refreshToken string) (*userEntity.TokenResponse, error) {
+	next, raw, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.Rotate(utils.HashToken(refreshToken), next); err != nil {
+		return nil, err
+	}
+
+	storeID, err := s.storeIDFor(next.UserID, next.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := signAccessToken(next.UserID, next.Role, storeID, next.AccessJTI, next.AccessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userEntity.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: raw,
+		ExpiresIn:    next.AccessExpiresAt - time.Now().Unix(),
+	}, nil
+}
+
+func (s *userService) Logout(userID int, jti string, accessExpiresAt int64, all bool) error {
+	if err := s.tokenRepo.RevokeSession(userID, jti, accessExpiresAt); err != nil {
+		return err
+	}
+	if all {
+		return s.tokenRepo.RevokeAllForUser(userID)
+	}
+	return nil
+}
+
+// storeIDFor returns the store owned by a store user, or 0 for other roles.
+func (s *userService) storeIDFor(userID int, role string) (int, error) {
+	if role != "store" {
+		return 0, nil
+	}
+	return s.userRepo.StoreIDByUserID(userID)
+}
+
+// issueTokens starts a new refresh token family for the user.
+func (s *userService) issueTokens(userID int, role string) (*userEntity.TokenResponse, error) {
+	refresh, raw, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	refresh.UserID = userID
+	refresh.FamilyID, err = utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	storeID, err := s.storeIDFor(userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := signAccessToken(userID, role, storeID, refresh.AccessJTI, refresh.AccessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.Create(refresh); err != nil {
+		return nil, err
 	}
 
-	return tokenString, nil
+	return &userEntity.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: raw,
+		ExpiresIn:    refresh.AccessExpiresAt - time.Now().Unix(),
+	}, nil
 }
 
-func NewUserService(userRepo userRepo.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+func NewUserService(userRepo userRepo.UserRepository, tokenRepo userRepo.TokenRepository, otpService otpSvc.OTPService, depRepo depRepo.DepositRepository) UserService {
+	return &userService{userRepo: userRepo, tokenRepo: tokenRepo, otpService: otpService, depRepo: depRepo}
 }
@@ -0,0 +1,66 @@
+package svc
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	userEntity "github.com/ghulammuzz/backend-parkerin/internal/users/entity"
+	"github.com/ghulammuzz/backend-parkerin/pkg/utils"
+)
+
+const (
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// ttlFromEnv reads a duration such as "15m" from key, falling back to def.
+func ttlFromEnv(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+func signAccessToken(userID int, role string, storeID int, jti string, expiresAt int64) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.Claims{
+		Apps:    "parkirin-backend",
+		UserID:  userID,
+		Role:    role,
+		StoreID: storeID,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			ExpiresAt: expiresAt,
+		},
+	})
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return "", errors.New("JWT_SECRET is not set in environment variables")
+	}
+
+	return token.SignedString([]byte(secret))
+}
+
+// newRefreshToken returns an unsaved refresh token with a fresh access jti,
+// plus the raw token value that is handed to the client exactly once.
+func newRefreshToken() (*userEntity.RefreshToken, string, error) {
+	raw, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	jti, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	return &userEntity.RefreshToken{
+		TokenHash:       utils.HashToken(raw),
+		AccessJTI:       jti,
+		AccessExpiresAt: now.Add(ttlFromEnv("JWT_ACCESS_TTL", defaultAccessTTL)).Unix(),
+		ExpiresAt:       now.Add(ttlFromEnv("JWT_REFRESH_TTL", defaultRefreshTTL)).Unix(),
+	}, raw, nil
+}
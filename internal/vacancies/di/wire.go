@@ -0,0 +1,25 @@
+package di
+
+import (
+	"database/sql"
+
+	appRepo "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
+	storeRepo "github.com/ghulammuzz/backend-parkerin/internal/store/repo"
+	"github.com/ghulammuzz/backend-parkerin/internal/vacancies/handler"
+	vacRepo "github.com/ghulammuzz/backend-parkerin/internal/vacancies/repo"
+	vacSvc "github.com/ghulammuzz/backend-parkerin/internal/vacancies/svc"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/wire"
+)
+
+func InitializedVacancyServiceFake(sb *sql.DB, val *validator.Validate) *handler.VacancyHandler {
+	wire.Build(
+		handler.NewVacancyHandler,
+		vacSvc.NewVacancyService,
+		vacRepo.NewVacancyRepository,
+		storeRepo.NewStoreRepository,
+		appRepo.NewApplicationRepository,
+	)
+
+	return &handler.VacancyHandler{}
+}
@@ -0,0 +1,48 @@
+package entity
+
+import "github.com/ghulammuzz/backend-parkerin/pkg/schedule"
+
+const (
+	StatusOpen   = "open"
+	StatusFilled = "filled"
+	StatusClosed = "closed"
+)
+
+type Vacancy struct {
+	ID           int      `json:"id"`
+	StoreID      int      `json:"store_id"`
+	Title        string   `json:"title"`
+	ShiftStart   string   `json:"shift_start"`
+	ShiftEnd     string   `json:"shift_end"`
+	Days         []string `json:"days"`
+	Slots        int      `json:"slots"`
+	Filled       int      `json:"filled"`
+	Fee          int64    `json:"fee"`
+	Requirements string   `json:"requirements"`
+	Status       string   `json:"status"`
+	CreatedAt    int64    `json:"created_at"`
+	UpdatedAt    int64    `json:"updated_at"`
+}
+
+// ShiftPattern expresses the vacancy's shift as a weekly schedule, which is
+// also what an employment started from it records.
+func (v *Vacancy) ShiftPattern() *schedule.Schedule {
+	sched := &schedule.Schedule{
+		Timezone: schedule.DefaultTimezone,
+		Days:     make(map[string][]schedule.Range, len(v.Days)),
+	}
+	for _, day := range v.Days {
+		sched.Days[day] = []schedule.Range{{Open: v.ShiftStart, Close: v.ShiftEnd}}
+	}
+	return sched
+}
+
+type VacancyRequest struct {
+	Title        string   `json:"title" validate:"required,min=3,max=100"`
+	ShiftStart   string   `json:"shift_start" validate:"required,len=5"`
+	ShiftEnd     string   `json:"shift_end" validate:"required,len=5"`
+	Days         []string `json:"days" validate:"required,min=1,max=7,unique,dive,oneof=mon tue wed thu fri sat sun"`
+	Slots        int      `json:"slots" validate:"required,min=1,max=50"`
+	Fee          int64    `json:"fee" validate:"min=0"`
+	Requirements string   `json:"requirements" validate:"max=1000"`
+}
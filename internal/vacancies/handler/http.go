@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/ghulammuzz/backend-parkerin/internal/middleware"
+	storeSvc "github.com/ghulammuzz/backend-parkerin/internal/store/svc"
+	vacEntity "github.com/ghulammuzz/backend-parkerin/internal/vacancies/entity"
+	vacRepo "github.com/ghulammuzz/backend-parkerin/internal/vacancies/repo"
+	vacService "github.com/ghulammuzz/backend-parkerin/internal/vacancies/svc"
+	"github.com/ghulammuzz/backend-parkerin/pkg/form"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+	"github.com/ghulammuzz/backend-parkerin/pkg/response"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type VacancyHandler struct {
+	vacService vacService.VacancyService
+	val        *validator.Validate
+}
+
+func NewVacancyHandler(vacService vacService.VacancyService, val *validator.Validate) *VacancyHandler {
+	return &VacancyHandler{vacService, val}
+}
+
+func (h *VacancyHandler) Router(r fiber.Router) {
+	r.Get("/stores/:id/vacancies", h.ListOpenVacancies)
+	r.Get("/vacancies/store", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.ListStoreVacancies)
+	r.Post("/vacancies", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.CreateVacancy)
+	r.Put("/vacancies/:id", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.UpdateVacancy)
+	r.Put("/vacancies/:id/close", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.CloseVacancy)
+	r.Put("/vacancies/:id/reopen", middleware.JWTProtected(), middleware.RequireRole(middleware.RoleStore), h.ReopenVacancy)
+}
+
+func (h *VacancyHandler) ListOpenVacancies(c *fiber.Ctx) error {
+	storeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || storeID < 1 {
+		log.Error("Invalid store ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid store ID", nil)
+	}
+
+	vacancies, err := h.vacService.ListOpen(storeID)
+	if err != nil {
+		log.Error("Failed to retrieve vacancies", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve vacancies", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Vacancies retrieved successfully", vacancies)
+}
+
+func (h *VacancyHandler) ListStoreVacancies(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	vacancies, err := h.vacService.ListForStore(principal.StoreID)
+	if err != nil {
+		log.Error("Failed to retrieve vacancies", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusInternalServerError, "Failed to retrieve vacancies", err.Error())
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Vacancies retrieved successfully", vacancies)
+}
+
+func (h *VacancyHandler) CreateVacancy(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	req := new(vacEntity.VacancyRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	vacancy, err := h.vacService.Create(principal.StoreID, req)
+	if err != nil {
+		return h.vacancyError(c, "Failed to create vacancy", err)
+	}
+
+	return response.JSON(c, fiber.StatusCreated, "Vacancy created successfully", vacancy)
+}
+
+func (h *VacancyHandler) UpdateVacancy(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid vacancy ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid vacancy ID", nil)
+	}
+
+	req := new(vacEntity.VacancyRequest)
+	if err := c.BodyParser(req); err != nil {
+		log.Error("Payload error", slog.String("error", err.Error()))
+		return response.JSON(c, fiber.StatusBadRequest, "invalid payload", err.Error())
+	}
+	if err := h.val.Struct(req); err != nil {
+		validationErrors := form.ValidationErrorResponse(err)
+		log.Error("Validation failed", slog.Any("errors", validationErrors))
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", validationErrors)
+	}
+
+	vacancy, err := h.vacService.Update(principal.StoreID, id, req)
+	if err != nil {
+		return h.vacancyError(c, "Failed to update vacancy", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Vacancy updated successfully", vacancy)
+}
+
+func (h *VacancyHandler) CloseVacancy(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid vacancy ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid vacancy ID", nil)
+	}
+
+	if err := h.vacService.Close(principal.StoreID, id); err != nil {
+		return h.vacancyError(c, "Failed to close vacancy", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Vacancy closed", nil)
+}
+
+func (h *VacancyHandler) ReopenVacancy(c *fiber.Ctx) error {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		log.Error("Invalid token")
+		return response.JSON(c, fiber.StatusUnauthorized, "Invalid token", nil)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		log.Error("Invalid vacancy ID", slog.String("id", c.Params("id")))
+		return response.JSON(c, fiber.StatusBadRequest, "Invalid vacancy ID", nil)
+	}
+
+	if err := h.vacService.Reopen(principal.StoreID, id); err != nil {
+		return h.vacancyError(c, "Failed to reopen vacancy", err)
+	}
+
+	return response.JSON(c, fiber.StatusOK, "Vacancy reopened", nil)
+}
+
+func (h *VacancyHandler) vacancyError(c *fiber.Ctx, message string, err error) error {
+	log.Error(message, slog.String("error", err.Error()))
+	switch {
+	case errors.Is(err, vacRepo.ErrVacancyNotFound):
+		return response.JSON(c, fiber.StatusNotFound, "Vacancy not found", nil)
+	case errors.Is(err, vacService.ErrInvalidVacancy):
+		return response.JSON(c, fiber.StatusBadRequest, "Validation failed", err.Error())
+	case errors.Is(err, vacService.ErrSlotsBelowFilled), errors.Is(err, vacService.ErrVacancyFull):
+		return response.JSON(c, fiber.StatusConflict, err.Error(), nil)
+	case errors.Is(err, storeSvc.ErrSubscriptionRequired):
+		return response.JSON(c, fiber.StatusPaymentRequired, err.Error(), nil)
+	case errors.Is(err, storeSvc.ErrStoreNotApproved):
+		return response.JSON(c, fiber.StatusForbidden, err.Error(), nil)
+	}
+	return response.JSON(c, fiber.StatusInternalServerError, message, err.Error())
+}
@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	vacEntity "github.com/ghulammuzz/backend-parkerin/internal/vacancies/entity"
+	"github.com/lib/pq"
+)
+
+var ErrVacancyNotFound = errors.New("vacancy not found")
+
+type VacancyRepository interface {
+	Create(vacancy *vacEntity.Vacancy) error
+	Update(vacancy *vacEntity.Vacancy) error
+	Detail(id int) (*vacEntity.Vacancy, error)
+	ListByStore(storeID int, onlyOpen bool) ([]vacEntity.Vacancy, error)
+	SetStatus(id int, status string) error
+}
+
+type vacancyRepository struct {
+	db *sql.DB
+}
+
+// selectVacancy counts active employments started from the vacancy as its
+// filled slots.
+const selectVacancy = `
+	SELECT v.id, v.store_id, v.title, v.shift_start, v.shift_end, v.days, v.slots,
+	       (SELECT COUNT(*) FROM employments e WHERE e.vacancy_id = v.id AND e.status = 'active'),
+	       v.fee, v.requirements, v.status, v.created_at, v.updated_at
+	FROM vacancies v
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanVacancy(row rowScanner) (*vacEntity.Vacancy, error) {
+	vacancy := &vacEntity.Vacancy{}
+	err := row.Scan(
+		&vacancy.ID,
+		&vacancy.StoreID,
+		&vacancy.Title,
+		&vacancy.ShiftStart,
+		&vacancy.ShiftEnd,
+		pq.Array(&vacancy.Days),
+		&vacancy.Slots,
+		&vacancy.Filled,
+		&vacancy.Fee,
+		&vacancy.Requirements,
+		&vacancy.Status,
+		&vacancy.CreatedAt,
+		&vacancy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return vacancy, nil
+}
+
+func (r *vacancyRepository) Create(vacancy *vacEntity.Vacancy) error {
+	query := `
+		INSERT INTO vacancies (store_id, title, shift_start, shift_end, days, slots, fee, requirements, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		RETURNING id
+	`
+	vacancy.CreatedAt = time.Now().Unix()
+	vacancy.UpdatedAt = vacancy.CreatedAt
+
+	err := r.db.QueryRow(query, vacancy.StoreID, vacancy.Title, vacancy.ShiftStart, vacancy.ShiftEnd, pq.Array(vacancy.Days),
+		vacancy.Slots, vacancy.Fee, vacancy.Requirements, vacancy.Status, vacancy.CreatedAt).Scan(&vacancy.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create vacancy: %w", err)
+	}
+	return nil
+}
+
+func (r *vacancyRepository) Update(vacancy *vacEntity.Vacancy) error {
+	query := `
+		UPDATE vacancies
+		SET title = $1, shift_start = $2, shift_end = $3, days = $4, slots = $5, fee = $6,
+		    requirements = $7, status = $8, updated_at = $9
+		WHERE id = $10
+	`
+	vacancy.UpdatedAt = time.Now().Unix()
+
+	result, err := r.db.Exec(query, vacancy.Title, vacancy.ShiftStart, vacancy.ShiftEnd, pq.Array(vacancy.Days), vacancy.Slots,
+		vacancy.Fee, vacancy.Requirements, vacancy.Status, vacancy.UpdatedAt, vacancy.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update vacancy: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrVacancyNotFound
+	}
+	return nil
+}
+
+func (r *vacancyRepository) Detail(id int) (*vacEntity.Vacancy, error) {
+	vacancy, err := scanVacancy(r.db.QueryRow(selectVacancy+` WHERE v.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVacancyNotFound
+		}
+		return nil, err
+	}
+	return vacancy, nil
+}
+
+func (r *vacancyRepository) ListByStore(storeID int, onlyOpen bool) ([]vacEntity.Vacancy, error) {
+	query := selectVacancy + `
+		WHERE v.store_id = $1 AND (v.status = 'open' OR $2 = false)
+		ORDER BY v.status = 'open' DESC, v.created_at DESC
+	`
+	rows, err := r.db.Query(query, storeID, onlyOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vacancies := []vacEntity.Vacancy{}
+	for rows.Next() {
+		vacancy, err := scanVacancy(rows)
+		if err != nil {
+			return nil, err
+		}
+		vacancies = append(vacancies, *vacancy)
+	}
+	return vacancies, rows.Err()
+}
+
+func (r *vacancyRepository) SetStatus(id int, status string) error {
+	result, err := r.db.Exec(`UPDATE vacancies SET status = $1, updated_at = $2 WHERE id = $3`, status, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update vacancy status: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrVacancyNotFound
+	}
+	return nil
+}
+
+func NewVacancyRepository(db *sql.DB) VacancyRepository {
+	return &vacancyRepository{db: db}
+}
@@ -0,0 +1,186 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+
+	appRepo "github.com/ghulammuzz/backend-parkerin/internal/applicants/repo"
+	storeEntity "github.com/ghulammuzz/backend-parkerin/internal/store/entity"
+	storeRepo "github.com/ghulammuzz/backend-parkerin/internal/store/repo"
+	storeSvc "github.com/ghulammuzz/backend-parkerin/internal/store/svc"
+	vacEntity "github.com/ghulammuzz/backend-parkerin/internal/vacancies/entity"
+	vacRepo "github.com/ghulammuzz/backend-parkerin/internal/vacancies/repo"
+	"github.com/ghulammuzz/backend-parkerin/pkg/log"
+)
+
+var (
+	ErrInvalidVacancy   = errors.New("invalid vacancy")
+	ErrSlotsBelowFilled = errors.New("slots cannot be lower than the number of tukang already hired")
+	ErrVacancyFull      = errors.New("vacancy has no free slots")
+)
+
+type VacancyService interface {
+	ListOpen(storeID int) ([]vacEntity.Vacancy, error)
+	ListForStore(storeID int) ([]vacEntity.Vacancy, error)
+	Create(storeID int, req *vacEntity.VacancyRequest) (*vacEntity.Vacancy, error)
+	Update(storeID, id int, req *vacEntity.VacancyRequest) (*vacEntity.Vacancy, error)
+	Close(storeID, id int) error
+	Reopen(storeID, id int) error
+}
+
+type vacancyService struct {
+	vacRepo   vacRepo.VacancyRepository
+	storeRepo storeRepo.StoreRepository
+	appRepo   appRepo.ApplicationRepository
+}
+
+func (s *vacancyService) ListOpen(storeID int) ([]vacEntity.Vacancy, error) {
+	return s.vacRepo.ListByStore(storeID, true)
+}
+
+func (s *vacancyService) ListForStore(storeID int) ([]vacEntity.Vacancy, error) {
+	return s.vacRepo.ListByStore(storeID, false)
+}
+
+func (s *vacancyService) Create(storeID int, req *vacEntity.VacancyRequest) (*vacEntity.Vacancy, error) {
+	if err := s.requireCanHire(storeID); err != nil {
+		return nil, err
+	}
+
+	vacancy := &vacEntity.Vacancy{
+		StoreID: storeID,
+		Status:  vacEntity.StatusOpen,
+	}
+	if err := apply(vacancy, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.vacRepo.Create(vacancy); err != nil {
+		return nil, err
+	}
+	return vacancy, nil
+}
+
+// Update edits the vacancy. Changing the slots moves it between open and
+// filled; a closed vacancy stays closed until it is reopened.
+func (s *vacancyService) Update(storeID, id int, req *vacEntity.VacancyRequest) (*vacEntity.Vacancy, error) {
+	vacancy, err := s.owned(storeID, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Slots < vacancy.Filled {
+		return nil, ErrSlotsBelowFilled
+	}
+	if err := apply(vacancy, req); err != nil {
+		return nil, err
+	}
+
+	wasOpen := vacancy.Status == vacEntity.StatusOpen
+	switch {
+	case vacancy.Status == vacEntity.StatusFilled && vacancy.Slots > vacancy.Filled:
+		if err := s.requireCanHire(storeID); err != nil {
+			return nil, err
+		}
+		vacancy.Status = vacEntity.StatusOpen
+	case wasOpen && vacancy.Slots == vacancy.Filled:
+		vacancy.Status = vacEntity.StatusFilled
+	}
+
+	if err := s.vacRepo.Update(vacancy); err != nil {
+		return nil, err
+	}
+	if wasOpen && vacancy.Status != vacEntity.StatusOpen {
+		if err := s.appRepo.RejectPendingByVacancy(id); err != nil {
+			return nil, err
+		}
+	}
+	return vacancy, nil
+}
+
+func (s *vacancyService) Close(storeID, id int) error {
+	vacancy, err := s.owned(storeID, id)
+	if err != nil {
+		return err
+	}
+	if vacancy.Status == vacEntity.StatusClosed {
+		return nil
+	}
+
+	if err := s.vacRepo.SetStatus(id, vacEntity.StatusClosed); err != nil {
+		return err
+	}
+	log.Info("vacancy closed", "vacancy_id", id, "store_id", storeID)
+	return s.appRepo.RejectPendingByVacancy(id)
+}
+
+func (s *vacancyService) Reopen(storeID, id int) error {
+	vacancy, err := s.owned(storeID, id)
+	if err != nil {
+		return err
+	}
+	if vacancy.Status == vacEntity.StatusOpen {
+		return nil
+	}
+	if vacancy.Filled >= vacancy.Slots {
+		return ErrVacancyFull
+	}
+	if err := s.requireCanHire(storeID); err != nil {
+		return err
+	}
+	return s.vacRepo.SetStatus(id, vacEntity.StatusOpen)
+}
+
+func (s *vacancyService) owned(storeID, id int) (*vacEntity.Vacancy, error) {
+	vacancy, err := s.vacRepo.Detail(id)
+	if err != nil {
+		return nil, err
+	}
+	if vacancy.StoreID != storeID {
+		return nil, vacRepo.ErrVacancyNotFound
+	}
+	return vacancy, nil
+}
+
+// requireCanHire applies the same gate as turning is_hiring on: the store must
+// be approved and subscribed.
+func (s *vacancyService) requireCanHire(storeID int) error {
+	status, err := s.storeRepo.VerificationStatus(storeID)
+	if err != nil {
+		return err
+	}
+	if status != storeEntity.VerificationApproved {
+		return storeSvc.ErrStoreNotApproved
+	}
+
+	_, active, err := s.storeRepo.ActiveSubscriptionUntil(storeID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return storeSvc.ErrSubscriptionRequired
+	}
+	return nil
+}
+
+func apply(vacancy *vacEntity.Vacancy, req *vacEntity.VacancyRequest) error {
+	vacancy.Title = req.Title
+	vacancy.ShiftStart = req.ShiftStart
+	vacancy.ShiftEnd = req.ShiftEnd
+	vacancy.Days = req.Days
+	vacancy.Slots = req.Slots
+	vacancy.Fee = req.Fee
+	vacancy.Requirements = req.Requirements
+
+	if err := vacancy.ShiftPattern().Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidVacancy, err)
+	}
+	return nil
+}
+
+func NewVacancyService(vacRepo vacRepo.VacancyRepository, storeRepo storeRepo.StoreRepository, appRepo appRepo.ApplicationRepository) VacancyService {
+	return &vacancyService{
+		vacRepo:   vacRepo,
+		storeRepo: storeRepo,
+		appRepo:   appRepo,
+	}
+}
@@ -0,0 +1,54 @@
+package geo
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle (haversine) distance between two points.
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return earthRadiusKm * 2 * math.Asin(math.Sqrt(a))
+}
+
+// Box is a latitude/longitude rectangle that contains every point within a
+// radius of its center. It is used as a cheap index prefilter before the
+// exact distance check.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+}
+
+func BoundingBox(lat, lng, radiusKm float64) Box {
+	dLat := radiusKm / 111.32
+	box := Box{
+		MinLat: math.Max(lat-dLat, -90),
+		MaxLat: math.Min(lat+dLat, 90),
+		MinLng: -180,
+		MaxLng: 180,
+	}
+
+	// near the poles (or for huge radii) a longitude window is meaningless
+	cosLat := math.Cos(toRadians(lat))
+	if cosLat > 0.01 {
+		dLng := radiusKm / (111.32 * cosLat)
+		if dLng < 180 {
+			box.MinLng = lng - dLng
+			box.MaxLng = lng + dLng
+		}
+	}
+
+	return box
+}
+
+func ValidCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
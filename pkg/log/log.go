@@ -61,3 +61,11 @@ func Error(msg string, args ...interface{}) {
 		Logger.Error(msg, args...)
 	}
 }
+
+// Audit records a security-relevant event (password reset, moderation, ...)
+// with a fixed "audit" marker so it can be queried separately in Loki.
+func Audit(event string, args ...interface{}) {
+	if Logger != nil {
+		Logger.With("audit", true, "event", event).Info("audit: "+event, args...)
+	}
+}
@@ -0,0 +1,268 @@
+// Package schedule models a store's weekly opening hours: per-weekday ranges,
+// ranges that run past midnight, and date-specific holiday overrides, all
+// evaluated in an Indonesian timezone.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultTimezone = "Asia/Jakarta"
+
+// zones are fixed offsets so evaluation does not depend on the container
+// shipping tzdata. None of them observe DST.
+var zones = map[string]*time.Location{
+	"Asia/Jakarta":  time.FixedZone("WIB", 7*60*60),
+	"Asia/Makassar": time.FixedZone("WITA", 8*60*60),
+	"Asia/Jayapura": time.FixedZone("WIT", 9*60*60),
+}
+
+// weekdays is indexed by time.Weekday.
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// displayOrder starts the week on Monday, as it is printed locally.
+var displayOrder = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+var weekdayLabels = map[string]string{
+	"sun": "Min", "mon": "Sen", "tue": "Sel", "wed": "Rab", "thu": "Kam", "fri": "Jum", "sat": "Sab",
+}
+
+// Range is an opening window in "HH:MM". A Close at or before Open means the
+// window runs past midnight into the next day; "24:00" closes at midnight.
+type Range struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// Override replaces the weekly hours for one date ("2006-01-02"). Closed
+// wins over Ranges.
+type Override struct {
+	Date   string  `json:"date"`
+	Closed bool    `json:"closed"`
+	Ranges []Range `json:"ranges,omitempty"`
+	Note   string  `json:"note,omitempty"`
+}
+
+type Schedule struct {
+	Timezone string             `json:"timezone"`
+	Days     map[string][]Range `json:"days"`
+	Holidays []Override         `json:"holidays,omitempty"`
+}
+
+func (s *Schedule) Location() *time.Location {
+	if loc, ok := zones[s.Timezone]; ok {
+		return loc
+	}
+	return zones[DefaultTimezone]
+}
+
+// Validate normalizes an empty timezone to DefaultTimezone and rejects unknown
+// weekdays, malformed times, zero-length or overlapping ranges and duplicate
+// holiday dates.
+func (s *Schedule) Validate() error {
+	if s.Timezone == "" {
+		s.Timezone = DefaultTimezone
+	}
+	if _, ok := zones[s.Timezone]; !ok {
+		return fmt.Errorf("unsupported timezone %q", s.Timezone)
+	}
+
+	hasRange := false
+	for day, ranges := range s.Days {
+		if _, ok := weekdayLabels[day]; !ok {
+			return fmt.Errorf("unknown weekday %q, use sun..sat", day)
+		}
+		if err := validateRanges(ranges); err != nil {
+			return fmt.Errorf("%s: %w", day, err)
+		}
+		hasRange = hasRange || len(ranges) > 0
+	}
+	if !hasRange {
+		return errors.New("schedule must have at least one opening range")
+	}
+
+	seen := make(map[string]bool, len(s.Holidays))
+	for _, h := range s.Holidays {
+		if _, err := time.Parse(time.DateOnly, h.Date); err != nil {
+			return fmt.Errorf("invalid holiday date %q", h.Date)
+		}
+		if seen[h.Date] {
+			return fmt.Errorf("duplicate holiday date %s", h.Date)
+		}
+		seen[h.Date] = true
+		if !h.Closed && len(h.Ranges) == 0 {
+			return fmt.Errorf("holiday %s must be closed or have ranges", h.Date)
+		}
+		if err := validateRanges(h.Ranges); err != nil {
+			return fmt.Errorf("holiday %s: %w", h.Date, err)
+		}
+	}
+	return nil
+}
+
+// IsOpenAt reports whether the store is open at t. A range that crosses
+// midnight is honoured on the following day too, using the ranges that apply
+// to the previous date (holiday overrides included).
+func (s *Schedule) IsOpenAt(t time.Time) bool {
+	local := t.In(s.Location())
+	now := local.Hour()*60 + local.Minute()
+
+	for _, r := range s.rangesOn(local) {
+		open, close := minutes(r.Open), minutes(r.Close)
+		if close > open {
+			if now >= open && now < close {
+				return true
+			}
+		} else if now >= open {
+			return true
+		}
+	}
+
+	for _, r := range s.rangesOn(local.AddDate(0, 0, -1)) {
+		open, close := minutes(r.Open), minutes(r.Close)
+		if close <= open && now < close {
+			return true
+		}
+	}
+	return false
+}
+
+// Span is one range of a given date as absolute times; End falls on the next
+// day for ranges crossing midnight.
+type Span struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SpansOn returns the ranges that start on the date of day, in the
+// schedule's zone, holiday overrides included.
+func (s *Schedule) SpansOn(day time.Time) []Span {
+	local := day.In(s.Location())
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+
+	var spans []Span
+	for _, r := range s.rangesOn(local) {
+		open, close := minutes(r.Open), minutes(r.Close)
+		if close <= open {
+			close += 24 * 60
+		}
+		spans = append(spans, Span{
+			Start: midnight.Add(time.Duration(open) * time.Minute),
+			End:   midnight.Add(time.Duration(close) * time.Minute),
+		})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+	return spans
+}
+
+func (s *Schedule) rangesOn(day time.Time) []Range {
+	date := day.Format(time.DateOnly)
+	for _, h := range s.Holidays {
+		if h.Date == date {
+			if h.Closed {
+				return nil
+			}
+			return h.Ranges
+		}
+	}
+	return s.Days[weekdays[day.Weekday()]]
+}
+
+// String renders the weekly hours in the legacy working_hours format, e.g.
+// "Sen-Jum 08:00-17:00, Sab 08:00-12:00", grouping consecutive days that share
+// the same ranges. Holidays are not included.
+func (s *Schedule) String() string {
+	type group struct {
+		from, to string
+		hours    string
+	}
+	var groups []group
+	for _, day := range displayOrder {
+		ranges := s.Days[day]
+		if len(ranges) == 0 {
+			continue
+		}
+		parts := make([]string, len(ranges))
+		for i, r := range ranges {
+			parts[i] = r.Open + "-" + r.Close
+		}
+		hours := strings.Join(parts, ", ")
+		if n := len(groups); n > 0 && groups[n-1].hours == hours && next(groups[n-1].to) == day {
+			groups[n-1].to = day
+			continue
+		}
+		groups = append(groups, group{from: day, to: day, hours: hours})
+	}
+
+	out := make([]string, len(groups))
+	for i, g := range groups {
+		days := weekdayLabels[g.from]
+		if g.to != g.from {
+			days += "-" + weekdayLabels[g.to]
+		}
+		out[i] = days + " " + g.hours
+	}
+	return strings.Join(out, ", ")
+}
+
+func validateRanges(ranges []Range) error {
+	type span struct{ from, to int }
+	var spans []span
+	for _, r := range ranges {
+		open, ok := parseClock(r.Open, false)
+		if !ok {
+			return fmt.Errorf("invalid open time %q, use HH:MM", r.Open)
+		}
+		close, ok := parseClock(r.Close, true)
+		if !ok {
+			return fmt.Errorf("invalid close time %q, use HH:MM", r.Close)
+		}
+		if open == close {
+			return fmt.Errorf("range %s-%s is empty", r.Open, r.Close)
+		}
+		if close < open {
+			close += 24 * 60
+		}
+		spans = append(spans, span{open, close})
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].from < spans[j].from })
+	for i := 1; i < len(spans); i++ {
+		if spans[i].from < spans[i-1].to {
+			return errors.New("ranges overlap")
+		}
+	}
+	return nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight. "24:00" is only
+// accepted as a closing time.
+func parseClock(v string, closing bool) (int, bool) {
+	if closing && v == "24:00" {
+		return 24 * 60, true
+	}
+	t, err := time.Parse("15:04", v)
+	if err != nil || len(v) != 5 {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// minutes assumes v already passed validation.
+func minutes(v string) int {
+	m, _ := parseClock(v, true)
+	return m
+}
+
+func next(day string) string {
+	for i, d := range weekdays {
+		if d == day {
+			return weekdays[(i+1)%len(weekdays)]
+		}
+	}
+	return ""
+}
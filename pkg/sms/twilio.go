@@ -0,0 +1,69 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const twilioBaseURL = "https://api.twilio.com"
+
+// Twilio sends through Twilio's Messages API. From is the sending number in
+// E.164; prefix it with "whatsapp:" to send over WhatsApp instead of SMS.
+type Twilio struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	// BaseURL overrides the API host, for tests.
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewTwilio(accountSID, authToken, from string) *Twilio {
+	return &Twilio{
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		From:       from,
+		BaseURL:    twilioBaseURL,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *Twilio) Send(phoneNumber, message string) error {
+	to := phoneNumber
+	if strings.HasPrefix(t.From, "whatsapp:") {
+		to = "whatsapp:" + phoneNumber
+	}
+	form := url.Values{"From": {t.From}, "To": {to}, "Body": {message}}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", t.BaseURL, url.PathEscape(t.AccountSID))
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("twilio: %d %s (code %d)", resp.StatusCode, apiErr.Message, apiErr.Code)
+		}
+		return fmt.Errorf("twilio: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
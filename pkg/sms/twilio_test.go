@@ -0,0 +1,66 @@
+package sms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTwilioSend(t *testing.T) {
+	cases := []struct {
+		name    string
+		from    string
+		status  int
+		body    string
+		wantTo  string
+		wantErr string
+	}{
+		{"sms", "+15005550006", http.StatusCreated, `{"sid":"SM1"}`, "+6281234567890", ""},
+		{"whatsapp", "whatsapp:+14155238886", http.StatusCreated, `{"sid":"SM1"}`, "whatsapp:+6281234567890", ""},
+		{"api error", "+15005550006", http.StatusBadRequest, `{"code":21211,"message":"Invalid 'To' Phone Number"}`, "+6281234567890", "Invalid 'To' Phone Number"},
+		{"no body", "+15005550006", http.StatusInternalServerError, ``, "+6281234567890", "unexpected status 500"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				if sid, token, ok := r.BasicAuth(); !ok || sid != "AC123" || token != "secret" {
+					t.Errorf("basic auth = %s:%s", sid, token)
+				}
+				if err := r.ParseForm(); err != nil {
+					t.Fatal(err)
+				}
+				if got := r.PostForm.Get("From"); got != tc.from {
+					t.Errorf("From = %q, want %q", got, tc.from)
+				}
+				if got := r.PostForm.Get("To"); got != tc.wantTo {
+					t.Errorf("To = %q, want %q", got, tc.wantTo)
+				}
+				if got := r.PostForm.Get("Body"); got != "kode 123456" {
+					t.Errorf("Body = %q", got)
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			sender := NewTwilio("AC123", "secret", tc.from)
+			sender.BaseURL = srv.URL
+
+			err := sender.Send("+6281234567890", "kode 123456")
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("send: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want it to mention %q", err, tc.wantErr)
+			}
+		})
+	}
+}
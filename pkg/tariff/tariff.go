@@ -0,0 +1,201 @@
+// Package tariff computes parking fees from a store's per-vehicle rules.
+// Amounts are whole Rupiah.
+package tariff
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	ModeFlat        = "flat"
+	ModeProgressive = "progressive"
+)
+
+const day = 24 * time.Hour
+
+// Rule is what a store charges for one vehicle type.
+//
+// A flat rule charges FlatFee per started 24 hours. A progressive rule charges
+// FirstHourFee for the first started hour and NextHourFee for every further
+// started hour; each 24 hours starts again at the first hour. DailyCap, when
+// set, limits what one 24 hour block costs before surcharges. Stays no longer
+// than GraceMinutes are free. NightSurcharge is added once for every night
+// window (NightStart to NightEnd, wall clock in the store's zone) the stay
+// overlaps.
+type Rule struct {
+	VehicleType    string `json:"vehicle_type" validate:"required,oneof=motor mobil truk"`
+	Mode           string `json:"mode" validate:"required,oneof=flat progressive"`
+	FlatFee        int64  `json:"flat_fee" validate:"min=0"`
+	FirstHourFee   int64  `json:"first_hour_fee" validate:"min=0"`
+	NextHourFee    int64  `json:"next_hour_fee" validate:"min=0"`
+	DailyCap       int64  `json:"daily_cap" validate:"min=0"`
+	GraceMinutes   int    `json:"grace_minutes" validate:"min=0,max=1440"`
+	NightSurcharge int64  `json:"night_surcharge" validate:"min=0"`
+	NightStart     string `json:"night_start,omitempty"`
+	NightEnd       string `json:"night_end,omitempty"`
+}
+
+// Line is one item of a Breakdown.
+type Line struct {
+	Label  string `json:"label"`
+	Amount int64  `json:"amount"`
+}
+
+type Breakdown struct {
+	VehicleType     string `json:"vehicle_type"`
+	Mode            string `json:"mode"`
+	EntryAt         int64  `json:"entry_at"`
+	ExitAt          int64  `json:"exit_at"`
+	DurationMinutes int    `json:"duration_minutes"`
+	Grace           bool   `json:"grace"`
+	BillableHours   int    `json:"billable_hours"`
+	Base            int64  `json:"base"`
+	CapApplied      bool   `json:"cap_applied"`
+	Nights          int    `json:"nights"`
+	Surcharge       int64  `json:"surcharge"`
+	Total           int64  `json:"total"`
+	Lines           []Line `json:"lines"`
+}
+
+// Validate checks what the struct tags cannot: the night window.
+func (r *Rule) Validate() error {
+	if r.Mode != ModeFlat && r.Mode != ModeProgressive {
+		return fmt.Errorf("unknown mode %q", r.Mode)
+	}
+	if r.FlatFee < 0 || r.FirstHourFee < 0 || r.NextHourFee < 0 || r.DailyCap < 0 || r.NightSurcharge < 0 || r.GraceMinutes < 0 {
+		return errors.New("fees and grace period cannot be negative")
+	}
+	if (r.NightStart == "") != (r.NightEnd == "") {
+		return errors.New("night_start and night_end go together")
+	}
+	if r.NightStart != "" {
+		start, ok := clock(r.NightStart)
+		if !ok {
+			return fmt.Errorf("invalid night_start %q, use HH:MM", r.NightStart)
+		}
+		end, ok := clock(r.NightEnd)
+		if !ok {
+			return fmt.Errorf("invalid night_end %q, use HH:MM", r.NightEnd)
+		}
+		if start == end {
+			return errors.New("night window is empty")
+		}
+	}
+	if r.NightSurcharge > 0 && r.NightStart == "" {
+		return errors.New("night_surcharge needs night_start and night_end")
+	}
+	return nil
+}
+
+// Quote prices a stay from entry to exit; loc is the zone the night window
+// is read in.
+func (r *Rule) Quote(entry, exit time.Time, loc *time.Location) (Breakdown, error) {
+	if err := r.Validate(); err != nil {
+		return Breakdown{}, err
+	}
+	if exit.Before(entry) {
+		return Breakdown{}, errors.New("exit is before entry")
+	}
+
+	stay := exit.Sub(entry)
+	b := Breakdown{
+		VehicleType:     r.VehicleType,
+		Mode:            r.Mode,
+		EntryAt:         entry.Unix(),
+		ExitAt:          exit.Unix(),
+		DurationMinutes: int(stay / time.Minute),
+		Lines:           []Line{},
+	}
+
+	if stay <= time.Duration(r.GraceMinutes)*time.Minute {
+		b.Grace = true
+		b.Lines = append(b.Lines, Line{Label: fmt.Sprintf("grace period (%d min)", r.GraceMinutes), Amount: 0})
+		return b, nil
+	}
+
+	// every started 24 hours is a block; a zero-length stay still is one
+	blocks := int(stay / day)
+	rest := stay % day
+	if rest > 0 || blocks == 0 {
+		blocks++
+	}
+
+	for i := 0; i < blocks; i++ {
+		length := day
+		if i == blocks-1 && rest > 0 {
+			length = rest
+		}
+		fee, hours := r.blockFee(length)
+		b.BillableHours += hours
+
+		label := fmt.Sprintf("day %d", i+1)
+		if r.Mode == ModeProgressive {
+			label = fmt.Sprintf("day %d (%d h)", i+1, hours)
+		}
+		if r.DailyCap > 0 && fee > r.DailyCap {
+			fee = r.DailyCap
+			b.CapApplied = true
+			label += ", capped"
+		}
+		b.Base += fee
+		b.Lines = append(b.Lines, Line{Label: label, Amount: fee})
+	}
+
+	if r.NightSurcharge > 0 {
+		b.Nights = r.nights(entry, exit, loc)
+		if b.Nights > 0 {
+			b.Surcharge = int64(b.Nights) * r.NightSurcharge
+			b.Lines = append(b.Lines, Line{Label: fmt.Sprintf("night surcharge x%d", b.Nights), Amount: b.Surcharge})
+		}
+	}
+
+	b.Total = b.Base + b.Surcharge
+	return b, nil
+}
+
+// blockFee prices one block of at most 24 hours, returning the fee and the
+// started hours it covers.
+func (r *Rule) blockFee(length time.Duration) (int64, int) {
+	hours := int(length / time.Hour)
+	if length%time.Hour > 0 || hours == 0 {
+		hours++
+	}
+	if r.Mode == ModeFlat {
+		return r.FlatFee, hours
+	}
+	return r.FirstHourFee + int64(hours-1)*r.NextHourFee, hours
+}
+
+// nights counts the night windows overlapping (entry, exit).
+func (r *Rule) nights(entry, exit time.Time, loc *time.Location) int {
+	start, _ := clock(r.NightStart)
+	end, _ := clock(r.NightEnd)
+
+	// a window starting the day before entry can still be running at entry
+	first := entry.In(loc).AddDate(0, 0, -1)
+	d := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+
+	count := 0
+	for ; !d.After(exit); d = d.AddDate(0, 0, 1) {
+		from := d.Add(time.Duration(start) * time.Minute)
+		to := d.Add(time.Duration(end) * time.Minute)
+		if end <= start {
+			to = to.AddDate(0, 0, 1)
+		}
+		if from.Before(exit) && to.After(entry) {
+			count++
+		}
+	}
+	return count
+}
+
+// clock parses "HH:MM" into minutes after midnight.
+func clock(v string) (int, bool) {
+	t, err := time.Parse("15:04", v)
+	if err != nil || len(v) != 5 {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
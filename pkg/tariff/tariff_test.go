@@ -0,0 +1,148 @@
+package tariff
+
+import (
+	"testing"
+	"time"
+)
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, time.January, day, hour, minute, 0, 0, wib)
+}
+
+func TestQuote(t *testing.T) {
+	flat := Rule{VehicleType: "motor", Mode: ModeFlat, FlatFee: 5000}
+	progressive := Rule{VehicleType: "mobil", Mode: ModeProgressive, FirstHourFee: 3000, NextHourFee: 2000}
+	graced := progressive
+	graced.GraceMinutes = 10
+	capped := progressive
+	capped.DailyCap = 20000
+	night := flat
+	night.NightSurcharge = 1000
+	night.NightStart = "22:00"
+	night.NightEnd = "06:00"
+	earlyNight := flat
+	earlyNight.NightSurcharge = 1000
+	earlyNight.NightStart = "00:00"
+	earlyNight.NightEnd = "05:00"
+
+	tests := []struct {
+		name   string
+		rule   Rule
+		entry  time.Time
+		exit   time.Time
+		total  int64
+		hours  int
+		grace  bool
+		capped bool
+		nights int
+	}{
+		{name: "flat, short stay", rule: flat, entry: at(5, 10, 0), exit: at(5, 10, 30), total: 5000, hours: 1},
+		{name: "flat, exactly 24h is one block", rule: flat, entry: at(5, 10, 0), exit: at(6, 10, 0), total: 5000, hours: 24},
+		{name: "flat, just over 24h starts a second block", rule: flat, entry: at(5, 10, 0), exit: at(6, 10, 1), total: 10000, hours: 25},
+		{name: "progressive, exactly one hour", rule: progressive, entry: at(5, 10, 0), exit: at(5, 11, 0), total: 3000, hours: 1},
+		{name: "progressive, one second into the second hour", rule: progressive, entry: at(5, 10, 0), exit: at(5, 11, 0).Add(time.Second), total: 5000, hours: 2},
+		{name: "progressive, exactly two hours", rule: progressive, entry: at(5, 10, 0), exit: at(5, 12, 0), total: 5000, hours: 2},
+		{name: "progressive, partial hour", rule: progressive, entry: at(5, 10, 0), exit: at(5, 12, 20), total: 7000, hours: 3},
+		{name: "grace equal to the stay is free", rule: graced, entry: at(5, 10, 0), exit: at(5, 10, 10), total: 0, grace: true},
+		{name: "just over grace is charged", rule: graced, entry: at(5, 10, 0), exit: at(5, 10, 10).Add(time.Second), total: 3000, hours: 1},
+		{name: "exit equal to entry is free", rule: progressive, entry: at(5, 10, 0), exit: at(5, 10, 0), total: 0, grace: true},
+		{name: "daily cap not reached", rule: capped, entry: at(5, 10, 0), exit: at(5, 18, 0), total: 17000, hours: 8},
+		{name: "daily cap applied", rule: capped, entry: at(5, 10, 0), exit: at(5, 22, 0), total: 20000, hours: 12, capped: true},
+		{name: "progressive, 25h restarts at the first hour", rule: progressive, entry: at(5, 10, 0), exit: at(6, 11, 0), total: 49000 + 3000, hours: 25},
+		{name: "capped, 25h caps the first day only", rule: capped, entry: at(5, 10, 0), exit: at(6, 11, 0), total: 20000 + 3000, hours: 25, capped: true},
+		{name: "capped, 48h is two capped days", rule: capped, entry: at(5, 10, 0), exit: at(7, 10, 0), total: 40000, hours: 48, capped: true},
+		{name: "capped, 50h", rule: capped, entry: at(5, 10, 0), exit: at(7, 12, 0), total: 40000 + 5000, hours: 50, capped: true},
+		{name: "night, inside the evening part", rule: night, entry: at(5, 21, 0), exit: at(5, 23, 0), total: 6000, hours: 2, nights: 1},
+		{name: "night, entry inside the window started the day before", rule: night, entry: at(5, 5, 0), exit: at(5, 7, 0), total: 6000, hours: 2, nights: 1},
+		{name: "night, daytime stay", rule: night, entry: at(5, 7, 0), exit: at(5, 21, 0), total: 5000, hours: 14},
+		{name: "night, exit exactly at the window start", rule: night, entry: at(5, 20, 0), exit: at(5, 22, 0), total: 5000, hours: 2},
+		{name: "night, entry exactly at the window end", rule: night, entry: at(5, 6, 0), exit: at(5, 8, 0), total: 5000, hours: 2},
+		{name: "night, stay across midnight", rule: night, entry: at(5, 23, 0), exit: at(6, 1, 0), total: 6000, hours: 2, nights: 1},
+		{name: "night, two nights", rule: night, entry: at(5, 21, 0), exit: at(7, 7, 0), total: 10000 + 2000, hours: 34, nights: 2},
+		{name: "night, three nights", rule: night, entry: at(5, 20, 0), exit: at(8, 8, 0), total: 15000 + 3000, hours: 60, nights: 3},
+		{name: "night window after midnight", rule: earlyNight, entry: at(5, 23, 0), exit: at(6, 1, 0), total: 6000, hours: 2, nights: 1},
+		{name: "night window after midnight, evening stay", rule: earlyNight, entry: at(5, 18, 0), exit: at(5, 23, 59), total: 5000, hours: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.rule.Quote(tt.entry, tt.exit, wib)
+			if err != nil {
+				t.Fatalf("Quote: %v", err)
+			}
+			if b.Total != tt.total {
+				t.Errorf("total = %d, want %d (lines %+v)", b.Total, tt.total, b.Lines)
+			}
+			if b.BillableHours != tt.hours {
+				t.Errorf("billable hours = %d, want %d", b.BillableHours, tt.hours)
+			}
+			if b.Grace != tt.grace {
+				t.Errorf("grace = %v, want %v", b.Grace, tt.grace)
+			}
+			if b.CapApplied != tt.capped {
+				t.Errorf("cap applied = %v, want %v", b.CapApplied, tt.capped)
+			}
+			if b.Nights != tt.nights {
+				t.Errorf("nights = %d, want %d", b.Nights, tt.nights)
+			}
+			if b.Total != b.Base+b.Surcharge {
+				t.Errorf("total %d is not base %d + surcharge %d", b.Total, b.Base, b.Surcharge)
+			}
+			var sum int64
+			for _, line := range b.Lines {
+				sum += line.Amount
+			}
+			if sum != b.Total {
+				t.Errorf("lines add up to %d, total is %d", sum, b.Total)
+			}
+		})
+	}
+}
+
+func TestQuoteExitBeforeEntry(t *testing.T) {
+	rule := Rule{VehicleType: "motor", Mode: ModeFlat, FlatFee: 5000}
+	if _, err := rule.Quote(at(5, 10, 0), at(5, 9, 59), wib); err == nil {
+		t.Fatal("expected an error for exit before entry")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		ok   bool
+	}{
+		{name: "flat", rule: Rule{Mode: ModeFlat, FlatFee: 5000}, ok: true},
+		{name: "night window", rule: Rule{Mode: ModeFlat, NightSurcharge: 1000, NightStart: "22:00", NightEnd: "06:00"}, ok: true},
+		{name: "unknown mode", rule: Rule{Mode: "hourly"}},
+		{name: "negative flat fee", rule: Rule{Mode: ModeFlat, FlatFee: -1}},
+		{name: "negative next hour fee", rule: Rule{Mode: ModeProgressive, NextHourFee: -1}},
+		{name: "negative daily cap", rule: Rule{Mode: ModeProgressive, DailyCap: -1}},
+		{name: "negative grace", rule: Rule{Mode: ModeFlat, GraceMinutes: -1}},
+		{name: "night start without end", rule: Rule{Mode: ModeFlat, NightStart: "22:00"}},
+		{name: "night end without start", rule: Rule{Mode: ModeFlat, NightEnd: "06:00"}},
+		{name: "invalid night start", rule: Rule{Mode: ModeFlat, NightStart: "25:00", NightEnd: "06:00"}},
+		{name: "night start without leading zero", rule: Rule{Mode: ModeFlat, NightStart: "22:00", NightEnd: "6:00"}},
+		{name: "empty night window", rule: Rule{Mode: ModeFlat, NightStart: "22:00", NightEnd: "22:00"}},
+		{name: "surcharge without window", rule: Rule{Mode: ModeFlat, NightSurcharge: 1000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.ok && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("expected a validation error")
+			}
+			if !tt.ok {
+				if _, err := tt.rule.Quote(at(5, 10, 0), at(5, 11, 0), wib); err == nil {
+					t.Fatal("Quote accepted an invalid rule")
+				}
+			}
+		})
+	}
+}